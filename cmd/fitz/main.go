@@ -12,7 +12,9 @@ var version = "dev"
 func main() {
 	cli.Version = version
 	if err := cli.Execute(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		if !cli.Reported(err) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(cli.ExitCode(err))
 	}
 }
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
+	"fitz/internal/cliapp"
 	"fitz/internal/config"
 	"fitz/internal/worktree"
 )
@@ -29,10 +31,13 @@ func (configCommand) Help(w io.Writer) {
 	fmt.Fprintln(w, "  --global    Operate on global config (~/.fitz/config.json)")
 	fmt.Fprintln(w, "              Default: repo-level config (~/.fitz/<owner>/<repo>/config.json)")
 	fmt.Fprintln(w)
-	fmt.Fprintln(w, "Valid keys: model, agent, branch-open-mode, branch-zellij-layout")
+	fmt.Fprintf(w, "Valid keys: %s\n", strings.Join(config.Keys, ", "))
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Key bindings: keys.<action>, set to comma-separated keys (e.g. keys.br.delete \"D,backspace\")")
+	fmt.Fprintf(w, "Actions: %s\n", strings.Join(config.KeyBindingNames(), ", "))
 }
 
-func (c configCommand) Run(_ context.Context, args []string, _ io.Reader, stdout, stderr io.Writer) error {
+func (c configCommand) Run(ctx context.Context, args []string, _ io.Reader, stdout, stderr io.Writer) error {
 	if len(args) == 0 {
 		c.Help(stdout)
 		return nil
@@ -76,10 +81,10 @@ func (c configCommand) Run(_ context.Context, args []string, _ io.Reader, stdout
 	case "unset":
 		return c.runUnset(configPath, rest)
 	case "list":
-		return c.runList(stdout, configPath)
+		return c.runList(ctx, stdout, configPath)
 	default:
 		c.Help(stderr)
-		return fmt.Errorf("unknown config subcommand: %s", subcommand)
+		return cliapp.Usagef("unknown config subcommand: %s", subcommand)
 	}
 }
 
@@ -107,7 +112,7 @@ func (c configCommand) resolvePath(global bool) (string, error) {
 
 func (c configCommand) runGet(w io.Writer, configPath string, args []string) error {
 	if len(args) != 1 {
-		return fmt.Errorf("usage: fitz config get <key>")
+		return cliapp.Usagef("usage: fitz config get <key>")
 	}
 	key := args[0]
 
@@ -118,7 +123,7 @@ func (c configCommand) runGet(w io.Writer, configPath string, args []string) err
 
 	value, ok := config.Get(cfg, key)
 	if !ok {
-		return fmt.Errorf("unknown config key: %s (valid keys: model, agent, branch-open-mode, branch-zellij-layout)", key)
+		return cliapp.Usagef("unknown config key: %s (valid keys: %s, keys.<action>)", key, strings.Join(config.Keys, ", "))
 	}
 	if value == "" {
 		fmt.Fprintf(w, "(not set)\n")
@@ -130,55 +135,61 @@ func (c configCommand) runGet(w io.Writer, configPath string, args []string) err
 
 func (c configCommand) runSet(configPath string, args []string) error {
 	if len(args) != 2 {
-		return fmt.Errorf("usage: fitz config set <key> <value>")
+		return cliapp.Usagef("usage: fitz config set <key> <value>")
 	}
 	key, value := args[0], args[1]
 
-	cfg, err := config.Load(configPath)
-	if err != nil {
-		return err
-	}
-
-	cfg, err = config.Set(cfg, key, value)
-	if err != nil {
-		return err
-	}
-
-	return config.Save(configPath, cfg)
+	return config.Update(configPath, func(cfg config.Config) (config.Config, error) {
+		return config.Set(cfg, key, value)
+	})
 }
 
 func (c configCommand) runUnset(configPath string, args []string) error {
 	if len(args) != 1 {
-		return fmt.Errorf("usage: fitz config unset <key>")
+		return cliapp.Usagef("usage: fitz config unset <key>")
 	}
 	key := args[0]
 
+	return config.Update(configPath, func(cfg config.Config) (config.Config, error) {
+		return config.Unset(cfg, key)
+	})
+}
+
+func (c configCommand) runList(ctx context.Context, w io.Writer, configPath string) error {
 	cfg, err := config.Load(configPath)
 	if err != nil {
 		return err
 	}
 
-	cfg, err = config.Unset(cfg, key)
-	if err != nil {
-		return err
+	result := configListResult{}
+	for _, key := range config.Keys {
+		if value, _ := config.Get(cfg, key); value != "" {
+			result[key] = &value
+		} else {
+			result[key] = nil
+		}
 	}
-
-	return config.Save(configPath, cfg)
+	for action, keys := range cfg.KeyBindings {
+		result["keys."+action] = &keys
+	}
+	return writeResult(ctx, w, result)
 }
 
-func (c configCommand) runList(w io.Writer, configPath string) error {
-	cfg, err := config.Load(configPath)
-	if err != nil {
-		return err
-	}
+// configListResult maps every config key to its value, or nil when unset,
+// plus the key bindings that are set.
+type configListResult map[string]*string
 
+func (r configListResult) WriteText(w io.Writer) {
 	for _, key := range config.Keys {
-		value, _ := config.Get(cfg, key)
-		if value == "" {
+		if value := r[key]; value == nil {
 			fmt.Fprintf(w, "%s=(not set)\n", key)
 		} else {
-			fmt.Fprintf(w, "%s=%s\n", key, value)
+			fmt.Fprintf(w, "%s=%s\n", key, *value)
+		}
+	}
+	for _, action := range config.KeyBindingNames() {
+		if value := r["keys."+action]; value != nil {
+			fmt.Fprintf(w, "keys.%s=%s\n", action, *value)
 		}
 	}
-	return nil
 }
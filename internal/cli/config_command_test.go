@@ -225,6 +225,25 @@ func TestConfigList_WithValues(t *testing.T) {
 	}
 }
 
+func TestConfigList_ShowsKeyBindingsThatAreSet(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, _, err := runConfigCmd(t, dir, []string{"--global", "set", "keys.br.delete", "D"}); err != nil {
+		t.Fatal(err)
+	}
+
+	out, _, err := runConfigCmd(t, dir, []string{"--global", "list"})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if !strings.Contains(out, "keys.br.delete=D") {
+		t.Errorf("list = %q, want keys.br.delete=D", out)
+	}
+	if strings.Contains(out, "keys.br.new") {
+		t.Errorf("list = %q, should only show bindings that are set", out)
+	}
+}
+
 func TestConfigSetAndGet_BranchOpenMode_Global(t *testing.T) {
 	dir := t.TempDir()
 
@@ -1,9 +1,12 @@
 package cli
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 
 	"github.com/alecthomas/kong"
@@ -16,6 +19,13 @@ var runUpdate = cliapp.Update
 var runAgentStatus = cliapp.AgentStatus
 var runAgentNotify = cliapp.AgentNotify
 var runReview = cliapp.Review
+var runSupervise = cliapp.SuperviseRun
+var runBrLogs = cliapp.BrLogs
+var runBrHistory = cliapp.BrHistory
+var runBrStop = cliapp.BrStop
+var runBrFanout = cliapp.BrFanout
+var runBrPick = cliapp.BrPick
+var runGC = cliapp.GC
 
 // Subcommand represents a command that has its own sub-subcommands.
 // Any such command must provide a Help method.
@@ -29,8 +39,12 @@ var subcommands = map[string]Subcommand{
 	"agent":  agentCommand{},
 	"br":     brCommand{},
 	"config": configCommand{},
+	"ls":     lsCommand{},
 	"review": reviewCommand{},
 	"todo":   todoCommand{},
+
+	// Hidden: launched by fitz itself to supervise background agent runs.
+	cliapp.SuperviseCommand: superviseCommand{},
 }
 
 type commandLine struct {
@@ -41,13 +55,59 @@ type commandLine struct {
 	Completion struct {
 		Shell string `arg:"" optional:"" help:"Target shell (bash or zsh)."`
 	} `cmd:"" help:"Print shell completion script."`
+	GC struct {
+		DryRun bool `help:"List what would be removed without removing it."`
+	} `cmd:"" name:"gc" help:"Remove status, todo links and directories left behind by removed worktrees."`
 	Agent  struct{} `cmd:"" help:"Workflow commands for agents to execute."`
 	Br     struct{} `cmd:"" help:"Manage worktrees."`
 	Review struct{} `cmd:"" help:"Review the current branch codebase."`
 	Todo   struct{} `cmd:"" help:"Quick per-repo todo list."`
 }
 
+// Execute runs the fitz command in args. A --json anywhere before a "--"
+// switches every command to machine-readable output: its structured result
+// (or, for commands without one, its text wrapped as {"output": ...}) is
+// written to stdout as JSON, and errors are written as
+// {"error": ..., "exit_code": ...} and returned as already reported.
 func Execute(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	asJSON, args := cutJSONFlag(args)
+	if !asJSON {
+		return execute(context.Background(), args, stdin, stdout, stderr)
+	}
+
+	out := &output{json: true}
+	var text bytes.Buffer
+	if err := execute(withOutput(context.Background(), out), args, stdin, &text, stderr); err != nil {
+		_ = writeJSON(stdout, jsonError{Error: err.Error(), ExitCode: ExitCode(err)})
+		return reportedError{err}
+	}
+	if out.result != nil {
+		return writeJSON(stdout, out.result)
+	}
+	return writeJSON(stdout, jsonText{Output: text.String()})
+}
+
+// cutJSONFlag reports whether args contain --json before the first "--" and
+// returns args without it. Arguments after "--" are passed on untouched, so
+// a literal --json can still be given to a command there.
+func cutJSONFlag(args []string) (bool, []string) {
+	found := false
+	rest := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		if arg == "--json" {
+			found = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return found, rest
+}
+
+func execute(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
 	if len(args) == 0 || args[0] == "help" || args[0] == "--help" || args[0] == "-h" {
 		printUsage(stdout)
 		return nil
@@ -60,7 +120,7 @@ func Execute(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
 			sub.Help(stdout)
 			return nil
 		}
-		return sub.Run(context.Background(), subArgs, stdin, stdout, stderr)
+		return sub.Run(ctx, subArgs, stdin, stdout, stderr)
 	}
 
 	cli := commandLine{}
@@ -78,23 +138,28 @@ func Execute(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
 	_, err = parser.Parse(args)
 	if err != nil {
 		printUsage(stderr)
-		return err
+		return cliapp.UsageError{Err: err}
 	}
 
 	switch commandName {
 	case "version":
-		err = cliapp.Version(context.Background(), stdout, currentVersion())
+		err = writeResult(ctx, stdout, cliapp.Version(ctx, currentVersion()))
 	case "update":
-		err = runUpdate(context.Background(), stdout, currentVersion(), cli.Update.Preview)
+		err = runUpdate(ctx, stdout, currentVersion(), cli.Update.Preview)
 	case "completion":
 		completionArgs := []string{}
 		if shell := strings.TrimSpace(cli.Completion.Shell); shell != "" {
 			completionArgs = append(completionArgs, shell)
 		}
-		err = cliapp.Completion(context.Background(), stdout, completionArgs)
+		err = cliapp.Completion(ctx, stdout, completionArgs)
+	case "gc":
+		var result cliapp.GCResult
+		if result, err = runGC(ctx, cli.GC.DryRun); err == nil {
+			err = writeResult(ctx, stdout, result)
+		}
 	default:
 		printUsage(stderr)
-		return fmt.Errorf("unknown command: %s", commandName)
+		return cliapp.Usagef("unknown command: %s", commandName)
 	}
 
 	if err != nil {
@@ -113,11 +178,16 @@ func printUsage(w io.Writer) {
 	fmt.Fprintln(w, "  br            Manage worktrees")
 	fmt.Fprintln(w, "  completion    Print shell completion script")
 	fmt.Fprintln(w, "  config        Get and set configuration values")
+	fmt.Fprintln(w, "  gc            Clean up after removed worktrees (--dry-run to preview)")
 	fmt.Fprintln(w, "  help          Show this help message")
+	fmt.Fprintln(w, "  ls            List worktrees across every repo")
 	fmt.Fprintln(w, "  review        Review the current branch codebase")
 	fmt.Fprintln(w, "  todo          Quick per-repo todo list")
 	fmt.Fprintln(w, "  update        Update fitz to the latest release")
 	fmt.Fprintln(w, "  version       Print version information")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --json        Print results and errors as JSON")
 }
 
 func isHelpArg(s string) bool {
@@ -133,7 +203,7 @@ func parseBrNewArgs(args []string) (name, base, prompt string, err error) {
 		if args[i] == "--base" {
 			i++
 			if i >= len(args) {
-				return "", "", "", fmt.Errorf("usage: fitz br new [--base <branch>] <name> [prompt...]")
+				return "", "", "", cliapp.Usagef("usage: fitz br new [--base <branch>] <name> [prompt...]")
 			}
 			base = args[i]
 		} else {
@@ -141,7 +211,7 @@ func parseBrNewArgs(args []string) (name, base, prompt string, err error) {
 		}
 	}
 	if len(positional) == 0 {
-		return "", "", "", fmt.Errorf("usage: fitz br new [--base <branch>] <name> [prompt...]")
+		return "", "", "", cliapp.Usagef("usage: fitz br new [--base <branch>] <name> [prompt...]")
 	}
 	name = positional[0]
 	if len(positional) > 1 {
@@ -150,26 +220,164 @@ func parseBrNewArgs(args []string) (name, base, prompt string, err error) {
 	return name, base, prompt, nil
 }
 
-func parseAgentStatusArgs(args []string) (message, prURL string, err error) {
+func parseAgentStatusArgs(args []string) (message, prURL string, update cliapp.AgentStateUpdate, err error) {
+	usage := cliapp.Usagef("usage: fitz agent status [--pr <url>] [--state <state> [--progress <fraction>] [--detail <text>]] [message]")
 	var positional []string
 	for i := 0; i < len(args); i++ {
-		if args[i] == "--pr" {
-			i++
-			if i >= len(args) {
-				return "", "", fmt.Errorf("usage: fitz agent status [--pr <url>] [message]")
-			}
-			prURL = args[i]
-		} else {
+		var value *string
+		switch args[i] {
+		case "--pr":
+			value = &prURL
+		case "--state":
+			value = &update.State
+		case "--progress":
+			value = &update.Progress
+		case "--detail":
+			value = &update.Detail
+		default:
 			positional = append(positional, args[i])
+			continue
 		}
+		i++
+		if i >= len(args) {
+			return "", "", cliapp.AgentStateUpdate{}, usage
+		}
+		*value = args[i]
 	}
 	if len(positional) > 0 {
 		message = strings.Join(positional, " ")
 	}
-	if strings.TrimSpace(message) == "" && strings.TrimSpace(prURL) == "" {
-		return "", "", fmt.Errorf("usage: fitz agent status [--pr <url>] [message]")
+	if strings.TrimSpace(message) == "" && strings.TrimSpace(prURL) == "" && strings.TrimSpace(update.State) == "" {
+		return "", "", cliapp.AgentStateUpdate{}, usage
+	}
+	return message, prURL, update, nil
+}
+
+// listFormat defaults `br list` and `ls` to JSON under --json.
+func listFormat(ctx context.Context, format string) string {
+	if format == "" && jsonMode(ctx) {
+		return "json"
+	}
+	return format
+}
+
+// parseBrListArgs extracts --format from the arguments after "list".
+func parseBrListArgs(args []string) (string, error) {
+	return parseFormatArgs(args, "usage: fitz br list [--format table|json|tsv]")
+}
+
+// parseFormatArgs extracts a lone --format flag from args, failing with
+// usage on anything else.
+func parseFormatArgs(args []string, usageText string) (string, error) {
+	usage := cliapp.UsageError{Err: errors.New(usageText)}
+	switch {
+	case len(args) == 0:
+		return "", nil
+	case len(args) == 1 && strings.HasPrefix(args[0], "--format="):
+		return strings.TrimPrefix(args[0], "--format="), nil
+	case len(args) == 2 && args[0] == "--format":
+		return args[1], nil
+	default:
+		return "", usage
 	}
-	return message, prURL, nil
+}
+
+// parseBrLogsArgs extracts the worktree name and options from the arguments
+// after "logs".
+func parseBrLogsArgs(args []string) (name, runID string, follow bool, tail int, err error) {
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--follow", "-f":
+			follow = true
+		case "--tail", "-n":
+			i++
+			if i >= len(args) {
+				return "", "", false, 0, cliapp.Usagef("usage: fitz br logs <name> [--follow] [--tail <n>] [--run <id>]")
+			}
+			tail, err = strconv.Atoi(args[i])
+			if err != nil || tail < 0 {
+				return "", "", false, 0, cliapp.Usagef("invalid --tail value: %s", args[i])
+			}
+		case "--run":
+			i++
+			if i >= len(args) {
+				return "", "", false, 0, cliapp.Usagef("usage: fitz br logs <name> [--follow] [--tail <n>] [--run <id>]")
+			}
+			runID = args[i]
+		default:
+			if name != "" {
+				return "", "", false, 0, cliapp.Usagef("usage: fitz br logs <name> [--follow] [--tail <n>] [--run <id>]")
+			}
+			name = args[i]
+		}
+	}
+	if name == "" {
+		return "", "", false, 0, cliapp.Usagef("usage: fitz br logs <name> [--follow] [--tail <n>] [--run <id>]")
+	}
+	return name, runID, follow, tail, nil
+}
+
+// parseTodoImportArgs extracts the --label (repeatable) and --assignee
+// filters from the arguments after "import".
+func parseTodoImportArgs(args []string) (labels []string, assignee string, err error) {
+	usage := cliapp.Usagef("usage: fitz todo import [--label <name>]... [--assignee <login|@me>]")
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--label":
+			i++
+			if i >= len(args) {
+				return nil, "", usage
+			}
+			labels = append(labels, args[i])
+		case "--assignee":
+			i++
+			if i >= len(args) {
+				return nil, "", usage
+			}
+			assignee = args[i]
+		default:
+			return nil, "", usage
+		}
+	}
+	return labels, assignee, nil
+}
+
+// parseBrFanoutArgs extracts the base name, --base, --count, --models and
+// prompt from the arguments after "fanout".
+func parseBrFanoutArgs(args []string) (name, base string, count int, models []string, prompt string, err error) {
+	usage := cliapp.Usagef("usage: fitz br fanout [--base <branch>] <name> --count <n> [--models a,b,c] <prompt...>")
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--base", "--count", "--models":
+			flag := args[i]
+			i++
+			if i >= len(args) {
+				return "", "", 0, nil, "", usage
+			}
+			switch flag {
+			case "--base":
+				base = args[i]
+			case "--count":
+				count, err = strconv.Atoi(args[i])
+				if err != nil || count < 1 {
+					return "", "", 0, nil, "", cliapp.Usagef("invalid --count value: %s", args[i])
+				}
+			case "--models":
+				for _, m := range strings.Split(args[i], ",") {
+					if m = strings.TrimSpace(m); m != "" {
+						models = append(models, m)
+					}
+				}
+			}
+		default:
+			positional = append(positional, args[i])
+		}
+	}
+	if len(positional) < 2 || (count == 0 && len(models) == 0) {
+		return "", "", 0, nil, "", usage
+	}
+	return positional[0], base, count, models, strings.Join(positional[1:], " "), nil
 }
 
 type agentCommand struct{}
@@ -179,11 +387,11 @@ func (agentCommand) Help(w io.Writer) {
 	fmt.Fprintln(w)
 	fmt.Fprintln(w, "Commands:")
 	fmt.Fprintln(w, "  help      Show this help message")
-	fmt.Fprintln(w, "  notify    Update tab name to signal agent activity (--clear to reset)")
-	fmt.Fprintln(w, "  status    Save branch status for agents (message and/or --pr URL)")
+	fmt.Fprintln(w, "  notify    Signal the agent is waiting: rename the tab and notify (--clear to reset)")
+	fmt.Fprintln(w, "  status    Save branch status for agents (message, --pr URL and/or --state)")
 }
 
-func (a agentCommand) Run(_ context.Context, args []string, _ io.Reader, stdout, stderr io.Writer) error {
+func (a agentCommand) Run(ctx context.Context, args []string, _ io.Reader, stdout, stderr io.Writer) error {
 	if len(args) == 0 {
 		a.Help(stdout)
 		return nil
@@ -199,14 +407,18 @@ func (a agentCommand) Run(_ context.Context, args []string, _ io.Reader, stdout,
 		}
 		return runAgentNotify(stdout, clear)
 	case "status":
-		message, prURL, err := parseAgentStatusArgs(args[1:])
+		message, prURL, update, err := parseAgentStatusArgs(args[1:])
 		if err != nil {
 			return err
 		}
-		return runAgentStatus(stdout, message, prURL)
+		result, err := runAgentStatus(message, prURL, update)
+		if err != nil {
+			return err
+		}
+		return writeResult(ctx, stdout, result)
 	default:
 		a.Help(stderr)
-		return fmt.Errorf("unknown agent subcommand: %s", args[0])
+		return cliapp.Usagef("unknown agent subcommand: %s", args[0])
 	}
 }
 
@@ -218,19 +430,25 @@ func (brCommand) Help(w io.Writer) {
 	fmt.Fprintln(w, "Commands:")
 	fmt.Fprintln(w, "  cd        Print the path to a worktree")
 	fmt.Fprintln(w, "  co        Check out a pull request into a new worktree")
+	fmt.Fprintln(w, "  fanout    Run one prompt in N new worktrees (--count N, --models a,b,c)")
 	fmt.Fprintln(w, "  go        Switch to an existing worktree")
 	fmt.Fprintln(w, "  help      Show this help message")
-	fmt.Fprintln(w, "  list      List all worktrees")
+	fmt.Fprintln(w, "  history   Show the timeline of a branch's agent status updates")
+	fmt.Fprintln(w, "  list      List all worktrees (--format table|json|tsv)")
+	fmt.Fprintln(w, "  logs      Show a background agent's output (--follow, --tail N, --run ID)")
 	fmt.Fprintln(w, "  new       Create a new worktree (optionally with --base and/or prompt)")
+	fmt.Fprintln(w, "  pick      Keep one fanout worktree and remove the rest")
 	fmt.Fprintln(w, "  publish   Push a branch and open a pull request (optionally specify worktree)")
+	fmt.Fprintln(w, "  queue     List queued and running agents (cancel <name>, move <name> <pos>)")
 	fmt.Fprintln(w, "  rm        Remove a worktree and its branch (--all to remove all)")
+	fmt.Fprintln(w, "  stop      Stop a background agent (--all to stop every agent)")
 	fmt.Fprintln(w)
 	fmt.Fprintln(w, "Run with no command to show the current worktree.")
 }
 
 func (b brCommand) Run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
 	if len(args) == 0 {
-		return cliapp.BrList(ctx, stdin, stdout)
+		return cliapp.BrList(ctx, stdin, stdout, listFormat(ctx, ""))
 	}
 
 	subcommand := args[0]
@@ -240,23 +458,36 @@ func (b brCommand) Run(ctx context.Context, args []string, stdin io.Reader, stdo
 		if err != nil {
 			return err
 		}
+		if jsonMode(ctx) {
+			result, err := cliapp.NewBranch(ctx, name, base, prompt)
+			if err != nil {
+				return err
+			}
+			return writeResult(ctx, stdout, result)
+		}
 		return cliapp.BrNew(ctx, stdout, name, base, prompt)
 
 	case "co":
 		if len(args) < 2 {
-			return fmt.Errorf("usage: fitz br co <pr-number-or-url>")
+			return cliapp.Usagef("usage: fitz br co <pr-number-or-url>")
+		}
+		if jsonMode(ctx) {
+			return errNoJSON("br co")
 		}
 		return cliapp.BrCheckout(ctx, stdout, args[1])
 
 	case "go":
 		if len(args) < 2 {
-			return fmt.Errorf("usage: fitz br go <name>")
+			return cliapp.Usagef("usage: fitz br go <name>")
+		}
+		if jsonMode(ctx) {
+			return errNoJSON("br go")
 		}
 		return cliapp.BrGo(ctx, stdout, args[1])
 
 	case "rm":
 		if len(args) < 2 {
-			return fmt.Errorf("usage: fitz br rm <name> [--force]\n       fitz br rm --all [--force]")
+			return cliapp.Usagef("usage: fitz br rm <name> [--force]\n       fitz br rm --all [--force]")
 		}
 
 		all := false
@@ -271,26 +502,43 @@ func (b brCommand) Run(ctx context.Context, args []string, stdin io.Reader, stdo
 				force = true
 			default:
 				if name != "" {
-					return fmt.Errorf("usage: fitz br rm <name> [--force]\n       fitz br rm --all [--force]")
+					return cliapp.Usagef("usage: fitz br rm <name> [--force]\n       fitz br rm --all [--force]")
 				}
 				name = arg
 			}
 		}
 
 		if all && name != "" {
-			return fmt.Errorf("usage: fitz br rm <name> [--force]\n       fitz br rm --all [--force]")
+			return cliapp.Usagef("usage: fitz br rm <name> [--force]\n       fitz br rm --all [--force]")
 		}
 		if !all && name == "" {
-			return fmt.Errorf("usage: fitz br rm <name> [--force]\n       fitz br rm --all [--force]")
+			return cliapp.Usagef("usage: fitz br rm <name> [--force]\n       fitz br rm --all [--force]")
 		}
 
+		var result cliapp.BrRemoveResult
+		var err error
 		if all {
-			return cliapp.BrRemoveAll(ctx, stdout, force)
+			result, err = cliapp.BrRemoveAll(ctx, stdout, force)
+		} else {
+			result, err = cliapp.BrRemove(ctx, stdout, name, force)
+		}
+		if err != nil {
+			return err
+		}
+		if err := writeResult(ctx, stdout, result); err != nil {
+			return err
 		}
-		return cliapp.BrRemove(ctx, stdout, name, force)
+		if len(result.Refused) > 0 {
+			return fmt.Errorf("%d of the worktrees were not removed", len(result.Refused))
+		}
+		return nil
 
 	case "list":
-		return cliapp.BrList(ctx, stdin, stdout)
+		format, err := parseBrListArgs(args[1:])
+		if err != nil {
+			return err
+		}
+		return cliapp.BrList(ctx, stdin, stdout, listFormat(ctx, format))
 
 	case "publish":
 		var name string
@@ -301,13 +549,89 @@ func (b brCommand) Run(ctx context.Context, args []string, stdin io.Reader, stdo
 
 	case "cd":
 		if len(args) < 2 {
-			return fmt.Errorf("usage: fitz br cd <name>")
+			return cliapp.Usagef("usage: fitz br cd <name>")
 		}
 		return cliapp.BrCd(ctx, stdout, args[1])
 
+	case "stop":
+		all := false
+		var name string
+		for _, arg := range args[1:] {
+			if arg == "--all" {
+				all = true
+				continue
+			}
+			if name != "" {
+				return cliapp.Usagef("usage: fitz br stop <name>\n       fitz br stop --all")
+			}
+			name = arg
+		}
+		if all == (name != "") {
+			return cliapp.Usagef("usage: fitz br stop <name>\n       fitz br stop --all")
+		}
+		return runBrStop(ctx, stdout, name, all)
+
+	case "fanout":
+		name, base, count, models, prompt, err := parseBrFanoutArgs(args[1:])
+		if err != nil {
+			return err
+		}
+		return runBrFanout(ctx, stdout, name, base, count, models, prompt)
+
+	case "pick":
+		if len(args) < 2 {
+			return cliapp.Usagef("usage: fitz br pick <name>")
+		}
+		return runBrPick(ctx, stdout, args[1])
+
+	case "queue":
+		return runBrQueueCommand(ctx, args[1:], stdout)
+
+	case "logs":
+		name, runID, follow, tail, err := parseBrLogsArgs(args[1:])
+		if err != nil {
+			return err
+		}
+		return runBrLogs(ctx, stdout, name, runID, follow, tail)
+
+	case "history":
+		if len(args) != 2 {
+			return cliapp.Usagef("usage: fitz br history <name>")
+		}
+		result, err := runBrHistory(ctx, args[1])
+		if err != nil {
+			return err
+		}
+		return writeResult(ctx, stdout, result)
+
 	default:
 		b.Help(stderr)
-		return fmt.Errorf("unknown br subcommand: %s", subcommand)
+		return cliapp.Usagef("unknown br subcommand: %s", subcommand)
+	}
+}
+
+func runBrQueueCommand(ctx context.Context, args []string, stdout io.Writer) error {
+	if len(args) == 0 || args[0] == "list" {
+		return cliapp.BrQueue(ctx, stdout)
+	}
+
+	switch args[0] {
+	case "cancel":
+		if len(args) != 2 {
+			return cliapp.Usagef("usage: fitz br queue cancel <name-or-run-id>")
+		}
+		return cliapp.BrQueueCancel(ctx, stdout, args[1])
+	case "move":
+		if len(args) != 3 {
+			return cliapp.Usagef("usage: fitz br queue move <name-or-run-id> <position>")
+		}
+		position, err := strconv.Atoi(args[2])
+		if err != nil || position < 1 {
+			return cliapp.Usagef("invalid position: %s", args[2])
+		}
+		return cliapp.BrQueueMove(ctx, stdout, args[1], position)
+	default:
+		return cliapp.Usagef("unknown br queue subcommand: %s (valid: list, cancel, move)", args[0])
 	}
 }
 
@@ -324,9 +648,13 @@ func (todoCommand) Help(w io.Writer) {
 	fmt.Fprintln(w, "Usage: fitz todo <command>")
 	fmt.Fprintln(w)
 	fmt.Fprintln(w, "Commands:")
-	fmt.Fprintln(w, "  <text>    Add a new todo item")
+	fmt.Fprintln(w, "  <text>    Add a new todo item (p0-p3 sets priority, #word adds a tag)")
+	fmt.Fprintln(w, "  edit      Edit a todo's text, or its text and notes in $EDITOR")
 	fmt.Fprintln(w, "  help      Show this help message")
-	fmt.Fprintln(w, "  list      Interactive todo list (enter: create worktree, d: done)")
+	fmt.Fprintln(w, "  import    Import open GitHub issues (--label x, --assignee @me)")
+	fmt.Fprintln(w, "  kickoff   Create a worktree and background agent for each todo ID")
+	fmt.Fprintln(w, "  list      Interactive todo list (enter: create worktree, space: mark, K: kickoff marked, t: filter tag, d: done)")
+	fmt.Fprintln(w, "            --done shows the archive of done todos")
 }
 
 func (t todoCommand) Run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
@@ -337,10 +665,39 @@ func (t todoCommand) Run(ctx context.Context, args []string, stdin io.Reader, st
 
 	switch args[0] {
 	case "list":
+		if len(args) == 2 && args[1] == "--done" {
+			return cliapp.TodoListDone(ctx, stdout)
+		}
+		if len(args) > 1 {
+			return cliapp.Usagef("usage: fitz todo list [--done]")
+		}
+		if jsonMode(ctx) {
+			return errNoJSON("todo list")
+		}
 		return cliapp.TodoList(ctx, stdin, stdout)
+	case "import":
+		labels, assignee, err := parseTodoImportArgs(args[1:])
+		if err != nil {
+			return err
+		}
+		return cliapp.TodoImport(ctx, stdout, labels, assignee)
+	case "edit":
+		if len(args) < 2 {
+			return cliapp.Usagef("usage: fitz todo edit <id> [text...]")
+		}
+		return cliapp.TodoEdit(ctx, stdout, args[1], strings.Join(args[2:], " "))
+	case "kickoff":
+		if len(args) < 2 {
+			return cliapp.Usagef("usage: fitz todo kickoff <id...>")
+		}
+		return cliapp.TodoKickoff(ctx, stdout, args[1:])
 	default:
 		text := strings.Join(args, " ")
-		return cliapp.TodoAdd(ctx, stdout, text)
+		result, err := cliapp.TodoAdd(ctx, text)
+		if err != nil {
+			return err
+		}
+		return writeResult(ctx, stdout, result)
 	}
 }
 
@@ -359,3 +716,19 @@ func (reviewCommand) Run(ctx context.Context, args []string, _ io.Reader, stdout
 	prompt := strings.Join(args, " ")
 	return runReview(ctx, stdout, prompt)
 }
+
+type superviseCommand struct{}
+
+func (superviseCommand) Help(w io.Writer) {
+	fmt.Fprintf(w, "Usage: fitz %s <runs-file> <run-id>\n", cliapp.SuperviseCommand)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Internal: runs a recorded background agent and records its exit code.")
+}
+
+func (s superviseCommand) Run(_ context.Context, args []string, _ io.Reader, stdout, _ io.Writer) error {
+	if len(args) != 2 {
+		s.Help(stdout)
+		return cliapp.Usagef("usage: fitz %s <runs-file> <run-id>", cliapp.SuperviseCommand)
+	}
+	return runSupervise(args[0], args[1])
+}
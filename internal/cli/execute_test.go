@@ -7,6 +7,10 @@ import (
 	"io"
 	"strings"
 	"testing"
+	"time"
+
+	"fitz/internal/cliapp"
+	"fitz/internal/status"
 )
 
 func TestExecuteKnownCommands(t *testing.T) {
@@ -97,10 +101,21 @@ func TestExecuteBrCommands(t *testing.T) {
 	}{
 		{name: "br no args", args: []string{"br"}, wantErr: false},
 		{name: "br list", args: []string{"br", "list"}, wantErr: false},
+		{name: "br list json", args: []string{"br", "list", "--format", "json"}, wantErr: false},
+		{name: "br list bad format", args: []string{"br", "list", "--format", "xml"}, wantErr: true},
+		{name: "br list stray arg", args: []string{"br", "list", "extra"}, wantErr: true},
 		{name: "br new missing name", args: []string{"br", "new"}, wantErr: true},
 		{name: "br go missing name", args: []string{"br", "go"}, wantErr: true},
 		{name: "br rm missing name", args: []string{"br", "rm"}, wantErr: true},
 		{name: "br cd missing name", args: []string{"br", "cd"}, wantErr: true},
+		{name: "br logs missing name", args: []string{"br", "logs"}, wantErr: true},
+		{name: "br stop missing name", args: []string{"br", "stop"}, wantErr: true},
+		{name: "br pick missing name", args: []string{"br", "pick"}, wantErr: true},
+		{name: "br queue cancel missing name", args: []string{"br", "queue", "cancel"}, wantErr: true},
+		{name: "br queue move bad position", args: []string{"br", "queue", "move", "feat", "first"}, wantErr: true},
+		{name: "br queue unknown", args: []string{"br", "queue", "wat"}, wantErr: true},
+		{name: "br fanout missing prompt", args: []string{"br", "fanout", "feat", "--count", "2"}, wantErr: true},
+		{name: "br stop name and all", args: []string{"br", "stop", "feat", "--all"}, wantErr: true},
 		{name: "br co missing pr", args: []string{"br", "co"}, wantErr: true},
 		{name: "br unknown subcommand", args: []string{"br", "wat"}, wantErr: true},
 	}
@@ -184,6 +199,156 @@ func TestParseBrNewArgs(t *testing.T) {
 	}
 }
 
+func TestParseBrLogsArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantName   string
+		wantRun    string
+		wantFollow bool
+		wantTail   int
+		wantErr    bool
+	}{
+		{name: "name only", args: []string{"feat"}, wantName: "feat"},
+		{name: "follow", args: []string{"feat", "--follow"}, wantName: "feat", wantFollow: true},
+		{name: "short flags", args: []string{"-f", "-n", "20", "feat"}, wantName: "feat", wantFollow: true, wantTail: 20},
+		{name: "run id", args: []string{"feat", "--run", "20260101T000000-abcd"}, wantName: "feat", wantRun: "20260101T000000-abcd"},
+		{name: "missing name", args: []string{"--follow"}, wantErr: true},
+		{name: "tail missing value", args: []string{"feat", "--tail"}, wantErr: true},
+		{name: "tail not a number", args: []string{"feat", "--tail", "lots"}, wantErr: true},
+		{name: "extra positional", args: []string{"feat", "other"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			name, runID, follow, tail, err := parseBrLogsArgs(tc.args)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if name != tc.wantName || runID != tc.wantRun || follow != tc.wantFollow || tail != tc.wantTail {
+				t.Fatalf("got (%q, %q, %v, %d), want (%q, %q, %v, %d)",
+					name, runID, follow, tail, tc.wantName, tc.wantRun, tc.wantFollow, tc.wantTail)
+			}
+		})
+	}
+}
+
+func TestExecuteBrLogsForwardsArgs(t *testing.T) {
+	prev := runBrLogs
+	t.Cleanup(func() { runBrLogs = prev })
+
+	var gotName string
+	var gotFollow bool
+	var gotTail int
+	runBrLogs = func(_ context.Context, _ io.Writer, name, _ string, follow bool, tail int) error {
+		gotName, gotFollow, gotTail = name, follow, tail
+		return nil
+	}
+
+	var out, errOut bytes.Buffer
+	if err := Execute([]string{"br", "logs", "feat", "--follow", "--tail", "5"}, strings.NewReader(""), &out, &errOut); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotName != "feat" || !gotFollow || gotTail != 5 {
+		t.Fatalf("got (%q, %v, %d)", gotName, gotFollow, gotTail)
+	}
+}
+
+func TestExecuteBrHistory(t *testing.T) {
+	prev := runBrHistory
+	t.Cleanup(func() { runBrHistory = prev })
+
+	at := time.Date(2026, 3, 1, 9, 30, 0, 0, time.Local)
+	runBrHistory = func(_ context.Context, name string) (cliapp.BrHistoryResult, error) {
+		return cliapp.BrHistoryResult{Branch: name, Events: []status.Event{
+			{Message: "running tests", At: at},
+			{PRURL: "https://github.com/acme/repo/pull/9", At: at.Add(time.Minute)},
+		}}, nil
+	}
+
+	var out, errOut bytes.Buffer
+	if err := Execute([]string{"br", "history", "feat"}, strings.NewReader(""), &out, &errOut); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "2026-03-01 09:30:00  running tests\n2026-03-01 09:31:00  PR: https://github.com/acme/repo/pull/9\n"
+	if out.String() != want {
+		t.Fatalf("output = %q, want %q", out.String(), want)
+	}
+
+	if err := Execute([]string{"br", "history"}, strings.NewReader(""), &out, &errOut); err == nil {
+		t.Fatal("expected usage error without a name")
+	}
+}
+
+func TestExecuteBrStopAll(t *testing.T) {
+	prev := runBrStop
+	t.Cleanup(func() { runBrStop = prev })
+
+	var gotName string
+	var gotAll bool
+	runBrStop = func(_ context.Context, _ io.Writer, name string, all bool) error {
+		gotName, gotAll = name, all
+		return nil
+	}
+
+	var out, errOut bytes.Buffer
+	if err := Execute([]string{"br", "stop", "--all"}, strings.NewReader(""), &out, &errOut); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotName != "" || !gotAll {
+		t.Fatalf("got (%q, %v), want all", gotName, gotAll)
+	}
+}
+
+func TestParseBrFanoutArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantName   string
+		wantBase   string
+		wantCount  int
+		wantModels []string
+		wantPrompt string
+		wantErr    bool
+	}{
+		{name: "count", args: []string{"feat", "--count", "3", "fix", "the", "bug"}, wantName: "feat", wantCount: 3, wantPrompt: "fix the bug"},
+		{name: "models only", args: []string{"--models", "a, b", "feat", "do it"}, wantName: "feat", wantModels: []string{"a", "b"}, wantPrompt: "do it"},
+		{name: "base and count", args: []string{"--base", "develop", "feat", "--count", "2", "do it"}, wantName: "feat", wantBase: "develop", wantCount: 2, wantPrompt: "do it"},
+		{name: "missing count and models", args: []string{"feat", "do it"}, wantErr: true},
+		{name: "invalid count", args: []string{"feat", "--count", "0", "do it"}, wantErr: true},
+		{name: "missing prompt", args: []string{"feat", "--count", "2"}, wantErr: true},
+		{name: "models missing value", args: []string{"feat", "do it", "--models"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			name, base, count, models, prompt, err := parseBrFanoutArgs(tc.args)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if name != tc.wantName || base != tc.wantBase || count != tc.wantCount || prompt != tc.wantPrompt {
+				t.Fatalf("got (%q, %q, %d, %q), want (%q, %q, %d, %q)",
+					name, base, count, prompt, tc.wantName, tc.wantBase, tc.wantCount, tc.wantPrompt)
+			}
+			if strings.Join(models, ",") != strings.Join(tc.wantModels, ",") {
+				t.Fatalf("models = %v, want %v", models, tc.wantModels)
+			}
+		})
+	}
+}
+
 func TestExecuteBrRmExtraArgs(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -247,6 +412,28 @@ func TestExecuteTodoHelp(t *testing.T) {
 	}
 }
 
+func TestExecuteTodoUsageErrors(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"todo", "kickoff"}, "usage: fitz todo kickoff"},
+		{[]string{"todo", "edit"}, "usage: fitz todo edit"},
+		{[]string{"todo", "list", "--bogus"}, "usage: fitz todo list"},
+		{[]string{"todo", "import", "--label"}, "usage: fitz todo import"},
+		{[]string{"todo", "import", "stray"}, "usage: fitz todo import"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			err := Execute(tt.args, strings.NewReader(""), &out, &errOut)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
 func TestExecuteHelpListsTodo(t *testing.T) {
 	var out, errOut bytes.Buffer
 	stdin := strings.NewReader("")
@@ -330,10 +517,10 @@ func TestExecuteAgentStatus(t *testing.T) {
 	t.Cleanup(func() { runAgentStatus = prev })
 
 	var gotMessage, gotPR string
-	runAgentStatus = func(w io.Writer, message, prURL string) error {
+	runAgentStatus = func(message, prURL string, _ cliapp.AgentStateUpdate) (cliapp.AgentStatusResult, error) {
 		gotMessage = message
 		gotPR = prURL
-		return nil
+		return cliapp.AgentStatusResult{Branch: "feature-auth"}, nil
 	}
 
 	var out, errOut bytes.Buffer
@@ -355,18 +542,27 @@ func TestParseAgentStatusArgs(t *testing.T) {
 		args        []string
 		wantMessage string
 		wantPR      string
+		wantUpdate  cliapp.AgentStateUpdate
 		wantErr     bool
 	}{
 		{name: "message only", args: []string{"Implementing auth"}, wantMessage: "Implementing auth"},
 		{name: "pr only", args: []string{"--pr", "https://github.com/acme/repo/pull/42"}, wantPR: "https://github.com/acme/repo/pull/42"},
 		{name: "message and pr", args: []string{"--pr", "https://github.com/acme/repo/pull/42", "Ready"}, wantMessage: "Ready", wantPR: "https://github.com/acme/repo/pull/42"},
+		{name: "state only", args: []string{"--state", "blocked"}, wantUpdate: cliapp.AgentStateUpdate{State: "blocked"}},
+		{
+			name:        "state with progress and detail",
+			args:        []string{"--state", "working", "--progress", "3/5", "--detail", "Running the test suite", "Testing"},
+			wantMessage: "Testing",
+			wantUpdate:  cliapp.AgentStateUpdate{State: "working", Progress: "3/5", Detail: "Running the test suite"},
+		},
 		{name: "missing pr value", args: []string{"--pr"}, wantErr: true},
+		{name: "missing state value", args: []string{"Ready", "--state"}, wantErr: true},
 		{name: "empty update", args: nil, wantErr: true},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			message, prURL, err := parseAgentStatusArgs(tc.args)
+			message, prURL, update, err := parseAgentStatusArgs(tc.args)
 			if tc.wantErr {
 				if err == nil {
 					t.Fatal("expected error")
@@ -382,6 +578,9 @@ func TestParseAgentStatusArgs(t *testing.T) {
 			if prURL != tc.wantPR {
 				t.Fatalf("pr = %q, want %q", prURL, tc.wantPR)
 			}
+			if update != tc.wantUpdate {
+				t.Fatalf("update = %+v, want %+v", update, tc.wantUpdate)
+			}
 		})
 	}
 }
@@ -448,3 +647,30 @@ func TestBrHelpListsCo(t *testing.T) {
 		t.Fatalf("stdout = %q, want 'co' listed", out.String())
 	}
 }
+
+func TestExecuteGC(t *testing.T) {
+	prev := runGC
+	t.Cleanup(func() { runGC = prev })
+
+	var gotDryRun bool
+	runGC = func(_ context.Context, dryRun bool) (cliapp.GCResult, error) {
+		gotDryRun = dryRun
+		return cliapp.GCResult{DryRun: dryRun, Branches: []string{"old-feature"}}, nil
+	}
+
+	var out, errOut bytes.Buffer
+	if err := Execute([]string{"gc", "--dry-run"}, strings.NewReader(""), &out, &errOut); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !gotDryRun || out.String() != "would remove status: old-feature\n" {
+		t.Fatalf("dry run = %v, output = %q", gotDryRun, out.String())
+	}
+
+	out.Reset()
+	if err := Execute([]string{"gc"}, strings.NewReader(""), &out, &errOut); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotDryRun || out.String() != "removed status: old-feature\n" {
+		t.Fatalf("dry run = %v, output = %q", gotDryRun, out.String())
+	}
+}
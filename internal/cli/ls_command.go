@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"fitz/internal/cliapp"
+)
+
+var runLs = cliapp.Ls
+var runLsGo = cliapp.LsGo
+
+type lsCommand struct{}
+
+func (lsCommand) Help(w io.Writer) {
+	fmt.Fprintln(w, "Usage: fitz ls [--format table|json|tsv]")
+	fmt.Fprintln(w, "       fitz ls go <[owner/]repo/name | name>")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Lists the worktrees of every repo fitz has created worktrees for,")
+	fmt.Fprintln(w, "grouped by repo, with their PR, agent activity and status message.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	fmt.Fprintln(w, "  go        Open a worktree from any repo, regardless of the current directory")
+	fmt.Fprintln(w, "  help      Show this help message")
+}
+
+func (l lsCommand) Run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) > 0 && args[0] == "go" {
+		if len(args) != 2 || strings.TrimSpace(args[1]) == "" {
+			return cliapp.Usagef("usage: fitz ls go <[owner/]repo/name | name>")
+		}
+		if jsonMode(ctx) {
+			return errNoJSON("ls go")
+		}
+		return runLsGo(ctx, stdout, args[1])
+	}
+
+	format, err := parseFormatArgs(args, "usage: fitz ls [--format table|json|tsv]")
+	if err != nil {
+		if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+			l.Help(stderr)
+			return cliapp.Usagef("unknown ls subcommand: %s", args[0])
+		}
+		return err
+	}
+	return runLs(ctx, stdin, stdout, listFormat(ctx, format))
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func stubLs(t *testing.T) (gotFormat *string, gotTarget *string) {
+	t.Helper()
+	prevLs, prevGo := runLs, runLsGo
+	t.Cleanup(func() { runLs, runLsGo = prevLs, prevGo })
+
+	gotFormat, gotTarget = new(string), new(string)
+	runLs = func(_ context.Context, _ io.Reader, _ io.Writer, format string) error {
+		*gotFormat = format
+		return nil
+	}
+	runLsGo = func(_ context.Context, _ io.Writer, target string) error {
+		*gotTarget = target
+		return nil
+	}
+	return gotFormat, gotTarget
+}
+
+func TestExecuteLs(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantFormat string
+		wantTarget string
+	}{
+		{name: "tui", args: []string{"ls"}},
+		{name: "format", args: []string{"ls", "--format", "tsv"}, wantFormat: "tsv"},
+		{name: "format equals", args: []string{"ls", "--format=table"}, wantFormat: "table"},
+		{name: "json mode", args: []string{"--json", "ls"}, wantFormat: "json"},
+		{name: "go", args: []string{"ls", "go", "acme/api/feat"}, wantTarget: "acme/api/feat"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotFormat, gotTarget := stubLs(t)
+			var out, errOut bytes.Buffer
+			if err := Execute(tt.args, strings.NewReader(""), &out, &errOut); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *gotFormat != tt.wantFormat || *gotTarget != tt.wantTarget {
+				t.Fatalf("format = %q, target = %q", *gotFormat, *gotTarget)
+			}
+		})
+	}
+}
+
+func TestExecuteLsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "go without target", args: []string{"ls", "go"}, wantErr: "usage: fitz ls go"},
+		{name: "stray flag", args: []string{"ls", "--bogus"}, wantErr: "usage: fitz ls"},
+		{name: "unknown subcommand", args: []string{"ls", "nope"}, wantErr: "unknown ls subcommand: nope"},
+		{name: "go under json", args: []string{"--json", "ls", "go", "feat"}, wantErr: "does not support --json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubLs(t)
+			var out, errOut bytes.Buffer
+			err := Execute(tt.args, strings.NewReader(""), &out, &errOut)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			if ExitCode(err) != ExitUsage {
+				t.Fatalf("exit code = %d, want %d", ExitCode(err), ExitUsage)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"fitz/internal/cliapp"
+)
+
+// Exit codes are part of the scripting contract: 0 on success, ExitFailure
+// when a command fails and ExitUsage for unknown commands or bad arguments.
+const (
+	ExitFailure = 1
+	ExitUsage   = 2
+)
+
+type outputKey struct{}
+
+// output carries --json mode through a command. Commands with a structured
+// result hand it to writeResult, which records it here instead of printing.
+type output struct {
+	json   bool
+	result cliapp.Result
+}
+
+func withOutput(ctx context.Context, o *output) context.Context {
+	return context.WithValue(ctx, outputKey{}, o)
+}
+
+// jsonMode reports whether the command is running under --json.
+func jsonMode(ctx context.Context) bool {
+	o, ok := ctx.Value(outputKey{}).(*output)
+	return ok && o.json
+}
+
+// writeResult prints r as text, or under --json keeps it for Execute to
+// encode once the command has finished.
+func writeResult(ctx context.Context, w io.Writer, r cliapp.Result) error {
+	if o, ok := ctx.Value(outputKey{}).(*output); ok && o.json {
+		o.result = r
+		return nil
+	}
+	r.WriteText(w)
+	return nil
+}
+
+// jsonError is how a failed command is reported under --json.
+type jsonError struct {
+	Error    string `json:"error"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// jsonText wraps the text output of commands without a structured result.
+type jsonText struct {
+	Output string `json:"output"`
+}
+
+func writeJSON(w io.Writer, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode json: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// reportedError is an error Execute has already written to stdout as JSON.
+type reportedError struct{ error }
+
+func (e reportedError) Unwrap() error { return e.error }
+
+// Reported reports whether err has already been printed by Execute, so the
+// caller should only exit with ExitCode(err).
+func Reported(err error) bool {
+	var reported reportedError
+	return errors.As(err, &reported)
+}
+
+// ExitCode returns the process exit code for an error returned by Execute.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var usage cliapp.UsageError
+	if errors.As(err, &usage) {
+		return ExitUsage
+	}
+	return ExitFailure
+}
+
+// errNoJSON rejects interactive commands under --json.
+func errNoJSON(command string) error {
+	return cliapp.Usagef("fitz %s is interactive and does not support --json", command)
+}
@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"fitz/internal/cliapp"
+)
+
+func TestExecuteJSONVersion(t *testing.T) {
+	prev := Version
+	Version = "1.2.3"
+	t.Cleanup(func() { Version = prev })
+
+	var out, errOut bytes.Buffer
+	if err := Execute([]string{"--json", "version"}, strings.NewReader(""), &out, &errOut); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, out.String())
+	}
+	if got["version"] != "1.2.3" {
+		t.Fatalf("json = %v", got)
+	}
+}
+
+func TestExecuteJSONAfterCommand(t *testing.T) {
+	prev := Version
+	Version = "1.2.3"
+	t.Cleanup(func() { Version = prev })
+
+	var out, errOut bytes.Buffer
+	if err := Execute([]string{"version", "--json"}, strings.NewReader(""), &out, &errOut); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, out.String())
+	}
+	if got["version"] != "1.2.3" {
+		t.Fatalf("json = %v", got)
+	}
+}
+
+func TestCutJSONFlag(t *testing.T) {
+	tests := []struct {
+		args     []string
+		wantJSON bool
+		wantRest []string
+	}{
+		{args: []string{"br", "list"}, wantRest: []string{"br", "list"}},
+		{args: []string{"--json", "br", "list"}, wantJSON: true, wantRest: []string{"br", "list"}},
+		{args: []string{"br", "--json", "list"}, wantJSON: true, wantRest: []string{"br", "list"}},
+		{args: []string{"br", "list", "--json"}, wantJSON: true, wantRest: []string{"br", "list"}},
+		{args: []string{"todo", "--", "--json"}, wantRest: []string{"todo", "--", "--json"}},
+	}
+	for _, tt := range tests {
+		gotJSON, gotRest := cutJSONFlag(tt.args)
+		if gotJSON != tt.wantJSON || strings.Join(gotRest, " ") != strings.Join(tt.wantRest, " ") {
+			t.Errorf("cutJSONFlag(%q) = %v, %q; want %v, %q", tt.args, gotJSON, gotRest, tt.wantJSON, tt.wantRest)
+		}
+	}
+}
+
+func TestExecuteJSONAgentStatus(t *testing.T) {
+	prev := runAgentStatus
+	t.Cleanup(func() { runAgentStatus = prev })
+	runAgentStatus = func(message, prURL string, _ cliapp.AgentStateUpdate) (cliapp.AgentStatusResult, error) {
+		result := cliapp.AgentStatusResult{Branch: "feature-auth"}
+		result.Message = message
+		return result, nil
+	}
+
+	var out, errOut bytes.Buffer
+	if err := Execute([]string{"--json", "agent", "status", "Implementing auth"}, strings.NewReader(""), &out, &errOut); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, out.String())
+	}
+	if got["branch"] != "feature-auth" || got["message"] != "Implementing auth" {
+		t.Fatalf("json = %v", got)
+	}
+}
+
+func TestExecuteJSONWrapsTextOutput(t *testing.T) {
+	prev := runUpdate
+	t.Cleanup(func() { runUpdate = prev })
+	runUpdate = func(_ context.Context, w io.Writer, _ string, _ bool) error {
+		_, err := fmt.Fprintln(w, "already up to date")
+		return err
+	}
+
+	var out, errOut bytes.Buffer
+	if err := Execute([]string{"--json", "update"}, strings.NewReader(""), &out, &errOut); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got jsonText
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, out.String())
+	}
+	if got.Output != "already up to date\n" {
+		t.Fatalf("output = %q", got.Output)
+	}
+}
+
+func TestExecuteJSONErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+	}{
+		{name: "unknown command", args: []string{"--json", "nope"}, wantCode: ExitUsage},
+		{name: "bad arguments", args: []string{"--json", "br", "new"}, wantCode: ExitUsage},
+		{name: "interactive command", args: []string{"--json", "br", "go", "feat"}, wantCode: ExitUsage},
+		{name: "bad flag value", args: []string{"--json", "agent", "status", "--state", "asleep"}, wantCode: ExitUsage},
+		{name: "bad list format", args: []string{"--json", "br", "list", "--format", "xml"}, wantCode: ExitUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			err := Execute(tt.args, strings.NewReader(""), &out, &errOut)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !Reported(err) {
+				t.Fatalf("error %v should be reported", err)
+			}
+			if code := ExitCode(err); code != tt.wantCode {
+				t.Fatalf("exit code = %d, want %d", code, tt.wantCode)
+			}
+			var got jsonError
+			if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+				t.Fatalf("invalid json: %v\n%s", err, out.String())
+			}
+			if got.Error == "" || got.ExitCode != tt.wantCode {
+				t.Fatalf("json = %+v", got)
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "success", err: nil, want: 0},
+		{name: "failure", err: errors.New("gh not found"), want: ExitFailure},
+		{name: "wrapped failure", err: fmt.Errorf("update failed: %w", errors.New("network down")), want: ExitFailure},
+		{name: "usage error", err: cliapp.Usagef("usage: fitz br new <name>"), want: ExitUsage},
+		{name: "wrapped usage error", err: fmt.Errorf("br failed: %w", cliapp.Usagef("invalid format: xml")), want: ExitUsage},
+		// Only the error's type decides, never its text.
+		{name: "runtime error that reads like usage", err: errors.New("unknown agent: claude2"), want: ExitFailure},
+		{name: "config error", err: fmt.Errorf("br failed: %w", errors.New("invalid branch-open-mode: tabs")), want: ExitFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Fatalf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteWithoutJSONIsNotReported(t *testing.T) {
+	var out, errOut bytes.Buffer
+	err := Execute([]string{"nope"}, strings.NewReader(""), &out, &errOut)
+	if err == nil || Reported(err) {
+		t.Fatalf("error = %v, want unreported error", err)
+	}
+	if ExitCode(err) != ExitUsage {
+		t.Fatalf("exit code = %d, want %d", ExitCode(err), ExitUsage)
+	}
+}
@@ -1,8 +1,8 @@
 package cliapp
 
 import (
+	"errors"
 	"fmt"
-	"io"
 	"os"
 	"strings"
 
@@ -14,41 +14,97 @@ var resolveAgentStatusStorePath = resolveAgentStatusPath
 var resolveCurrentBranch = currentBranch
 var setAgentBranchStatus = status.SetStatus
 var setAgentBranchPR = status.SetPR
+var setAgentBranchState = status.SetState
+var notifyAgent = AgentNotify
 
-func AgentStatus(w io.Writer, message, prURL string) error {
+const agentStatusUsage = "usage: fitz agent status [--pr <url>] [--state <state> [--progress <fraction>] [--detail <text>]] [message]"
+
+// AgentStateUpdate is the --state, --progress and --detail given to
+// `agent status`, as typed.
+type AgentStateUpdate struct {
+	State    string
+	Progress string
+	Detail   string
+}
+
+func AgentStatus(message, prURL string, update AgentStateUpdate) (AgentStatusResult, error) {
 	message = strings.TrimSpace(message)
 	prURL = strings.TrimSpace(prURL)
-	if message == "" && prURL == "" {
-		return fmt.Errorf("usage: fitz agent status [--pr <url>] [message]")
+	state, err := parseAgentState(update)
+	if err != nil {
+		return AgentStatusResult{}, err
+	}
+	if message == "" && prURL == "" && state.State == "" {
+		return AgentStatusResult{}, UsageError{errors.New(agentStatusUsage)}
 	}
 
 	storePath, err := resolveAgentStatusStorePath()
 	if err != nil {
-		return err
+		return AgentStatusResult{}, err
 	}
 
 	branch, err := resolveCurrentBranch()
 	if err != nil {
-		return err
+		return AgentStatusResult{}, err
 	}
 	if branch == "" || branch == "HEAD" {
-		return fmt.Errorf("cannot set agent status on detached HEAD")
+		return AgentStatusResult{}, fmt.Errorf("cannot set agent status on detached HEAD")
 	}
 
+	result := AgentStatusResult{Branch: branch}
 	if message != "" {
 		message = truncateStatusMessage(message)
-		if _, err := setAgentBranchStatus(storePath, branch, message); err != nil {
-			return fmt.Errorf("update status: %w", err)
+		if result.BranchStatus, err = setAgentBranchStatus(storePath, branch, message); err != nil {
+			return AgentStatusResult{}, fmt.Errorf("update status: %w", err)
 		}
 	}
 	if prURL != "" {
-		if _, err := setAgentBranchPR(storePath, branch, prURL); err != nil {
-			return fmt.Errorf("update pull request: %w", err)
+		if result.BranchStatus, err = setAgentBranchPR(storePath, branch, prURL); err != nil {
+			return AgentStatusResult{}, fmt.Errorf("update pull request: %w", err)
+		}
+	}
+	if state.State != "" {
+		var previous string
+		if result.BranchStatus, previous, err = setAgentBranchState(storePath, branch, state); err != nil {
+			return AgentStatusResult{}, fmt.Errorf("update state: %w", err)
+		}
+		// Someone has to answer the agent, so flag its tab like
+		// `fitz agent notify` would.
+		if state.State == status.StateNeedsInput && previous != status.StateNeedsInput {
+			if err := notifyAgent(os.Stderr, false); err != nil {
+				fmt.Fprintf(os.Stderr, "fitz: notify: %v\n", err)
+			}
 		}
 	}
 
-	fmt.Fprintf(w, "updated status for %s\n", branch)
-	return nil
+	return result, nil
+}
+
+// parseAgentState validates update. Progress and detail describe a state,
+// so they need --state.
+func parseAgentState(update AgentStateUpdate) (status.AgentState, error) {
+	state := status.AgentState{
+		State:  strings.TrimSpace(update.State),
+		Detail: strings.TrimSpace(update.Detail),
+	}
+	progress := strings.TrimSpace(update.Progress)
+	if state.State == "" {
+		if progress != "" || state.Detail != "" {
+			return status.AgentState{}, Usagef("--progress and --detail need --state")
+		}
+		return state, nil
+	}
+	if !status.ValidState(state.State) {
+		return status.AgentState{}, Usagef("invalid state: %s (valid values: %s)", state.State, strings.Join(status.States, ", "))
+	}
+	if progress != "" {
+		p, err := status.ParseProgress(progress)
+		if err != nil {
+			return status.AgentState{}, UsageError{err}
+		}
+		state.Progress = &p
+	}
+	return state, nil
 }
 
 func truncateStatusMessage(message string) string {
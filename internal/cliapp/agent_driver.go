@@ -0,0 +1,273 @@
+package cliapp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"fitz/internal/config"
+	"fitz/internal/session"
+)
+
+// AgentDriver adapts a CLI coding agent to fitz. The arg slices returned by
+// the launch methods include the binary name as argv[0], matching what
+// runExec and runBackground expect.
+type AgentDriver interface {
+	// Binary is the executable name resolved through PATH.
+	Binary() string
+	// DisplayName is the agent's name as shown to the user.
+	DisplayName() string
+	// ModelArgs returns the flags that select model, or nil when model is empty.
+	ModelArgs(model string) []string
+	// InteractiveArgs returns the argv for an interactive session.
+	InteractiveArgs(model string) []string
+	// PromptArgs returns the argv for a headless, non-interactive run of prompt.
+	PromptArgs(model, prompt string) []string
+	// ResumeArgs returns the argv for interactively resuming sessionID.
+	ResumeArgs(model, sessionID string) []string
+	// FindSessions returns the most recently updated session for each cwd.
+	// Cwds with no matching session are omitted.
+	FindSessions(cwds []string) (map[string]session.SessionInfo, error)
+}
+
+const defaultAgent = "copilot-cli"
+
+// agentDrivers maps the `agent` config value to a driver constructor. Its
+// keys are config.Agents.
+var agentDrivers = map[string]func(cfg config.Config) (AgentDriver, error){
+	"copilot-cli": func(config.Config) (AgentDriver, error) { return copilotDriver{}, nil },
+	"claude":      func(config.Config) (AgentDriver, error) { return claudeDriver{}, nil },
+	"codex":       func(config.Config) (AgentDriver, error) { return codexDriver{}, nil },
+	"command":     newCommandDriver,
+}
+
+// resolveAgentDriver returns the driver selected by cfg.Agent, defaulting
+// to Copilot CLI when unset.
+func resolveAgentDriver(cfg config.Config) (AgentDriver, error) {
+	name := strings.TrimSpace(cfg.Agent)
+	if name == "" {
+		name = defaultAgent
+	}
+	newDriver, ok := agentDrivers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown agent: %s (valid agents: %s)", name, strings.Join(agentNames(), ", "))
+	}
+	return newDriver(cfg)
+}
+
+// agentDisplayName returns the display name of the agent cfg selects, or a
+// generic name when the config names no usable agent.
+func agentDisplayName(cfg config.Config) string {
+	driver, err := resolveAgentDriver(cfg)
+	if err != nil {
+		return "agent"
+	}
+	return driver.DisplayName()
+}
+
+func agentNames() []string {
+	names := make([]string, 0, len(agentDrivers))
+	for name := range agentDrivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// copilotDriver drives GitHub Copilot CLI.
+type copilotDriver struct{}
+
+func (copilotDriver) Binary() string { return "copilot" }
+
+func (copilotDriver) DisplayName() string { return "Copilot" }
+
+func (copilotDriver) ModelArgs(model string) []string {
+	if model == "" {
+		return nil
+	}
+	return []string{"--model", model}
+}
+
+func (d copilotDriver) InteractiveArgs(model string) []string {
+	return append([]string{d.Binary()}, d.ModelArgs(model)...)
+}
+
+func (d copilotDriver) PromptArgs(model, prompt string) []string {
+	return append(d.InteractiveArgs(model), "--yolo", "-p", prompt)
+}
+
+func (d copilotDriver) ResumeArgs(model, sessionID string) []string {
+	return append(d.InteractiveArgs(model), "--resume", sessionID)
+}
+
+func (copilotDriver) FindSessions(cwds []string) (map[string]session.SessionInfo, error) {
+	configDir := copilotConfigDir()
+	if configDir == "" {
+		return map[string]session.SessionInfo{}, nil
+	}
+	return session.FindAllSessionInfos(configDir, cwds)
+}
+
+// claudeConfigDir returns the Claude Code configuration directory
+// ($CLAUDE_CONFIG_DIR, or ~/.claude).
+var claudeConfigDir = func() string {
+	if dir := strings.TrimSpace(os.Getenv("CLAUDE_CONFIG_DIR")); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".claude")
+}
+
+// claudeDriver drives Claude Code.
+type claudeDriver struct{}
+
+func (claudeDriver) Binary() string { return "claude" }
+
+func (claudeDriver) DisplayName() string { return "Claude" }
+
+func (claudeDriver) ModelArgs(model string) []string {
+	if model == "" {
+		return nil
+	}
+	return []string{"--model", model}
+}
+
+func (d claudeDriver) InteractiveArgs(model string) []string {
+	return append([]string{d.Binary()}, d.ModelArgs(model)...)
+}
+
+func (d claudeDriver) PromptArgs(model, prompt string) []string {
+	return append(d.InteractiveArgs(model), "--dangerously-skip-permissions", "-p", prompt)
+}
+
+func (d claudeDriver) ResumeArgs(model, sessionID string) []string {
+	return append(d.InteractiveArgs(model), "--resume", sessionID)
+}
+
+func (claudeDriver) FindSessions(cwds []string) (map[string]session.SessionInfo, error) {
+	configDir := claudeConfigDir()
+	if configDir == "" {
+		return map[string]session.SessionInfo{}, nil
+	}
+	return session.FindAllClaudeSessionInfos(configDir, cwds)
+}
+
+// codexConfigDir returns the Codex CLI home directory ($CODEX_HOME, or ~/.codex).
+var codexConfigDir = func() string {
+	if dir := strings.TrimSpace(os.Getenv("CODEX_HOME")); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".codex")
+}
+
+// codexDriver drives OpenAI Codex CLI. Headless runs use `codex exec`, and
+// resumes use the `codex resume` subcommand.
+type codexDriver struct{}
+
+func (codexDriver) Binary() string { return "codex" }
+
+func (codexDriver) DisplayName() string { return "Codex" }
+
+func (codexDriver) ModelArgs(model string) []string {
+	if model == "" {
+		return nil
+	}
+	return []string{"--model", model}
+}
+
+func (d codexDriver) InteractiveArgs(model string) []string {
+	return append([]string{d.Binary()}, d.ModelArgs(model)...)
+}
+
+func (d codexDriver) PromptArgs(model, prompt string) []string {
+	args := append([]string{d.Binary(), "exec"}, d.ModelArgs(model)...)
+	return append(args, "--dangerously-bypass-approvals-and-sandbox", prompt)
+}
+
+func (d codexDriver) ResumeArgs(model, sessionID string) []string {
+	args := append([]string{d.Binary(), "resume"}, d.ModelArgs(model)...)
+	return append(args, sessionID)
+}
+
+func (codexDriver) FindSessions(cwds []string) (map[string]session.SessionInfo, error) {
+	configDir := codexConfigDir()
+	if configDir == "" {
+		return map[string]session.SessionInfo{}, nil
+	}
+	return session.FindAllCodexSessionInfos(configDir, cwds)
+}
+
+// commandDriver runs an arbitrary agent CLI described by config templates.
+// Templates are split on whitespace, then {model} and {prompt} placeholders
+// are substituted per field, so a prompt with spaces stays a single arg.
+type commandDriver struct {
+	command    string
+	modelArgs  string
+	promptArgs string
+}
+
+func newCommandDriver(cfg config.Config) (AgentDriver, error) {
+	command := strings.TrimSpace(cfg.AgentCommand)
+	if command == "" {
+		return nil, fmt.Errorf("agent=command requires agent-command to be set")
+	}
+	promptArgs := strings.TrimSpace(cfg.AgentPromptArgs)
+	if promptArgs == "" {
+		promptArgs = "{prompt}"
+	}
+	return commandDriver{
+		command:    command,
+		modelArgs:  strings.TrimSpace(cfg.AgentModelArgs),
+		promptArgs: promptArgs,
+	}, nil
+}
+
+func (d commandDriver) Binary() string { return d.command }
+
+func (d commandDriver) DisplayName() string { return filepath.Base(d.command) }
+
+func (d commandDriver) ModelArgs(model string) []string {
+	if model == "" {
+		return nil
+	}
+	return expandArgsTemplate(d.modelArgs, map[string]string{"{model}": model})
+}
+
+func (d commandDriver) InteractiveArgs(model string) []string {
+	return append([]string{d.command}, d.ModelArgs(model)...)
+}
+
+func (d commandDriver) PromptArgs(model, prompt string) []string {
+	return append(d.InteractiveArgs(model), expandArgsTemplate(d.promptArgs, map[string]string{"{prompt}": prompt})...)
+}
+
+// ResumeArgs falls back to a fresh interactive session; command agents have
+// no session discovery, so there is nothing to resume.
+func (d commandDriver) ResumeArgs(model, _ string) []string {
+	return d.InteractiveArgs(model)
+}
+
+func (commandDriver) FindSessions([]string) (map[string]session.SessionInfo, error) {
+	return map[string]session.SessionInfo{}, nil
+}
+
+func expandArgsTemplate(tmpl string, vars map[string]string) []string {
+	fields := strings.Fields(tmpl)
+	args := make([]string, 0, len(fields))
+	for _, f := range fields {
+		for placeholder, value := range vars {
+			f = strings.ReplaceAll(f, placeholder, value)
+		}
+		args = append(args, f)
+	}
+	return args
+}
@@ -0,0 +1,256 @@
+package cliapp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"fitz/internal/config"
+)
+
+func TestResolveAgentDriver_DefaultsToCopilot(t *testing.T) {
+	driver, err := resolveAgentDriver(config.Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if driver.Binary() != "copilot" {
+		t.Fatalf("binary = %q, want copilot", driver.Binary())
+	}
+}
+
+func TestAgentDisplayName(t *testing.T) {
+	tests := []struct {
+		cfg  config.Config
+		want string
+	}{
+		{cfg: config.Config{}, want: "Copilot"},
+		{cfg: config.Config{Agent: "claude"}, want: "Claude"},
+		{cfg: config.Config{Agent: "codex"}, want: "Codex"},
+		{cfg: config.Config{Agent: "command", AgentCommand: "/usr/local/bin/aider"}, want: "aider"},
+		{cfg: config.Config{Agent: "command"}, want: "agent"},
+	}
+	for _, tt := range tests {
+		if got := agentDisplayName(tt.cfg); got != tt.want {
+			t.Errorf("agentDisplayName(%+v) = %q, want %q", tt.cfg, got, tt.want)
+		}
+	}
+}
+
+func TestAgentDriversMatchConfigAgents(t *testing.T) {
+	if got, want := agentNames(), slices.Sorted(slices.Values(config.Agents)); !slices.Equal(got, want) {
+		t.Fatalf("drivers = %v, config.Agents = %v", got, want)
+	}
+}
+
+func TestResolveAgentDriver_Unknown(t *testing.T) {
+	_, err := resolveAgentDriver(config.Config{Agent: "nope"})
+	if err == nil {
+		t.Fatal("expected error for unknown agent")
+	}
+	if !strings.Contains(err.Error(), "unknown agent: nope") || !strings.Contains(err.Error(), "copilot-cli") {
+		t.Fatalf("error = %q, want unknown agent with valid list", err.Error())
+	}
+}
+
+func TestCopilotDriverArgs(t *testing.T) {
+	d := copilotDriver{}
+
+	tests := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"interactive no model", d.InteractiveArgs(""), []string{"copilot"}},
+		{"interactive with model", d.InteractiveArgs("claude-opus-4.6"), []string{"copilot", "--model", "claude-opus-4.6"}},
+		{"prompt", d.PromptArgs("m", "do it"), []string{"copilot", "--model", "m", "--yolo", "-p", "do it"}},
+		{"resume", d.ResumeArgs("", "abc-123"), []string{"copilot", "--resume", "abc-123"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Fatalf("args = %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCopilotDriverFindSessions(t *testing.T) {
+	original := copilotConfigDir
+	t.Cleanup(func() { copilotConfigDir = original })
+
+	dir := t.TempDir()
+	copilotConfigDir = func() string { return dir }
+
+	sessionDir := filepath.Join(dir, "session-state", "sess-1")
+	if err := os.MkdirAll(sessionDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := "id: sess-1\ncwd: /wt/a\nupdated_at: 2026-01-10T10:00:00.000Z\n"
+	if err := os.WriteFile(filepath.Join(sessionDir, "workspace.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := copilotDriver{}.FindSessions([]string{"/wt/a", "/wt/b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sessions["/wt/a"].SessionID != "sess-1" {
+		t.Fatalf("sessions = %+v, want sess-1 for /wt/a", sessions)
+	}
+	if _, ok := sessions["/wt/b"]; ok {
+		t.Fatal("expected no session for /wt/b")
+	}
+}
+
+func TestCommandDriverRequiresCommand(t *testing.T) {
+	_, err := resolveAgentDriver(config.Config{Agent: "command"})
+	if err == nil || !strings.Contains(err.Error(), "agent-command") {
+		t.Fatalf("error = %v, want agent-command requirement", err)
+	}
+}
+
+func TestCommandDriverArgs(t *testing.T) {
+	driver, err := resolveAgentDriver(config.Config{
+		Agent:           "command",
+		AgentCommand:    "aider",
+		AgentModelArgs:  "--model {model}",
+		AgentPromptArgs: "--yes --message {prompt}",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if driver.Binary() != "aider" {
+		t.Fatalf("binary = %q, want aider", driver.Binary())
+	}
+	if got, want := driver.InteractiveArgs("gpt"), []string{"aider", "--model", "gpt"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("interactive = %v, want %v", got, want)
+	}
+	if got, want := driver.InteractiveArgs(""), []string{"aider"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("interactive (no model) = %v, want %v", got, want)
+	}
+	want := []string{"aider", "--model", "gpt", "--yes", "--message", "fix the login bug"}
+	if got := driver.PromptArgs("gpt", "fix the login bug"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("prompt = %v, want %v", got, want)
+	}
+	if got := driver.ResumeArgs("", "sess"); !reflect.DeepEqual(got, []string{"aider"}) {
+		t.Fatalf("resume = %v, want [aider]", got)
+	}
+}
+
+func TestCommandDriverDefaultPromptArgs(t *testing.T) {
+	driver, err := resolveAgentDriver(config.Config{Agent: "command", AgentCommand: "myagent"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"myagent", "do it"}
+	if got := driver.PromptArgs("ignored", "do it"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("prompt = %v, want %v", got, want)
+	}
+}
+
+func TestClaudeDriverArgs(t *testing.T) {
+	driver, err := resolveAgentDriver(config.Config{Agent: "claude"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"interactive", driver.InteractiveArgs("opus"), []string{"claude", "--model", "opus"}},
+		{"prompt", driver.PromptArgs("", "do it"), []string{"claude", "--dangerously-skip-permissions", "-p", "do it"}},
+		{"resume", driver.ResumeArgs("opus", "abc"), []string{"claude", "--model", "opus", "--resume", "abc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Fatalf("args = %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCodexDriverArgs(t *testing.T) {
+	driver, err := resolveAgentDriver(config.Config{Agent: "codex"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"interactive", driver.InteractiveArgs(""), []string{"codex"}},
+		{"prompt", driver.PromptArgs("gpt-5", "do it"), []string{"codex", "exec", "--model", "gpt-5", "--dangerously-bypass-approvals-and-sandbox", "do it"}},
+		{"resume", driver.ResumeArgs("", "abc"), []string{"codex", "resume", "abc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Fatalf("args = %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultConfigPassesNoModelFlag(t *testing.T) {
+	for _, agent := range []string{"copilot-cli", "claude", "codex"} {
+		cfg := config.DefaultConfig()
+		cfg.Agent = agent
+		driver, err := resolveAgentDriver(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if args := driver.PromptArgs(cfg.Model, "hi"); slices.Contains(args, "--model") {
+			t.Errorf("%s: args = %v, want no --model without a configured model", agent, args)
+		}
+	}
+}
+
+func TestClaudeAndCodexDriversFindSessions(t *testing.T) {
+	origClaude := claudeConfigDir
+	origCodex := codexConfigDir
+	t.Cleanup(func() {
+		claudeConfigDir = origClaude
+		codexConfigDir = origCodex
+	})
+
+	claudeDir := t.TempDir()
+	codexDir := t.TempDir()
+	claudeConfigDir = func() string { return claudeDir }
+	codexConfigDir = func() string { return codexDir }
+
+	projectDir := filepath.Join(claudeDir, "projects", "-wt-a")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	claudeLine := `{"type":"user","sessionId":"claude-1","cwd":"/wt/a","timestamp":"2026-01-10T10:00:00Z","message":{"role":"user","content":"hi"}}` + "\n"
+	if err := os.WriteFile(filepath.Join(projectDir, "claude-1.jsonl"), []byte(claudeLine), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dayDir := filepath.Join(codexDir, "sessions", "2026", "01", "10")
+	if err := os.MkdirAll(dayDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	codexLine := `{"type":"session_meta","payload":{"id":"codex-1","cwd":"/wt/a"}}` + "\n"
+	if err := os.WriteFile(filepath.Join(dayDir, "rollout-1.jsonl"), []byte(codexLine), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	claudeSessions, err := claudeDriver{}.FindSessions([]string{"/wt/a"})
+	if err != nil || claudeSessions["/wt/a"].SessionID != "claude-1" {
+		t.Fatalf("claude sessions = %v, err = %v", claudeSessions, err)
+	}
+	codexSessions, err := codexDriver{}.FindSessions([]string{"/wt/a"})
+	if err != nil || codexSessions["/wt/a"].SessionID != "codex-1" {
+		t.Fatalf("codex sessions = %v, err = %v", codexSessions, err)
+	}
+}
@@ -0,0 +1,399 @@
+package cliapp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"fitz/internal/runs"
+	"fitz/internal/worktree"
+)
+
+// SuperviseCommand is the hidden fitz subcommand that wraps a background
+// agent run: `fitz __supervise <runs.json> <run-id>`.
+const SuperviseCommand = "__supervise"
+
+var resolveRunStorePath = resolveRunsPath
+var isProcessAlive = processAlive
+var logPollInterval = 500 * time.Millisecond
+
+var signalRun = signalProcessGroup
+var stopGracePeriod = 5 * time.Second
+var stopPollInterval = 100 * time.Millisecond
+
+// startAgentRun records a background run in the run registry and launches
+// it under a fitz supervisor. The caller fills in what to run (Branch, Dir,
+// Binary, Args and any metadata); the ID, log path and start time are
+// assigned here. The supervisor's output (and so the agent's) goes to the
+// run log, and it records the exit code when the agent finishes.
+//
+// Queued runs go first: any that a free slot can take are started before
+// this run, and when max-concurrent-agents runs are then live, the run is
+// queued behind them instead and started by dispatchQueuedRuns once a slot
+// frees up.
+func startAgentRun(run runs.Run) (runs.Run, error) {
+	storePath, err := resolveRunStorePath()
+	if err != nil {
+		return runs.Run{}, err
+	}
+	exe, err := executablePath()
+	if err != nil {
+		return runs.Run{}, fmt.Errorf("resolve fitz executable: %w", err)
+	}
+	limit := loadEffectiveConfig(run.Dir).AgentLimit()
+
+	run.ID = runs.NewID()
+	run.LogPath = runs.LogPath(storePath, run.ID)
+	run.StartedAt = time.Now().UTC()
+
+	// Decide and launch under the registry lock so concurrent kickoffs can't
+	// both take the last slot. The supervisor's first read waits for it.
+	var launchErr error
+	err = runs.Mutate(storePath, func(all []runs.Run) ([]runs.Run, error) {
+		startQueued(exe, storePath, all, limit)
+		if limit > 0 && (countLiveRuns(all) >= limit || len(runs.Queued(all)) > 0) {
+			run.Queued = true
+		} else {
+			launchErr = launchRun(exe, storePath, &run)
+		}
+		return append(all, run), nil
+	})
+	if err != nil {
+		return runs.Run{}, fmt.Errorf("record run: %w", err)
+	}
+	if launchErr != nil {
+		return runs.Run{}, launchErr
+	}
+	return run, nil
+}
+
+// dispatchQueuedRuns starts queued runs, in queue order, while fewer than
+// limit runs are live (limit 0 means no limit). It returns the runs it
+// started.
+func dispatchQueuedRuns(storePath string, limit int) ([]runs.Run, error) {
+	exe, err := executablePath()
+	if err != nil {
+		return nil, fmt.Errorf("resolve fitz executable: %w", err)
+	}
+
+	var started []runs.Run
+	err = runs.Mutate(storePath, func(all []runs.Run) ([]runs.Run, error) {
+		started = startQueued(exe, storePath, all, limit)
+		return all, nil
+	})
+	return started, err
+}
+
+// startQueued starts the queued runs in all, in queue order, while fewer
+// than limit runs are live, and returns them. Like launchRun, it must be
+// called inside runs.Mutate.
+func startQueued(exe, storePath string, all []runs.Run, limit int) []runs.Run {
+	var started []runs.Run
+	live := countLiveRuns(all)
+	for i := range all {
+		if !all[i].Queued {
+			continue
+		}
+		if limit > 0 && live >= limit {
+			break
+		}
+		all[i].Queued = false
+		all[i].StartedAt = time.Now().UTC()
+		if err := launchRun(exe, storePath, &all[i]); err != nil {
+			continue // recorded as failed by launchRun
+		}
+		live++
+		started = append(started, all[i])
+	}
+	return started
+}
+
+// launchRun starts the supervisor for run and records its PID, or records a
+// failed exit if it couldn't be started. It must be called inside
+// runs.Mutate, with run pointing into the registry being saved.
+func launchRun(exe, storePath string, run *runs.Run) error {
+	pid, err := runBackground(exe, []string{"fitz", SuperviseCommand, storePath, run.ID}, run.Dir, run.LogPath)
+	if err != nil {
+		now := time.Now().UTC()
+		exitCode := -1
+		run.EndedAt = &now
+		run.ExitCode = &exitCode
+		return err
+	}
+	run.PID = pid
+	return nil
+}
+
+// countLiveRuns returns how many runs have been started and are still
+// running.
+func countLiveRuns(all []runs.Run) int {
+	n := 0
+	for _, r := range all {
+		if !r.Queued && runIsLive(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// SuperviseRun runs the agent recorded under id in the registry at storePath,
+// streaming its output to stdout/stderr and recording the exit code when it
+// finishes. Interrupts are caught so the supervisor outlives the agent long
+// enough to record how it ended.
+func SuperviseRun(storePath, id string) error {
+	run, err := runs.Get(storePath, id)
+	if err != nil {
+		return err
+	}
+	if len(run.Args) == 0 {
+		return fmt.Errorf("run %q has no command", id)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	cmd := exec.Command(run.Binary, run.Args[1:]...)
+	cmd.Dir = run.Dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	exitCode := 0
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			exitCode = exitErr.ExitCode()
+		} else {
+			fmt.Fprintf(os.Stderr, "fitz: start %s: %v\n", run.Args[0], err)
+			exitCode = 127
+		}
+	}
+
+	if _, err := runs.Finish(storePath, id, exitCode); err != nil {
+		return err
+	}
+
+	// This run's slot is free now; hand it to the next queued kickoff.
+	_, err = dispatchQueuedRuns(storePath, loadEffectiveConfig(run.Dir).AgentLimit())
+	return err
+}
+
+// runIsLive reports whether a run's supervisor is still running.
+func runIsLive(run runs.Run) bool {
+	return run.ExitCode == nil && isProcessAlive(run.PID)
+}
+
+// stopAgentRun records that run was stopped, then signals its process group:
+// an interrupt first, then SIGTERM if it is still running after
+// stopGracePeriod. The supervisor outlives the agent and records its exit.
+func stopAgentRun(storePath string, run runs.Run) error {
+	if _, err := runs.MarkStopped(storePath, run.ID); err != nil {
+		return fmt.Errorf("record stop: %w", err)
+	}
+
+	if err := signalRun(run.PID, false); err != nil && isProcessAlive(run.PID) {
+		return fmt.Errorf("interrupt agent: %w", err)
+	}
+	if waitForExit(run.PID, stopGracePeriod) {
+		return nil
+	}
+
+	if err := signalRun(run.PID, true); err != nil && isProcessAlive(run.PID) {
+		return fmt.Errorf("terminate agent: %w", err)
+	}
+	if waitForExit(run.PID, stopGracePeriod) {
+		return nil
+	}
+	return fmt.Errorf("agent (pid %d) is still running after SIGTERM", run.PID)
+}
+
+func waitForExit(pid int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for isProcessAlive(pid) {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(stopPollInterval)
+	}
+	return true
+}
+
+// liveRuns returns the latest run of each branch whose agent is still
+// running, keyed by branch.
+func liveRuns(storePath string) (map[string]runs.Run, error) {
+	all, err := runs.Load(storePath)
+	if err != nil {
+		return nil, err
+	}
+	live := make(map[string]runs.Run)
+	for branch, run := range runs.Latest(all) {
+		if runIsLive(run) {
+			live[branch] = run
+		}
+	}
+	return live, nil
+}
+
+// loadLatestRuns returns the most recent background run per branch, or an
+// empty map if the registry can't be read.
+func loadLatestRuns() map[string]runs.Run {
+	storePath, err := resolveRunStorePath()
+	if err != nil {
+		return map[string]runs.Run{}
+	}
+	all, err := runs.Load(storePath)
+	if err != nil {
+		return map[string]runs.Run{}
+	}
+	return runs.Latest(all)
+}
+
+// findRun returns the run with the given id for branch, or the most recent
+// run for branch when id is empty.
+func findRun(storePath, branch, id string) (runs.Run, error) {
+	all, err := runs.Load(storePath)
+	if err != nil {
+		return runs.Run{}, err
+	}
+	if id != "" {
+		for _, r := range all {
+			if r.ID == id && r.Branch == branch {
+				return r, nil
+			}
+		}
+		return runs.Run{}, fmt.Errorf("run %q not found for %s", id, branch)
+	}
+	run, ok := runs.Latest(all)[branch]
+	if !ok {
+		return runs.Run{}, fmt.Errorf("no background runs for %s", branch)
+	}
+	return run, nil
+}
+
+// readRunLog returns a run's log. A log that hasn't been created yet reads
+// as empty.
+func readRunLog(run runs.Run) ([]byte, error) {
+	data, err := os.ReadFile(run.LogPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read log: %w", err)
+	}
+	return data, nil
+}
+
+// tailLines returns the last n lines of data, or all of it when n is not
+// positive.
+func tailLines(data []byte, n int) []byte {
+	if n <= 0 {
+		return data
+	}
+	end := len(data)
+	if end > 0 && data[end-1] == '\n' {
+		end--
+	}
+	for i := 0; i < n; i++ {
+		idx := bytes.LastIndexByte(data[:end], '\n')
+		if idx < 0 {
+			return data
+		}
+		end = idx
+	}
+	return data[end+1:]
+}
+
+// followRunLog copies new output from a run's log to w, starting at offset,
+// until the run finishes or ctx is cancelled.
+func followRunLog(ctx context.Context, w io.Writer, storePath string, run runs.Run, offset int64) error {
+	ticker := time.NewTicker(logPollInterval)
+	defer ticker.Stop()
+
+	for {
+		n, err := copyLogFrom(w, run.LogPath, offset)
+		if err != nil {
+			return err
+		}
+		offset += n
+
+		current, err := runs.Get(storePath, run.ID)
+		if err != nil {
+			return err
+		}
+		// A run with no PID yet is still being launched.
+		if current.ExitCode != nil || (current.PID != 0 && !isProcessAlive(current.PID)) {
+			// Pick up anything written between the last read and exit.
+			_, err := copyLogFrom(w, run.LogPath, offset)
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func copyLogFrom(w io.Writer, path string, offset int64) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("open log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("seek log: %w", err)
+	}
+	return io.Copy(w, f)
+}
+
+// loadBranchLog returns the log of the latest background run for branch,
+// for display in the br TUI.
+func loadBranchLog(branch string) (string, error) {
+	storePath, err := resolveRunStorePath()
+	if err != nil {
+		return "", err
+	}
+	run, err := findRun(storePath, branch, "")
+	if err != nil {
+		return "", err
+	}
+	data, err := readRunLog(run)
+	if err != nil {
+		return "", err
+	}
+	if len(data) == 0 {
+		return "(no output yet)", nil
+	}
+	return string(data), nil
+}
+
+func resolveRunsPath() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("get working directory: %w", err)
+	}
+
+	git := worktree.ShellGit{}
+	owner, repo, err := worktree.RepoID(git, cwd)
+	if err != nil {
+		return "", fmt.Errorf("identify repository: %w", err)
+	}
+
+	path, err := runs.StorePath("", owner, repo)
+	if err != nil {
+		return "", fmt.Errorf("resolve run store path: %w", err)
+	}
+	return path, nil
+}
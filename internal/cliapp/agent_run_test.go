@@ -0,0 +1,448 @@
+package cliapp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"fitz/internal/config"
+	"fitz/internal/runs"
+)
+
+func stubRunStore(t *testing.T) string {
+	t.Helper()
+	original := resolveRunStorePath
+	t.Cleanup(func() { resolveRunStorePath = original })
+
+	storePath := filepath.Join(t.TempDir(), "runs.json")
+	resolveRunStorePath = func() (string, error) { return storePath, nil }
+	return storePath
+}
+
+func TestStartAgentRunLaunchesSupervisor(t *testing.T) {
+	storePath := stubRunStore(t)
+	originalBg := runBackground
+	originalExe := executablePath
+	t.Cleanup(func() {
+		runBackground = originalBg
+		executablePath = originalExe
+	})
+	executablePath = func() (string, error) { return "/usr/bin/fitz", nil }
+
+	var gotBinary, gotDir, gotLog string
+	var gotArgs []string
+	runBackground = func(binary string, args []string, dir, logPath string) (int, error) {
+		gotBinary, gotArgs, gotDir, gotLog = binary, args, dir, logPath
+		return 4242, nil
+	}
+
+	run, err := startAgentRun(runs.Run{Branch: "feat", Dir: "/tmp/wt", Binary: "/usr/bin/copilot", Args: []string{"copilot", "-p", "hi"}, Prompt: "hi"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gotBinary != "/usr/bin/fitz" || gotDir != "/tmp/wt" || gotLog != run.LogPath {
+		t.Fatalf("runBackground(%q, _, %q, %q), want fitz in /tmp/wt logging to %q", gotBinary, gotDir, gotLog, run.LogPath)
+	}
+	if want := []string{"fitz", SuperviseCommand, storePath, run.ID}; !reflect.DeepEqual(gotArgs, want) {
+		t.Fatalf("args = %v, want %v", gotArgs, want)
+	}
+
+	stored, err := runs.Get(storePath, run.ID)
+	if err != nil {
+		t.Fatalf("get run: %v", err)
+	}
+	if stored.PID != 4242 || stored.Branch != "feat" || stored.Binary != "/usr/bin/copilot" || stored.ExitCode != nil {
+		t.Fatalf("stored run = %+v", stored)
+	}
+}
+
+func TestStartAgentRunKeepsExitOfFastSupervisor(t *testing.T) {
+	storePath := stubRunStore(t)
+	originalBg := runBackground
+	originalExe := executablePath
+	t.Cleanup(func() {
+		runBackground = originalBg
+		executablePath = originalExe
+	})
+	executablePath = func() (string, error) { return "/usr/bin/fitz", nil }
+
+	// The supervisor finishes as soon as it starts, racing the PID being
+	// recorded: its exit status must survive.
+	finished := make(chan error, 1)
+	runBackground = func(_ string, args []string, _, _ string) (int, error) {
+		go func() {
+			_, err := runs.Finish(storePath, args[len(args)-1], 3)
+			finished <- err
+		}()
+		return 4242, nil
+	}
+
+	run, err := startAgentRun(runs.Run{Branch: "feat", Dir: "/tmp/wt", Binary: "/usr/bin/copilot", Args: []string{"copilot"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := <-finished; err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+
+	stored, err := runs.Get(storePath, run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.PID != 4242 || stored.ExitCode == nil || *stored.ExitCode != 3 {
+		t.Fatalf("stored run = %+v, want PID 4242 and exit 3", stored)
+	}
+}
+
+func TestStartAgentRunRecordsLaunchFailure(t *testing.T) {
+	storePath := stubRunStore(t)
+	originalBg := runBackground
+	originalExe := executablePath
+	t.Cleanup(func() {
+		runBackground = originalBg
+		executablePath = originalExe
+	})
+	executablePath = func() (string, error) { return "/usr/bin/fitz", nil }
+	runBackground = func(string, []string, string, string) (int, error) {
+		return 0, errors.New("boom")
+	}
+
+	if _, err := startAgentRun(runs.Run{Branch: "feat", Dir: "/tmp/wt", Binary: "/usr/bin/copilot", Args: []string{"copilot"}}); err == nil {
+		t.Fatal("expected launch error")
+	}
+
+	all, err := runs.Load(storePath)
+	if err != nil || len(all) != 1 {
+		t.Fatalf("runs = %v, err = %v", all, err)
+	}
+	if all[0].Label(false) != "failed (exit -1)" {
+		t.Fatalf("label = %q, want failed (exit -1)", all[0].Label(false))
+	}
+}
+
+func TestSuperviseRunRecordsExitCode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+
+	storePath := filepath.Join(t.TempDir(), "runs.json")
+	if err := runs.Add(storePath, runs.Run{ID: "r1", Dir: t.TempDir(), Binary: sh, Args: []string{"sh", "-c", "exit 3"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SuperviseRun(storePath, "r1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	run, err := runs.Get(storePath, "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.ExitCode == nil || *run.ExitCode != 3 || run.EndedAt == nil {
+		t.Fatalf("run = %+v, want exit code 3", run)
+	}
+}
+
+func TestTailLines(t *testing.T) {
+	data := []byte("one\ntwo\nthree\n")
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{0, "one\ntwo\nthree\n"},
+		{1, "three\n"},
+		{2, "two\nthree\n"},
+		{5, "one\ntwo\nthree\n"},
+	}
+	for _, tt := range tests {
+		if got := string(tailLines(data, tt.n)); got != tt.want {
+			t.Errorf("tailLines(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+	if got := string(tailLines([]byte("a\nb"), 1)); got != "b" {
+		t.Errorf("tailLines without trailing newline = %q, want %q", got, "b")
+	}
+}
+
+func TestFindRun(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "runs.json")
+	base := time.Date(2026, 2, 19, 6, 0, 0, 0, time.UTC)
+	for _, r := range []runs.Run{
+		{ID: "old", Branch: "feat", StartedAt: base},
+		{ID: "new", Branch: "feat", StartedAt: base.Add(time.Minute)},
+		{ID: "other", Branch: "other", StartedAt: base},
+	} {
+		if err := runs.Add(storePath, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if run, err := findRun(storePath, "feat", ""); err != nil || run.ID != "new" {
+		t.Fatalf("latest = %+v, err = %v; want new", run, err)
+	}
+	if run, err := findRun(storePath, "feat", "old"); err != nil || run.ID != "old" {
+		t.Fatalf("chosen = %+v, err = %v; want old", run, err)
+	}
+	if _, err := findRun(storePath, "feat", "other"); err == nil {
+		t.Fatal("expected error for run belonging to another branch")
+	}
+	if _, err := findRun(storePath, "missing", ""); err == nil {
+		t.Fatal("expected error for branch without runs")
+	}
+}
+
+func TestFollowRunLogStopsWhenRunFinishes(t *testing.T) {
+	originalInterval := logPollInterval
+	originalAlive := isProcessAlive
+	t.Cleanup(func() {
+		logPollInterval = originalInterval
+		isProcessAlive = originalAlive
+	})
+	logPollInterval = time.Millisecond
+	isProcessAlive = func(int) bool { return true }
+
+	dir := t.TempDir()
+	storePath := filepath.Join(dir, "runs.json")
+	run := runs.Run{ID: "r1", Branch: "feat", PID: 99, LogPath: runs.LogPath(storePath, "r1")}
+	if err := runs.Add(storePath, run); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(run.LogPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(run.LogPath, []byte("already shown\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Append output and finish the run while following.
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		f, err := os.OpenFile(run.LogPath, os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return
+		}
+		_, _ = f.WriteString("new output\n")
+		_ = f.Close()
+		_, _ = runs.Finish(storePath, "r1", 0)
+	}()
+
+	var out bytes.Buffer
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := followRunLog(ctx, &out, storePath, run, int64(len("already shown\n"))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("follow did not stop when the run finished")
+	}
+	if out.String() != "new output\n" {
+		t.Fatalf("followed output = %q, want %q", out.String(), "new output\n")
+	}
+}
+
+// stubStopSignals makes signalRun record signals and kills the fake agent
+// once it receives a signal of the given strength.
+func stubStopSignals(t *testing.T, diesOnTerminate bool) *[]bool {
+	t.Helper()
+	originalSignal := signalRun
+	originalAlive := isProcessAlive
+	originalGrace := stopGracePeriod
+	originalPoll := stopPollInterval
+	t.Cleanup(func() {
+		signalRun = originalSignal
+		isProcessAlive = originalAlive
+		stopGracePeriod = originalGrace
+		stopPollInterval = originalPoll
+	})
+	stopGracePeriod = 20 * time.Millisecond
+	stopPollInterval = time.Millisecond
+
+	var signals []bool
+	alive := true
+	signalRun = func(_ int, terminate bool) error {
+		signals = append(signals, terminate)
+		if terminate || !diesOnTerminate {
+			alive = false
+		}
+		return nil
+	}
+	isProcessAlive = func(int) bool { return alive }
+	return &signals
+}
+
+func TestStopAgentRunInterruptsFirst(t *testing.T) {
+	signals := stubStopSignals(t, false)
+	storePath := filepath.Join(t.TempDir(), "runs.json")
+	run := runs.Run{ID: "r1", Branch: "feat", PID: 321}
+	if err := runs.Add(storePath, run); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stopAgentRun(storePath, run); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(*signals, []bool{false}) {
+		t.Fatalf("signals = %v, want a single interrupt", *signals)
+	}
+
+	stored, err := runs.Get(storePath, "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stored.Label(false); got != "stopped" {
+		t.Fatalf("label = %q, want stopped", got)
+	}
+}
+
+func TestStopAgentRunEscalatesToTerminate(t *testing.T) {
+	signals := stubStopSignals(t, true)
+	storePath := filepath.Join(t.TempDir(), "runs.json")
+	run := runs.Run{ID: "r1", Branch: "feat", PID: 321}
+	if err := runs.Add(storePath, run); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stopAgentRun(storePath, run); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(*signals, []bool{false, true}) {
+		t.Fatalf("signals = %v, want interrupt then terminate", *signals)
+	}
+}
+
+// stubQueue stubs everything startAgentRun and dispatchQueuedRuns touch:
+// the run store, the fitz executable, the agent limit and runBackground.
+// Launched runs are alive until their PID is removed from the returned set.
+func stubQueue(t *testing.T, limit string) (storePath string, launched *[]string, alive map[int]bool) {
+	t.Helper()
+	storePath = stubRunStore(t)
+	originalBg := runBackground
+	originalExe := executablePath
+	originalCfg := loadEffectiveConfig
+	originalAlive := isProcessAlive
+	t.Cleanup(func() {
+		runBackground = originalBg
+		executablePath = originalExe
+		loadEffectiveConfig = originalCfg
+		isProcessAlive = originalAlive
+	})
+	executablePath = func() (string, error) { return "/usr/bin/fitz", nil }
+	loadEffectiveConfig = func(string) config.Config { return config.Config{MaxConcurrentAgents: limit} }
+
+	launched = &[]string{}
+	alive = map[int]bool{}
+	nextPID := 100
+	runBackground = func(_ string, args []string, _, _ string) (int, error) {
+		*launched = append(*launched, args[len(args)-1])
+		nextPID++
+		alive[nextPID] = true
+		return nextPID, nil
+	}
+	isProcessAlive = func(pid int) bool { return alive[pid] }
+	return storePath, launched, alive
+}
+
+func TestStartAgentRunQueuesAtLimit(t *testing.T) {
+	storePath, launched, alive := stubQueue(t, "1")
+
+	first, err := startAgentRun(runs.Run{Branch: "a", Args: []string{"copilot"}})
+	if err != nil || first.Queued {
+		t.Fatalf("first run = %+v, err = %v; want started", first, err)
+	}
+	second, err := startAgentRun(runs.Run{Branch: "b", Args: []string{"copilot"}})
+	if err != nil || !second.Queued {
+		t.Fatalf("second run = %+v, err = %v; want queued", second, err)
+	}
+	third, err := startAgentRun(runs.Run{Branch: "c", Args: []string{"copilot"}})
+	if err != nil || !third.Queued {
+		t.Fatalf("third run = %+v, err = %v; want queued", third, err)
+	}
+	if len(*launched) != 1 {
+		t.Fatalf("launched %v, want only the first run", *launched)
+	}
+
+	// Nothing starts while the first run is still going.
+	if started, err := dispatchQueuedRuns(storePath, 1); err != nil || len(started) != 0 {
+		t.Fatalf("dispatch while full started %v, err = %v", started, err)
+	}
+
+	delete(alive, first.PID)
+	started, err := dispatchQueuedRuns(storePath, 1)
+	if err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	if len(started) != 1 || started[0].ID != second.ID {
+		t.Fatalf("started %v, want the second run", started)
+	}
+
+	stored, err := runs.Get(storePath, second.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Queued || stored.PID == 0 {
+		t.Fatalf("dispatched run = %+v, want started with a PID", stored)
+	}
+}
+
+func TestStartAgentRunKeepsQueueOrder(t *testing.T) {
+	storePath, launched, alive := stubQueue(t, "1")
+
+	first, _ := startAgentRun(runs.Run{Branch: "a", Args: []string{"copilot"}})
+	second, _ := startAgentRun(runs.Run{Branch: "b", Args: []string{"copilot"}})
+
+	// The slot frees up, but nothing has dispatched the queue yet: a new
+	// kickoff must not jump ahead of the queued one.
+	delete(alive, first.PID)
+	third, err := startAgentRun(runs.Run{Branch: "c", Args: []string{"copilot"}})
+	if err != nil || !third.Queued {
+		t.Fatalf("third run = %+v, err = %v; want queued", third, err)
+	}
+	if want := []string{first.ID, second.ID}; !reflect.DeepEqual(*launched, want) {
+		t.Fatalf("launched %v, want %v (queued run first)", *launched, want)
+	}
+	queued, err := runs.Load(storePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q := runs.Queued(queued); len(q) != 1 || q[0].ID != third.ID {
+		t.Fatalf("queue = %+v, want only the third run", q)
+	}
+}
+
+func TestSuperviseRunStartsNextQueuedRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+	storePath, launched, _ := stubQueue(t, "1")
+
+	dir := t.TempDir()
+	if err := runs.Add(storePath, runs.Run{ID: "r1", Branch: "a", Dir: dir, Binary: sh, Args: []string{"sh", "-c", "exit 0"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := runs.Add(storePath, runs.Run{ID: "r2", Branch: "b", Dir: dir, Queued: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SuperviseRun(storePath, "r1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(*launched, []string{"r2"}) {
+		t.Fatalf("launched %v, want the queued run r2", *launched)
+	}
+}
@@ -3,6 +3,8 @@ package cliapp
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -38,15 +40,17 @@ func TestAgentStatusSetsMessage(t *testing.T) {
 		return status.BranchStatus{}, nil
 	}
 
-	var out bytes.Buffer
-	if err := AgentStatus(&out, "Implementing auth", ""); err != nil {
+	result, err := AgentStatus("Implementing auth", "", AgentStateUpdate{})
+	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
 	if statusCall.path != "/tmp/status.json" || statusCall.branch != "feature-auth" || statusCall.message != "Implementing auth" {
 		t.Fatalf("status call = %+v", statusCall)
 	}
-	if !strings.Contains(out.String(), "updated status for feature-auth") {
+	var out bytes.Buffer
+	result.WriteText(&out)
+	if result.Branch != "feature-auth" || !strings.Contains(out.String(), "updated status for feature-auth") {
 		t.Fatalf("stdout = %q", out.String())
 	}
 }
@@ -79,8 +83,7 @@ func TestAgentStatusSetsPR(t *testing.T) {
 		return status.BranchStatus{}, nil
 	}
 
-	var out bytes.Buffer
-	if err := AgentStatus(&out, "", "https://github.com/acme/repo/pull/42"); err != nil {
+	if _, err := AgentStatus("", "https://github.com/acme/repo/pull/42", AgentStateUpdate{}); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	if prCall.prURL != "https://github.com/acme/repo/pull/42" {
@@ -113,8 +116,7 @@ func TestAgentStatusTruncatesMessage(t *testing.T) {
 		return status.BranchStatus{}, nil
 	}
 
-	var out bytes.Buffer
-	if err := AgentStatus(&out, long, ""); err != nil {
+	if _, err := AgentStatus(long, "", AgentStateUpdate{}); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	if len(got) != 80 {
@@ -123,8 +125,7 @@ func TestAgentStatusTruncatesMessage(t *testing.T) {
 }
 
 func TestAgentStatusRequiresUpdate(t *testing.T) {
-	var out bytes.Buffer
-	err := AgentStatus(&out, "", "")
+	_, err := AgentStatus("", "", AgentStateUpdate{})
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -151,9 +152,64 @@ func TestAgentStatusPropagatesErrors(t *testing.T) {
 		return status.BranchStatus{}, nil
 	}
 
-	var out bytes.Buffer
-	err := AgentStatus(&out, "hello", "")
+	_, err := AgentStatus("hello", "", AgentStateUpdate{})
 	if err == nil || !strings.Contains(err.Error(), "bad repo") {
 		t.Fatalf("error = %v", err)
 	}
 }
+
+func TestAgentStatusNeedsInputNotifies(t *testing.T) {
+	origPath := resolveAgentStatusStorePath
+	origBranch := resolveCurrentBranch
+	origNotify := notifyAgent
+	t.Cleanup(func() {
+		resolveAgentStatusStorePath = origPath
+		resolveCurrentBranch = origBranch
+		notifyAgent = origNotify
+	})
+
+	storePath := filepath.Join(t.TempDir(), "status.json")
+	resolveAgentStatusStorePath = func() (string, error) { return storePath, nil }
+	resolveCurrentBranch = func() (string, error) { return "feature-auth", nil }
+	notified := 0
+	notifyAgent = func(_ io.Writer, clear bool) error {
+		if clear {
+			t.Fatal("notify should not clear")
+		}
+		notified++
+		return nil
+	}
+
+	result, err := AgentStatus("", "", AgentStateUpdate{State: "working", Progress: "3/5", Detail: "running tests"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.State != status.StateWorking || result.Progress == nil || *result.Progress != 0.6 || result.Detail != "running tests" {
+		t.Fatalf("result = %+v", result.BranchStatus)
+	}
+	if notified != 0 {
+		t.Fatalf("notified %d times for working, want 0", notified)
+	}
+
+	for range 2 {
+		if _, err := AgentStatus("Which database?", "", AgentStateUpdate{State: "needs-input"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if notified != 1 {
+		t.Fatalf("notified %d times, want once when the state changed to needs-input", notified)
+	}
+}
+
+func TestAgentStatusRejectsBadState(t *testing.T) {
+	for _, update := range []AgentStateUpdate{
+		{State: "sleeping"},
+		{State: "working", Progress: "lots"},
+		{Progress: "1/2"},
+		{Detail: "no state"},
+	} {
+		if _, err := AgentStatus("hello", "", update); err == nil {
+			t.Errorf("AgentStatus(%+v) succeeded, want error", update)
+		}
+	}
+}
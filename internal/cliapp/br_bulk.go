@@ -0,0 +1,184 @@
+package cliapp
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"fitz/internal/worktree"
+)
+
+// brRemoveConcurrency caps how many worktrees a bulk delete works on at
+// once. The git commands of the removals still run one at a time (see
+// removeWorktree).
+const brRemoveConcurrency = 4
+
+// brOp is an action running against one worktree in the background.
+type brOp string
+
+const (
+	brOpQueued   brOp = "queued"
+	brOpRemoving brOp = "removing…"
+	brOpStopping brOp = "stopping…"
+)
+
+// brOpDoneMsg reports that the remove or stop of a worktree finished.
+type brOpDoneMsg struct {
+	name   string
+	remove bool
+	err    error
+}
+
+// targets returns the names the next bulk action applies to: the marked
+// worktrees in list order, or the worktree under the cursor when none are
+// marked.
+func (m brModel) targets() []string {
+	var names []string
+	for _, wt := range m.all[min(1, len(m.all)):] {
+		if m.marked[worktreeName(wt)] {
+			names = append(names, worktreeName(wt))
+		}
+	}
+	if len(names) == 0 && m.cursor > 0 && m.cursor < len(m.worktrees) {
+		names = []string{worktreeName(m.worktrees[m.cursor])}
+	}
+	return names
+}
+
+// startRemovals queues names for removal and starts the first few.
+func (m brModel) startRemovals(names []string, force bool) (brModel, tea.Cmd) {
+	for _, name := range names {
+		if _, busy := m.ops[name]; busy {
+			continue
+		}
+		m.ops[name] = brOpQueued
+		delete(m.failed, name)
+		delete(m.marked, name)
+		m.removeQueue = append(m.removeQueue, brRemoval{name: name, force: force})
+	}
+	return m.nextRemovals()
+}
+
+// nextRemovals starts queued removals while fewer than
+// brRemoveConcurrency are running.
+func (m brModel) nextRemovals() (brModel, tea.Cmd) {
+	running := 0
+	for _, op := range m.ops {
+		if op == brOpRemoving {
+			running++
+		}
+	}
+	var cmds []tea.Cmd
+	for running < brRemoveConcurrency && len(m.removeQueue) > 0 {
+		next := m.removeQueue[0]
+		m.removeQueue = m.removeQueue[1:]
+		m.ops[next.name] = brOpRemoving
+		running++
+		remove := m.onRemove
+		cmds = append(cmds, func() tea.Msg {
+			var err error
+			if remove != nil {
+				err = remove(next.name, next.force)
+			}
+			return brOpDoneMsg{name: next.name, remove: true, err: err}
+		})
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// startStops stops the background agents of names concurrently, skipping
+// worktrees without a running agent.
+func (m brModel) startStops(names []string) (brModel, tea.Cmd) {
+	if m.onStop == nil {
+		return m, nil
+	}
+	var cmds []tea.Cmd
+	for _, name := range names {
+		if _, busy := m.ops[name]; busy || !m.hasLiveRun(name) {
+			continue
+		}
+		m.ops[name] = brOpStopping
+		delete(m.failed, name)
+		delete(m.marked, name)
+		stop := m.onStop
+		cmds = append(cmds, func() tea.Msg {
+			return brOpDoneMsg{name: name, err: stop(name)}
+		})
+	}
+	if len(cmds) == 0 {
+		m.notice = "no running agents to stop"
+		return m, nil
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// finishOp records the outcome of a remove or stop. Removed worktrees leave
+// the list; failures stay listed with their error.
+func (m brModel) finishOp(msg brOpDoneMsg) (brModel, tea.Cmd) {
+	delete(m.ops, msg.name)
+	if msg.err != nil {
+		m.failed[msg.name] = msg.err.Error()
+		m.opErrs = append(m.opErrs, msg.name)
+	} else if msg.remove {
+		m.refreshSeq++
+		m.all = slices.DeleteFunc(m.all, func(wt worktree.WorktreeInfo) bool {
+			return worktreeName(wt) == msg.name
+		})
+		m = m.updateView()
+	}
+
+	var cmd tea.Cmd
+	if msg.remove {
+		m, cmd = m.nextRemovals()
+	}
+	if len(m.ops) == 0 {
+		m = m.failureBanner(m.opErrs, msg.remove)
+		m.opErrs = nil
+		return m, tea.Batch(cmd, m.refreshCmd())
+	}
+	return m, cmd
+}
+
+// failureBanner puts the failures of the last remove or stop of names in
+// the banner above the list.
+func (m brModel) failureBanner(names []string, remove bool) brModel {
+	verb := "stop the agent in"
+	if remove {
+		verb = "remove"
+	}
+	switch len(names) {
+	case 0:
+	case 1:
+		m.notice = fmt.Sprintf("could not %s %s: %s", verb, names[0], firstLine(m.failed[names[0]]))
+	default:
+		m.notice = fmt.Sprintf("could not %s %d worktrees (%s); see the rows marked failed", verb, len(names), strings.Join(names, ", "))
+	}
+	return m
+}
+
+// waitForOps explains why the TUI won't quit or publish yet.
+func (m brModel) waitForOps() brModel {
+	m.notice = fmt.Sprintf("waiting for %d worktree actions to finish (ctrl+c quits anyway)", len(m.ops))
+	return m
+}
+
+// hasLiveRun reports whether the worktree's latest background run is still
+// running.
+func (m brModel) hasLiveRun(name string) bool {
+	run, ok := m.runs[name]
+	return ok && run.PID != 0 && run.ExitCode == nil && run.StoppedAt == nil && isProcessAlive(run.PID)
+}
+
+// opStatus returns the STATUS column override for a worktree with an
+// action in progress or a failed one, and the message to show with it.
+func (m brModel) opStatus(name string) (statusText, message string, ok bool) {
+	if op, busy := m.ops[name]; busy {
+		return string(op), "", true
+	}
+	if err, failed := m.failed[name]; failed {
+		return "failed", strings.ReplaceAll(err, "\n", " "), true
+	}
+	return "", "", false
+}
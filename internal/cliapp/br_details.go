@@ -0,0 +1,149 @@
+package cliapp
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"fitz/internal/status"
+	"fitz/internal/worktree"
+)
+
+// brDetailCommits is how many recent commits the detail pane lists.
+const brDetailCommits = 5
+
+// brDetailChanges caps the `git status --short` lines in the detail pane.
+const brDetailChanges = 8
+
+// brDetailHistory is how many recent status events the detail pane lists.
+const brDetailHistory = 5
+
+// brDetails caches a worktree's git details and the latest events of its
+// status history, keyed by path in the br model. stale entries are still
+// shown while they are reloaded.
+type brDetails struct {
+	details worktree.Details
+	err     error
+	history []status.Event
+	loading bool
+	stale   bool
+}
+
+// brDetailsMsg carries git details and status history loaded for the
+// worktree at path.
+type brDetailsMsg struct {
+	path    string
+	details worktree.Details
+	err     error
+	history []status.Event
+}
+
+// detailsCmd loads the selected worktree's git details when the detail pane
+// is open and they aren't cached, so git only runs for rows the user stops
+// on.
+func (m brModel) detailsCmd() tea.Cmd {
+	if !m.showDetails || m.loadDetails == nil || m.dissolving >= 0 || m.cursor < 1 || m.cursor >= len(m.worktrees) {
+		return nil
+	}
+	path, name := m.worktrees[m.cursor].Path, worktreeName(m.worktrees[m.cursor])
+	entry, ok := m.details[path]
+	if ok && (entry.loading || !entry.stale) {
+		return nil
+	}
+	entry.loading = true
+	m.details[path] = entry
+
+	load, loadHistory := m.loadDetails, m.loadHistory
+	return func() tea.Msg {
+		d, err := load(path)
+		msg := brDetailsMsg{path: path, details: d, err: err}
+		if loadHistory != nil {
+			// The history is best-effort: without it the pane just omits it.
+			if events, err := loadHistory(name); err == nil {
+				msg.history = events[max(0, len(events)-brDetailHistory):]
+			}
+		}
+		return msg
+	}
+}
+
+// viewDetails renders the detail pane for the worktree under the cursor.
+func (m brModel) viewDetails() string {
+	if m.cursor < 1 || m.cursor >= len(m.worktrees) {
+		return ""
+	}
+	wt := m.worktrees[m.cursor]
+	name := worktreeName(wt)
+
+	var b strings.Builder
+	b.WriteString(dimStyle.Render("── " + name + " " + strings.Repeat("─", max(0, 40-len(name)))))
+	b.WriteString("\n")
+	line := func(label, value string) {
+		b.WriteString(fmt.Sprintf("  %-8s %s\n", label, value))
+	}
+
+	line("Path", wt.Path)
+
+	entry, ok := m.details[wt.Path]
+	switch {
+	case !ok || (entry.loading && entry.details.Path == "" && entry.err == nil):
+		line("Git", dimStyle.Render("loading…"))
+	case entry.err != nil:
+		line("Git", entry.err.Error())
+	default:
+		d := entry.details
+		if d.Base != "" {
+			line("Base", fmt.Sprintf("%s (%d ahead, %d behind)", d.Base, d.Ahead, d.Behind))
+		}
+		if len(d.Changes) == 0 {
+			line("Changes", "clean")
+		}
+		for i, c := range d.Changes {
+			label := ""
+			if i == 0 {
+				label = "Changes"
+			}
+			if i == brDetailChanges {
+				line(label, dimStyle.Render(fmt.Sprintf("… %d more", len(d.Changes)-i)))
+				break
+			}
+			line(label, c)
+		}
+		for i, c := range d.Commits {
+			label := ""
+			if i == 0 {
+				label = "Commits"
+			}
+			line(label, c)
+		}
+	}
+
+	if info, ok := m.sessions[wt.Path]; ok && info.Summary != "" {
+		line("Session", info.Summary)
+	}
+	if st, ok := m.statuses[name]; ok && st.Message != "" {
+		msg := st.Message
+		if !st.UpdatedAt.IsZero() {
+			msg += dimStyle.Render(" (" + formatAge(time.Since(st.UpdatedAt)) + " ago)")
+		}
+		line("Status", msg)
+	}
+	if st := m.statuses[name]; st.State != "" {
+		line("State", stateStyle(st.State).Render(stateBadge(st.AgentState)))
+		for _, l := range strings.Split(st.Detail, "\n") {
+			if l != "" {
+				line("", l)
+			}
+		}
+	}
+	for i, e := range entry.history {
+		label := ""
+		if i == 0 {
+			label = "History"
+		}
+		line(label, eventText(e)+dimStyle.Render(" ("+formatAge(time.Since(e.At))+" ago)"))
+	}
+	return b.String()
+}
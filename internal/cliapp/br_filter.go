@@ -0,0 +1,263 @@
+package cliapp
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fitz/internal/worktree"
+)
+
+// brSort is the order of the br TUI's worktree list.
+type brSort int
+
+const (
+	brSortDefault  brSort = iota // blocked agents first, then git worktree order, fanouts grouped
+	brSortName                   // branch name
+	brSortActivity               // most recent agent activity first
+	brSortPR                     // open PRs first, then merged, closed and none
+)
+
+func (s brSort) String() string {
+	switch s {
+	case brSortName:
+		return "name"
+	case brSortActivity:
+		return "activity"
+	case brSortPR:
+		return "PR state"
+	default:
+		return "default"
+	}
+}
+
+// next returns the sort mode the s key switches to.
+func (s brSort) next() brSort {
+	return (s + 1) % (brSortPR + 1)
+}
+
+// fuzzyMatch reports whether every whitespace-separated term of pattern
+// appears in text as a case-insensitive subsequence, so "ath fx" matches
+// "feat/auth: fix redirect".
+func fuzzyMatch(pattern, text string) bool {
+	text = strings.ToLower(text)
+	for _, term := range strings.Fields(strings.ToLower(pattern)) {
+		rest := text
+		for _, r := range term {
+			i := strings.IndexRune(rest, r)
+			if i < 0 {
+				return false
+			}
+			rest = rest[i+len(string(r)):]
+		}
+	}
+	return true
+}
+
+// worktreeName is the name the br TUI shows and acts on for wt: its branch,
+// or its directory name when detached.
+func worktreeName(wt worktree.WorktreeInfo) string {
+	if wt.Branch != "" {
+		return wt.Branch
+	}
+	return wt.Name
+}
+
+// lastActivity returns the most recent sign of agent activity in wt: its
+// latest session update, status update or background run start or end.
+func (m brModel) lastActivity(wt worktree.WorktreeInfo) time.Time {
+	latest := m.sessions[wt.Path].UpdatedAt
+	name := worktreeName(wt)
+	if t := m.statuses[name].UpdatedAt; t.After(latest) {
+		latest = t
+	}
+	if run, ok := m.runs[name]; ok {
+		if run.StartedAt.After(latest) {
+			latest = run.StartedAt
+		}
+		if run.EndedAt != nil && run.EndedAt.After(latest) {
+			latest = *run.EndedAt
+		}
+	}
+	return latest
+}
+
+// isActive reports whether wt has a running agent or saw agent activity
+// within the active window.
+func (m brModel) isActive(wt worktree.WorktreeInfo) bool {
+	if m.hasLiveRun(worktreeName(wt)) {
+		return true
+	}
+	latest := m.lastActivity(wt)
+	return !latest.IsZero() && time.Since(latest) < m.activeWindow
+}
+
+// prRank orders worktrees for the PR sort: open, draft, merged, closed,
+// PR of unknown state, then no PR.
+func (m brModel) prRank(wt worktree.WorktreeInfo) int {
+	url := m.statuses[worktreeName(wt)].PRURL
+	if url == "" {
+		return 5
+	}
+	switch m.prStates[url] {
+	case "open":
+		return 0
+	case "draft":
+		return 1
+	case "merged":
+		return 2
+	case "closed":
+		return 3
+	default:
+		return 4
+	}
+}
+
+// brPRStateMsg carries a PR state looked up for the PR sort.
+type brPRStateMsg struct {
+	url   string
+	state string
+}
+
+// pullRequestState asks gh for the state of the pull request at prURL:
+// "open", "draft", "merged" or "closed", or "" when it can't be found.
+func pullRequestState(dir, prURL string) string {
+	out, err := runGh(dir, "pr", "view", prURL, "--json", "state,isDraft")
+	if err != nil {
+		return ""
+	}
+	var pr struct {
+		State   string `json:"state"`
+		IsDraft bool   `json:"isDraft"`
+	}
+	if err := json.Unmarshal([]byte(out), &pr); err != nil {
+		return ""
+	}
+	if pr.State == "OPEN" && pr.IsDraft {
+		return "draft"
+	}
+	return strings.ToLower(pr.State)
+}
+
+func prNumber(prURL string) int {
+	if m := pullURLPattern.FindStringSubmatch(prURL); len(m) == 2 {
+		n, _ := strconv.Atoi(m[1])
+		return n
+	}
+	return 0
+}
+
+// visibleWorktrees returns the root worktree followed by the worktrees that
+// pass the filter and active-only toggle, in the current sort order.
+func (m brModel) visibleWorktrees() []worktree.WorktreeInfo {
+	if len(m.all) == 0 {
+		return nil
+	}
+	visible := []worktree.WorktreeInfo{m.all[0]}
+	for _, wt := range m.all[1:] {
+		if m.onlyActive && !m.isActive(wt) {
+			continue
+		}
+		if m.filter != "" {
+			text := worktreeName(wt) + " " + describeWorktree(wt, m.statuses, m.sessions, m.runs).Message
+			if !fuzzyMatch(m.filter, text) {
+				continue
+			}
+		}
+		visible = append(visible, wt)
+	}
+
+	rest := visible[1:]
+	byName := func(i, j int) bool {
+		return strings.ToLower(worktreeName(rest[i])) < strings.ToLower(worktreeName(rest[j]))
+	}
+	switch m.sort {
+	case brSortDefault:
+		sort.SliceStable(rest, func(i, j int) bool {
+			return m.statuses[worktreeName(rest[i])].NeedsAttention() && !m.statuses[worktreeName(rest[j])].NeedsAttention()
+		})
+	case brSortName:
+		sort.SliceStable(rest, byName)
+	case brSortActivity:
+		sort.SliceStable(rest, func(i, j int) bool {
+			return m.lastActivity(rest[i]).After(m.lastActivity(rest[j]))
+		})
+	case brSortPR:
+		sort.SliceStable(rest, func(i, j int) bool {
+			ri, rj := m.prRank(rest[i]), m.prRank(rest[j])
+			if ri != rj {
+				return ri < rj
+			}
+			pi := prNumber(m.statuses[worktreeName(rest[i])].PRURL)
+			pj := prNumber(m.statuses[worktreeName(rest[j])].PRURL)
+			if pi != pj {
+				return pi > pj
+			}
+			return byName(i, j)
+		})
+	}
+	return visible
+}
+
+// updateView recomputes the visible worktrees after the data, filter, sort
+// or active-only toggle changed, keeping the cursor on the same worktree
+// when it is still visible.
+func (m brModel) updateView() brModel {
+	selected := ""
+	if m.cursor > 0 && m.cursor < len(m.worktrees) {
+		selected = worktreeName(m.worktrees[m.cursor])
+	}
+
+	m.worktrees = m.visibleWorktrees()
+
+	for i, wt := range m.worktrees {
+		if i > 0 && selected != "" && worktreeName(wt) == selected {
+			m.cursor = i
+			return m
+		}
+	}
+	if m.cursor >= len(m.worktrees) {
+		m.cursor = len(m.worktrees) - 1
+	}
+	if m.cursor < 1 {
+		m.cursor = 1
+	}
+	if len(m.worktrees) <= 1 {
+		m.cursor = 0
+	}
+	return m
+}
+
+// missingPRStates returns the PR URLs whose state hasn't been looked up.
+func (m brModel) missingPRStates() []string {
+	var urls []string
+	for _, wt := range m.all {
+		url := m.statuses[worktreeName(wt)].PRURL
+		if _, known := m.prStates[url]; url != "" && !known {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// filterHint describes the active filter, sort and active-only toggle, or
+// returns "" when the full list is shown in its default order.
+func (m brModel) filterHint() string {
+	var parts []string
+	if m.filter != "" {
+		parts = append(parts, "filter: "+m.filter)
+	}
+	if m.sort != brSortDefault {
+		parts = append(parts, "sort: "+m.sort.String())
+	}
+	if m.onlyActive {
+		parts = append(parts, "active in the last "+formatAge(m.activeWindow))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	parts = append(parts, strconv.Itoa(len(m.worktrees)-1)+" of "+strconv.Itoa(len(m.all)-1)+" worktrees")
+	return strings.Join(parts, " · ")
+}
@@ -0,0 +1,151 @@
+package cliapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"fitz/internal/runs"
+	"fitz/internal/session"
+	"fitz/internal/status"
+	"fitz/internal/worktree"
+)
+
+// BrListFormats are the output formats accepted by `br list --format`.
+var BrListFormats = []string{"table", "json", "tsv"}
+
+// isTerminal reports whether v is a terminal. Non-terminal stdin or stdout
+// makes `br list` print a table instead of starting the TUI.
+var isTerminal = func(v any) bool {
+	f, ok := v.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// brListRow is one worktree as printed by `br list --format`.
+type brListRow struct {
+	Name      string     `json:"name"`
+	Path      string     `json:"path"`
+	Branch    string     `json:"branch"`
+	Current   bool       `json:"current"`
+	PRURL     string     `json:"pr_url"`
+	State     string     `json:"state"`
+	UpdatedAt *time.Time `json:"updated_at"`
+	Message   string     `json:"message"`
+
+	// The state the agent reported with `agent status --state`.
+	AgentState string   `json:"agent_state"`
+	Progress   *float64 `json:"progress"`
+	Detail     string   `json:"detail"`
+
+	pr     string // short PR label for the table
+	status string // state as shown in the TUI, e.g. "12m ago"
+}
+
+// brListRows computes the same per-worktree data the br TUI shows. The first
+// entry of list is the repository root, which has no agent details and stays
+// first; worktrees whose agent is blocked or needs input come next.
+func brListRows(list []worktree.WorktreeInfo, current string, statuses map[string]status.BranchStatus, sessions map[string]session.SessionInfo, latestRuns map[string]runs.Run) []brListRow {
+	rows := make([]brListRow, 0, len(list))
+	for i, wt := range list {
+		row := brListRow{Path: wt.Path, Branch: wt.Branch}
+		row.Name = wt.Branch
+		if i == 0 {
+			row.Name = "root"
+		} else if row.Name == "" {
+			row.Name = wt.Name
+		}
+		row.Current = (i == 0 && current == "root") || (i > 0 && current == wt.Name)
+
+		if i > 0 {
+			d := describeWorktree(wt, statuses, sessions, latestRuns)
+			row.PRURL, row.pr = d.PRURL, d.PR
+			row.State, row.status = d.State, d.Status
+			row.Message = d.Message
+			row.AgentState, row.Progress, row.Detail = d.Agent.State, d.Agent.Progress, d.Agent.Detail
+			if !d.UpdatedAt.IsZero() {
+				updated := d.UpdatedAt.UTC()
+				row.UpdatedAt = &updated
+			}
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) > 1 {
+		rest := rows[1:]
+		sort.SliceStable(rest, func(i, j int) bool {
+			return needsAttention(rest[i].AgentState) && !needsAttention(rest[j].AgentState)
+		})
+	}
+	return rows
+}
+
+// needsAttention reports whether an agent in state is waiting on a person.
+func needsAttention(state string) bool {
+	return status.AgentState{State: state}.NeedsAttention()
+}
+
+// writeBrList renders rows in format: an aligned table for people, JSON, or
+// header-less TSV with the columns name, path, branch, current, pr_url,
+// state, updated_at, message, agent_state and progress.
+func writeBrList(w io.Writer, format string, rows []brListRow) error {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encode worktrees: %w", err)
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	case "tsv":
+		for _, row := range rows {
+			fmt.Fprintln(w, strings.Join(row.tsvFields(), "\t"))
+		}
+		return nil
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  BRANCH\tPR\tSTATUS\tAGENT\tPATH\tMESSAGE")
+		for _, row := range rows {
+			marker := " "
+			if row.Current {
+				marker = "*"
+			}
+			badge := stateBadge(status.AgentState{State: row.AgentState, Progress: row.Progress})
+			fmt.Fprintf(tw, "%s %s\t%s\t%s\t%s\t%s\t%s\n", marker, row.Name, orDash(row.pr), orDash(row.status), orDash(badge), row.Path, row.Message)
+		}
+		return tw.Flush()
+	}
+}
+
+// tsvFields returns the row's TSV columns with tabs and newlines in values
+// replaced by spaces.
+func (row brListRow) tsvFields() []string {
+	updated := ""
+	if row.UpdatedAt != nil {
+		updated = row.UpdatedAt.Format(time.RFC3339)
+	}
+	progress := ""
+	if row.Progress != nil {
+		progress = strconv.FormatFloat(*row.Progress, 'f', -1, 64)
+	}
+	fields := []string{row.Name, row.Path, row.Branch, strconv.FormatBool(row.Current), row.PRURL, row.State, updated, row.Message, row.AgentState, progress}
+	for i, field := range fields {
+		fields[i] = strings.NewReplacer("\t", " ", "\n", " ").Replace(field)
+	}
+	return fields
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "--"
+	}
+	return s
+}
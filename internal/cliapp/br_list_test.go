@@ -0,0 +1,168 @@
+package cliapp
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"fitz/internal/runs"
+	"fitz/internal/session"
+	"fitz/internal/status"
+	"fitz/internal/worktree"
+)
+
+func testBrListRows(t *testing.T) []brListRow {
+	t.Helper()
+	orig := isProcessAlive
+	t.Cleanup(func() { isProcessAlive = orig })
+	isProcessAlive = func(pid int) bool { return pid == 42 }
+
+	list := []worktree.WorktreeInfo{
+		{Path: "/repo", Branch: "main", Name: "repo"},
+		{Path: "/wt/feat-auth", Branch: "feat/auth", Name: "feat-auth"},
+		{Path: "/wt/bg", Branch: "bg", Name: "bg"},
+		{Path: "/wt/detached", Name: "detached"},
+	}
+	updated := time.Now().Add(-30 * time.Minute)
+	statuses := map[string]status.BranchStatus{
+		"feat/auth": {Message: "Implementing\tauth", PRURL: "https://github.com/acme/repo/pull/42"},
+	}
+	sessions := map[string]session.SessionInfo{
+		"/wt/feat-auth": {SessionID: "s1", UpdatedAt: updated},
+	}
+	latest := map[string]runs.Run{
+		"bg": {ID: "r1", Branch: "bg", PID: 42},
+	}
+	return brListRows(list, "feat-auth", statuses, sessions, latest)
+}
+
+func TestBrListRows(t *testing.T) {
+	rows := testBrListRows(t)
+	if len(rows) != 4 {
+		t.Fatalf("got %d rows, want 4", len(rows))
+	}
+
+	root, auth, bg, detached := rows[0], rows[1], rows[2], rows[3]
+	if root.Name != "root" || root.Current || root.State != "" {
+		t.Fatalf("root row = %+v", root)
+	}
+	if auth.Name != "feat/auth" || !auth.Current || auth.State != "idle" || auth.status != "30m ago" || auth.UpdatedAt == nil {
+		t.Fatalf("feat/auth row = %+v", auth)
+	}
+	if auth.PRURL != "https://github.com/acme/repo/pull/42" || auth.pr != "PR #42" {
+		t.Fatalf("feat/auth PR = %q / %q", auth.PRURL, auth.pr)
+	}
+	if bg.State != runs.StateRunning {
+		t.Fatalf("bg state = %q, want running", bg.State)
+	}
+	if detached.Name != "detached" || detached.Branch != "" {
+		t.Fatalf("detached row = %+v", detached)
+	}
+}
+
+func TestWriteBrListTable(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeBrList(&out, "table", testBrListRows(t)); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("got %d lines, want header + 4:\n%s", len(lines), out.String())
+	}
+	if !strings.HasPrefix(lines[0], "  BRANCH") {
+		t.Fatalf("header = %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[2], "* feat/auth") || !strings.Contains(lines[2], "PR #42") || !strings.Contains(lines[2], "30m ago") {
+		t.Fatalf("current row = %q", lines[2])
+	}
+	if !strings.Contains(lines[1], "--") {
+		t.Fatalf("root row = %q, want placeholders", lines[1])
+	}
+}
+
+func TestWriteBrListJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeBrList(&out, "json", testBrListRows(t)); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	var decoded []map[string]any
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, out.String())
+	}
+	if len(decoded) != 4 {
+		t.Fatalf("got %d entries, want 4", len(decoded))
+	}
+	auth := decoded[1]
+	if auth["branch"] != "feat/auth" || auth["current"] != true || auth["state"] != "idle" || auth["message"] != "Implementing\tauth" {
+		t.Fatalf("feat/auth entry = %v", auth)
+	}
+	if _, ok := decoded[0]["updated_at"]; !ok {
+		t.Fatal("expected updated_at key even when unknown")
+	}
+}
+
+func TestWriteBrListTSV(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeBrList(&out, "tsv", testBrListRows(t)); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want 4", len(lines))
+	}
+	fields := strings.Split(lines[1], "\t")
+	if len(fields) != 10 {
+		t.Fatalf("got %d fields, want 10: %q", len(fields), lines[1])
+	}
+	if fields[0] != "feat/auth" || fields[3] != "true" || fields[5] != "idle" || fields[7] != "Implementing auth" {
+		t.Fatalf("fields = %q", fields)
+	}
+}
+
+func TestBrListRowsPutsAgentsWaitingOnYouFirst(t *testing.T) {
+	list := []worktree.WorktreeInfo{
+		{Path: "/repo", Branch: "main", Name: "repo"},
+		{Path: "/wt/a", Branch: "a", Name: "a"},
+		{Path: "/wt/b", Branch: "b", Name: "b"},
+		{Path: "/wt/c", Branch: "c", Name: "c"},
+		{Path: "/wt/d", Branch: "d", Name: "d"},
+	}
+	progress := 0.6
+	statuses := map[string]status.BranchStatus{
+		"a": {AgentState: status.AgentState{State: status.StateWorking, Progress: &progress}},
+		"b": {AgentState: status.AgentState{State: status.StateNeedsInput, Detail: "Which database?"}},
+		"d": {AgentState: status.AgentState{State: status.StateBlocked}},
+	}
+	rows := brListRows(list, "", statuses, nil, nil)
+
+	var names []string
+	for _, row := range rows {
+		names = append(names, row.Name)
+	}
+	if got := strings.Join(names, " "); got != "root b d a c" {
+		t.Fatalf("order = %q, want root, the waiting agents, then the rest in git order", got)
+	}
+	if rows[1].AgentState != status.StateNeedsInput || rows[1].Detail != "Which database?" {
+		t.Fatalf("b row = %+v", rows[1])
+	}
+
+	var out bytes.Buffer
+	if err := writeBrList(&out, "table", rows); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	if !strings.Contains(lines[0], "AGENT") || !strings.Contains(lines[4], "working 60%") {
+		t.Fatalf("table = %q", out.String())
+	}
+
+	out.Reset()
+	if err := writeBrList(&out, "tsv", rows); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	fields := strings.Split(strings.Split(out.String(), "\n")[3], "\t")
+	if fields[8] != "working" || fields[9] != "0.6" {
+		t.Fatalf("tsv fields = %q", fields)
+	}
+}
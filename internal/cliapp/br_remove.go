@@ -0,0 +1,133 @@
+package cliapp
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"fitz/internal/worktree"
+)
+
+// brPendingShown caps the changed files and commits listed per worktree in
+// the delete confirmation.
+const brPendingShown = 5
+
+// brRemoval is a queued removal; force removes a worktree with uncommitted
+// changes and stops its agent, like `br rm --force`.
+type brRemoval struct {
+	name  string
+	force bool
+}
+
+// brPendingMsg carries the work that removing names would lose. Worktrees
+// with nothing to lose are absent from pending; errs holds the worktrees
+// that couldn't be checked.
+type brPendingMsg struct {
+	names   []string
+	pending map[string]worktree.Pending
+	errs    map[string]error
+}
+
+// confirmTargets returns the worktrees the delete confirmation is for.
+func (m brModel) confirmTargets() []string {
+	if len(m.confirmNames) > 0 {
+		return m.confirmNames
+	}
+	return []string{m.confirmName}
+}
+
+// openConfirmDelete shows the delete confirmation for the current targets
+// and starts checking them for uncommitted and unpushed work.
+func (m brModel) openConfirmDelete() (brModel, tea.Cmd) {
+	m.state = brStateConfirmDelete
+	m.confirmPending = nil
+	m.confirmErrs = nil
+	m.confirmChecking = false
+	if m.loadPending == nil {
+		return m, nil
+	}
+
+	names := m.confirmTargets()
+	paths := make(map[string]string, len(names))
+	for _, wt := range m.all {
+		if name := worktreeName(wt); slices.Contains(names, name) {
+			paths[name] = wt.Path
+		}
+	}
+	m.confirmChecking = true
+	load := m.loadPending
+	return m, func() tea.Msg {
+		msg := brPendingMsg{names: names, pending: map[string]worktree.Pending{}, errs: map[string]error{}}
+		for name, path := range paths {
+			p, err := load(path, name)
+			if err != nil {
+				msg.errs[name] = err
+			} else if !p.Empty() {
+				msg.pending[name] = p
+			}
+		}
+		return msg
+	}
+}
+
+// applyPending records a finished check, unless the confirmation it was for
+// has been closed since.
+func (m brModel) applyPending(msg brPendingMsg) brModel {
+	if m.state != brStateConfirmDelete || !slices.Equal(msg.names, m.confirmTargets()) {
+		return m
+	}
+	m.confirmChecking = false
+	m.confirmPending = msg.pending
+	m.confirmErrs = msg.errs
+	return m
+}
+
+// cleanTargets returns the confirmation's worktrees that have nothing to
+// lose, so they can be removed without force.
+func (m brModel) cleanTargets() []string {
+	var clean []string
+	for _, name := range m.confirmTargets() {
+		_, pending := m.confirmPending[name]
+		_, failed := m.confirmErrs[name]
+		if !pending && !failed {
+			clean = append(clean, name)
+		}
+	}
+	return clean
+}
+
+// viewPending describes what removing name would lose, or "" when nothing.
+func (m brModel) viewPending(name string) string {
+	var b strings.Builder
+	list := func(label string, lines []string) {
+		for i, l := range lines {
+			if i == brPendingShown {
+				b.WriteString(fmt.Sprintf("      %-12s %s\n", "", dimStyle.Render(fmt.Sprintf("… %d more", len(lines)-i))))
+				break
+			}
+			if i > 0 {
+				label = ""
+			}
+			b.WriteString(fmt.Sprintf("      %-12s %s\n", label, l))
+		}
+	}
+	if err, ok := m.confirmErrs[name]; ok {
+		b.WriteString(errorStyle.Render("      could not check for unsaved work: " + firstLine(err.Error())))
+		b.WriteString("\n")
+	}
+	if p, ok := m.confirmPending[name]; ok {
+		list("uncommitted", p.Changes)
+		list("unpushed", p.Unpushed)
+	}
+	return b.String()
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
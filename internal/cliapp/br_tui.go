@@ -4,27 +4,30 @@ import (
 	"fmt"
 	"math/rand"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 
+	"fitz/internal/config"
+	"fitz/internal/runs"
 	"fitz/internal/session"
 	"fitz/internal/status"
 	"fitz/internal/worktree"
 )
 
-// Reuse styles from todo_tui.go (same package).
-// var selectedStyle, dimStyle, promptStyle already defined in todo_tui.go
-
 const (
 	brStateList = iota
 	brStateConfirmDelete
 	brStateNewBranch
 	brStateNewAction
 	brStateNewPrompt
+	brStateLogs
+	brStateFilter
 )
 
 // BrAction describes the action the user chose in the TUI.
@@ -41,15 +44,17 @@ const (
 // BrResult carries the user's selection out of the TUI.
 type BrResult struct {
 	Action     BrAction
-	Name       string // worktree name for go/publish
-	BranchName string // new branch name for new
-	Prompt     string // kickoff prompt
+	Name       string   // worktree name for go/publish
+	Names      []string // marked worktrees for bulk publish
+	BranchName string   // new branch name for new
+	Prompt     string   // kickoff prompt
 }
 
 type brModel struct {
-	worktrees []worktree.WorktreeInfo
-	current   string // current worktree name
-	cursor    int    // index in worktrees (never 0 - root is not selectable)
+	all       []worktree.WorktreeInfo // every worktree, root first
+	worktrees []worktree.WorktreeInfo // the rows shown: root, then all filtered and sorted
+	current   string                  // current worktree name
+	cursor    int                     // index in worktrees (never 0 - root is not selectable)
 	state     int
 	quitting  bool
 
@@ -57,8 +62,27 @@ type brModel struct {
 	sessions map[string]session.SessionInfo
 	statuses map[string]status.BranchStatus
 
-	// confirm delete state
-	confirmName string
+	// latest background run keyed by branch
+	runs map[string]runs.Run
+
+	// confirm delete state; confirmNames is set for a bulk delete. The
+	// targets are checked for unsaved work before removal is allowed
+	// without force.
+	confirmName     string
+	confirmNames    []string
+	confirmChecking bool
+	confirmPending  map[string]worktree.Pending
+	confirmErrs     map[string]error
+	loadPending     func(path, branch string) (worktree.Pending, error)
+
+	// multi-select and background actions, keyed by worktree name: marked
+	// rows, removes and stops in progress, and the errors of failed ones
+	marked      map[string]bool
+	ops         map[string]brOp
+	failed      map[string]string
+	removeQueue []brRemoval
+	opErrs      []string // failures since the last batch of actions settled
+	notice      string   // banner above the list, cleared by the next key
 
 	// new branch input state
 	branchInput textinput.Model
@@ -69,8 +93,44 @@ type brModel struct {
 	// prompt input state (kickoff mode)
 	promptInput textinput.Model
 
+	// filter, sort and active-only state; see visibleWorktrees
+	filter       string
+	filterInput  textinput.Model
+	sort         brSort
+	onlyActive   bool
+	activeWindow time.Duration
+
+	// PR states keyed by URL ("open", "draft", "merged", "closed"), looked
+	// up the first time the PR sort is chosen; "" while pending or unknown
+	prStates    map[string]string
+	loadPRState func(prURL string) string
+
+	// detail pane state: git details and status history keyed by worktree
+	// path, loaded lazily for the selected row
+	showDetails bool
+	details     map[string]brDetails
+	loadDetails func(path string) (worktree.Details, error)
+	loadHistory func(branch string) ([]status.Event, error)
+
+	// key bindings of the list, and whether its help footer lists them all
+	keys     brKeyMap
+	showHelp bool
+
+	// display name of the configured agent, shown in prompts
+	agent string
+
+	// terminal size, used to size the log viewport
+	width  int
+	height int
+
+	// log viewer state
+	logName string
+	logView viewport.Model
+
 	// dissolve animation state
 	dissolving    int // index of item being dissolved, -1 if none
+	dissolveName  string
+	dissolveForce bool
 	dissolveFrame int
 	dissolveRng   *rand.Rand
 
@@ -78,14 +138,28 @@ type brModel struct {
 	result BrResult
 
 	// callback for removing worktree (allows testing without actual git operations)
-	onRemove func(name string) error
+	onRemove func(name string, force bool) error
+
+	// callback for stopping a worktree's background agent
+	onStop func(name string) error
+
+	// callback for loading a worktree's latest background run log
+	loadLog func(name string) (string, error)
+
+	// refresh reloads worktrees, sessions and statuses; nil disables live
+	// refreshing. changes reports file changes that warrant a refresh.
+	refresh    func() (brSnapshot, error)
+	changes    <-chan struct{}
+	refreshSeq int
 }
 
 func newBrModel(worktrees []worktree.WorktreeInfo, current string, sessions map[string]session.SessionInfo) brModel {
 	bi := textinput.New()
 	bi.Placeholder = "branch-name"
 	pi := textinput.New()
-	pi.Placeholder = "prompt for copilot"
+	fi := textinput.New()
+	fi.Prompt = "/"
+	fi.Placeholder = "filter by branch or message"
 
 	// Start cursor at 1 (first non-root worktree).
 	cursor := 1
@@ -93,21 +167,86 @@ func newBrModel(worktrees []worktree.WorktreeInfo, current string, sessions map[
 		cursor = 0 // no non-root worktrees
 	}
 
-	return brModel{
-		worktrees:   worktrees,
-		current:     current,
-		cursor:      cursor,
-		sessions:    sessions,
-		statuses:    map[string]status.BranchStatus{},
-		branchInput: bi,
-		promptInput: pi,
-		dissolving:  -1,
+	m := brModel{
+		all:          worktrees,
+		worktrees:    worktrees,
+		current:      current,
+		cursor:       cursor,
+		sessions:     sessions,
+		statuses:     map[string]status.BranchStatus{},
+		runs:         map[string]runs.Run{},
+		branchInput:  bi,
+		promptInput:  pi,
+		filterInput:  fi,
+		activeWindow: config.DefaultActiveHours * time.Hour,
+		prStates:     map[string]string{},
+		showDetails:  true,
+		details:      map[string]brDetails{},
+		marked:       map[string]bool{},
+		ops:          map[string]brOp{},
+		failed:       map[string]string{},
+		keys:         newBrKeyMap(config.Config{}),
+		dissolving:   -1,
 	}
+	m.setAgent(agentDisplayName(config.Config{}))
+	return m
+}
+
+// setAgent names the agent that worktrees are opened with.
+func (m *brModel) setAgent(name string) {
+	m.agent = name
+	m.promptInput.Placeholder = "prompt for " + name
 }
 
-func (m brModel) Init() tea.Cmd { return nil }
+func (m brModel) Init() tea.Cmd {
+	if m.refresh == nil {
+		return nil
+	}
+	return tea.Batch(brRefreshTickCmd(), brWatchCmd(m.changes))
+}
 
 func (m brModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updated, cmd := m.update(msg)
+	if next, ok := updated.(brModel); ok {
+		if load := next.detailsCmd(); load != nil {
+			return next, tea.Batch(cmd, load)
+		}
+	}
+	return updated, cmd
+}
+
+func (m brModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.logView.Width, m.logView.Height = m.logViewSize()
+		return m, nil
+	case brRefreshTickMsg:
+		return m, tea.Batch(m.refreshCmd(), brRefreshTickCmd())
+	case brWatchMsg:
+		return m, tea.Batch(m.refreshCmd(), brWatchCmd(m.changes))
+	case brRefreshedMsg:
+		// Skip loads that started before a removal, and don't reshuffle
+		// rows while one is dissolving.
+		if msg.err == nil && msg.seq == m.refreshSeq && m.dissolving < 0 {
+			m = m.applySnapshot(msg.snapshot)
+		}
+		return m, nil
+	case brOpDoneMsg:
+		return m.finishOp(msg)
+	case brPendingMsg:
+		return m.applyPending(msg), nil
+	case brDetailsMsg:
+		m.details[msg.path] = brDetails{details: msg.details, err: msg.err, history: msg.history}
+		return m, nil
+	case brPRStateMsg:
+		m.prStates[msg.url] = msg.state
+		if m.sort == brSortPR && m.dissolving < 0 {
+			m = m.updateView()
+		}
+		return m, nil
+	}
+
 	switch m.state {
 	case brStateList:
 		return m.updateList(msg)
@@ -119,6 +258,10 @@ func (m brModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.updateNewAction(msg)
 	case brStateNewPrompt:
 		return m.updateNewPrompt(msg)
+	case brStateLogs:
+		return m.updateLogs(msg)
+	case brStateFilter:
+		return m.updateFilter(msg)
 	}
 	return m, nil
 }
@@ -130,28 +273,38 @@ func (m brModel) updateList(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.dissolveFrame++
 			if m.dissolveFrame > dissolveFrames {
 				// Animation complete — remove the worktree.
-				name := m.worktrees[m.dissolving].Branch
-				if name == "" {
-					name = m.worktrees[m.dissolving].Name
-				}
+				name := m.dissolveName
 
-				// Call removal callback if provided.
+				// Call removal callback if provided. A failed removal
+				// keeps the row and shows why.
+				var err error
 				if m.onRemove != nil {
-					_ = m.onRemove(name)
-				}
-
-				// Remove from list.
-				m.worktrees = append(m.worktrees[:m.dissolving], m.worktrees[m.dissolving+1:]...)
-
-				// Adjust cursor if needed.
-				if m.cursor >= len(m.worktrees) && m.cursor > 1 {
-					m.cursor--
+					err = m.onRemove(name, m.dissolveForce)
 				}
-				if m.cursor < 1 && len(m.worktrees) > 1 {
-					m.cursor = 1
+				if err != nil {
+					m.failed[name] = err.Error()
+					m = m.failureBanner([]string{name}, true)
+				} else {
+					m.refreshSeq++
+					delete(m.marked, name)
+
+					// Remove from the visible rows and the full list.
+					removed := func(wt worktree.WorktreeInfo) bool { return worktreeName(wt) == name }
+					m.worktrees = slices.DeleteFunc(m.worktrees, removed)
+					m.all = slices.DeleteFunc(m.all, removed)
+
+					// Adjust cursor if needed.
+					if m.cursor >= len(m.worktrees) && m.cursor > 1 {
+						m.cursor--
+					}
+					if m.cursor < 1 && len(m.worktrees) > 1 {
+						m.cursor = 1
+					}
 				}
 
 				m.dissolving = -1
+				m.dissolveName = ""
+				m.dissolveForce = false
 				m.dissolveFrame = 0
 				m.dissolveRng = nil
 				m.state = brStateList
@@ -164,19 +317,64 @@ func (m brModel) updateList(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "q", "esc", "ctrl+c":
+		m.notice = ""
+		switch {
+		case msg.String() == "ctrl+c":
+			m.quitting = true
+			return m, tea.Quit
+		case key.Matches(msg, m.keys.Back) && m.filter != "":
+			m.filter = ""
+			m.filterInput.SetValue("")
+			return m.updateView(), nil
+		case key.Matches(msg, m.keys.Quit):
+			if len(m.ops) > 0 {
+				return m.waitForOps(), nil
+			}
 			m.quitting = true
 			return m, tea.Quit
-		case "up", "k":
+		case key.Matches(msg, m.keys.Help):
+			m.showHelp = !m.showHelp
+		case key.Matches(msg, m.keys.Mark):
+			if m.cursor < 1 || m.cursor >= len(m.worktrees) {
+				return m, nil
+			}
+			name := worktreeName(m.worktrees[m.cursor])
+			if m.marked[name] {
+				delete(m.marked, name)
+			} else {
+				m.marked[name] = true
+			}
+			if m.cursor < len(m.worktrees)-1 {
+				m.cursor++
+			}
+		case key.Matches(msg, m.keys.Stop):
+			return m.startStops(m.targets())
+		case key.Matches(msg, m.keys.Filter):
+			m.filterInput.SetValue(m.filter)
+			m.filterInput.CursorEnd()
+			m.filterInput.Focus()
+			m.state = brStateFilter
+			return m, m.filterInput.Cursor.BlinkCmd()
+		case key.Matches(msg, m.keys.Sort):
+			m.sort = m.sort.next()
+			m = m.updateView()
+			if m.sort == brSortPR {
+				return m, m.loadPRStatesCmd()
+			}
+		case key.Matches(msg, m.keys.Active):
+			m.onlyActive = !m.onlyActive
+			m = m.updateView()
+		case key.Matches(msg, m.keys.Details):
+			m.showDetails = !m.showDetails
+		case key.Matches(msg, m.keys.Up):
 			if m.cursor > 1 {
 				m.cursor--
 			}
-		case "down", "j":
+		case key.Matches(msg, m.keys.Down):
 			if m.cursor < len(m.worktrees)-1 {
 				m.cursor++
 			}
-		case "enter":
+		case key.Matches(msg, m.keys.Go):
 			if len(m.worktrees) <= 1 {
 				return m, nil // no non-root worktrees
 			}
@@ -189,69 +387,259 @@ func (m brModel) updateList(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.result.Name = name
 			m.quitting = true
 			return m, tea.Quit
-		case "d":
+		case key.Matches(msg, m.keys.Delete):
 			if len(m.worktrees) <= 1 {
 				return m, nil // no non-root worktrees
 			}
+			if len(m.marked) > 0 {
+				// Confirm deleting every marked worktree at once.
+				m.confirmNames = m.targets()
+				return m.openConfirmDelete()
+			}
 			// Start delete confirmation.
-			name := m.worktrees[m.cursor].Branch
-			if name == "" {
-				name = m.worktrees[m.cursor].Name
+			name := worktreeName(m.worktrees[m.cursor])
+			if _, busy := m.ops[name]; busy {
+				return m, nil
 			}
 			m.confirmName = name
-			m.state = brStateConfirmDelete
-		case "n":
+			return m.openConfirmDelete()
+		case key.Matches(msg, m.keys.New):
 			// Create new worktree.
 			m.branchInput.SetValue("")
 			m.branchInput.Focus()
 			m.state = brStateNewBranch
 			return m, m.branchInput.Cursor.BlinkCmd()
-		case "p":
+		case key.Matches(msg, m.keys.Publish):
 			if len(m.worktrees) <= 1 {
 				return m, nil // no non-root worktrees
 			}
-			// Publish selected worktree.
+			if len(m.ops) > 0 {
+				return m.waitForOps(), nil
+			}
+			// Publish the marked worktrees, or the selected one.
+			if len(m.marked) > 0 {
+				m.result.Names = m.targets()
+			} else {
+				m.result.Name = worktreeName(m.worktrees[m.cursor])
+			}
+			m.result.Action = BrActionPublish
+			m.quitting = true
+			return m, tea.Quit
+		case key.Matches(msg, m.keys.Refresh):
+			return m, m.refreshCmd()
+		case key.Matches(msg, m.keys.Logs):
+			if len(m.worktrees) <= 1 || m.loadLog == nil {
+				return m, nil
+			}
+			// Open the latest background run log for the selected worktree.
 			name := m.worktrees[m.cursor].Branch
 			if name == "" {
 				name = m.worktrees[m.cursor].Name
 			}
-			m.result.Action = BrActionPublish
-			m.result.Name = name
+			content, err := m.loadLog(name)
+			if err != nil {
+				content = err.Error()
+			}
+			m.logName = name
+			m.logView = viewport.New(m.logViewSize())
+			m.logView.SetContent(content)
+			m.logView.GotoBottom()
+			m.state = brStateLogs
+		}
+	}
+	return m, nil
+}
+
+// refreshCmd reloads the TUI's data in the background, or does nothing
+// when live refreshing is off.
+func (m brModel) refreshCmd() tea.Cmd {
+	if m.refresh == nil {
+		return nil
+	}
+	return brRefreshCmd(m.refresh, m.refreshSeq)
+}
+
+// applySnapshot swaps in freshly loaded data, keeping the cursor on the same
+// worktree when it is still shown.
+func (m brModel) applySnapshot(snap brSnapshot) brModel {
+	m.all = snap.worktrees
+	m.current = snap.current
+	m.sessions = snap.sessions
+	m.statuses = snap.statuses
+	m.runs = snap.runs
+	for path, entry := range m.details {
+		entry.stale = true
+		m.details[path] = entry
+	}
+	for name := range m.marked {
+		if !slices.ContainsFunc(m.all, func(wt worktree.WorktreeInfo) bool { return worktreeName(wt) == name }) {
+			delete(m.marked, name)
+		}
+	}
+	return m.updateView()
+}
+
+// loadPRStatesCmd looks up the state of every PR not seen yet, one gh call
+// per PR, concurrently.
+func (m brModel) loadPRStatesCmd() tea.Cmd {
+	if m.loadPRState == nil {
+		return nil
+	}
+	var cmds []tea.Cmd
+	for _, url := range m.missingPRStates() {
+		m.prStates[url] = "" // pending
+		load := m.loadPRState
+		cmds = append(cmds, func() tea.Msg {
+			return brPRStateMsg{url: url, state: load(url)}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m brModel) updateFilter(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, m.keys.Back):
+			m.filter = ""
+			m.filterInput.SetValue("")
+			m.filterInput.Blur()
+			m.state = brStateList
+			return m.updateView(), nil
+		case key.Matches(msg, m.keys.Select):
+			m.filterInput.Blur()
+			m.state = brStateList
+			return m, nil
+		}
+		// Letters are typed into the filter, so only the arrows move.
+		switch msg.String() {
+		case "ctrl+c":
 			m.quitting = true
 			return m, tea.Quit
+		case "up":
+			// Move through the matches without leaving the filter.
+			if m.cursor > 1 {
+				m.cursor--
+			}
+			return m, nil
+		case "down":
+			if m.cursor < len(m.worktrees)-1 {
+				m.cursor++
+			}
+			return m, nil
 		}
 	}
-	return m, nil
+	var cmd tea.Cmd
+	m.filterInput, cmd = m.filterInput.Update(msg)
+	if m.filterInput.Value() != m.filter {
+		m.filter = m.filterInput.Value()
+		m = m.updateView()
+	}
+	return m, cmd
+}
+
+func (m brModel) updateLogs(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case msg.String() == "ctrl+c":
+			m.quitting = true
+			return m, tea.Quit
+		case key.Matches(msg, m.keys.Back, m.keys.Logs, m.keys.Quit):
+			m.state = brStateList
+			return m, nil
+		}
+	}
+	var cmd tea.Cmd
+	m.logView, cmd = m.logView.Update(msg)
+	return m, cmd
+}
+
+// logViewSize returns the viewport size for the log viewer, leaving room
+// for its title and key hints.
+func (m brModel) logViewSize() (width, height int) {
+	width, height = m.width, m.height
+	if width <= 0 {
+		width = 80
+	}
+	if height <= 0 {
+		height = 24
+	}
+	height -= 4
+	if height < 1 {
+		height = 1
+	}
+	return width, height
 }
 
 func (m brModel) updateConfirmDelete(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "esc", "n":
+		switch {
+		case key.Matches(msg, m.keys.No):
+			m.confirmNames = nil
 			m.state = brStateList
 			return m, nil
-		case "y":
-			// Start dissolve animation.
-			m.dissolving = m.cursor
-			m.dissolveFrame = 1
-			m.dissolveRng = rand.New(rand.NewSource(int64(len(m.confirmName))))
-			m.state = brStateList
-			return m, dissolveTickCmd()
+		case key.Matches(msg, m.keys.Yes):
+			// Without force, only worktrees with nothing to lose are
+			// removed.
+			if m.confirmChecking {
+				return m, nil
+			}
+			names := m.cleanTargets()
+			if len(names) == 0 {
+				return m, nil
+			}
+			return m.confirmRemove(names, false)
+		case key.Matches(msg, m.keys.Force):
+			if m.confirmChecking {
+				return m, nil
+			}
+			return m.confirmRemove(m.confirmTargets(), true)
 		}
 	}
 	return m, nil
 }
 
+// confirmRemove leaves the confirmation and removes names: with the
+// dissolve animation for a single worktree, or in the background alongside
+// other removals.
+func (m brModel) confirmRemove(names []string, force bool) (tea.Model, tea.Cmd) {
+	bulk := len(m.confirmNames) > 0
+	m.confirmNames = nil
+	m.state = brStateList
+
+	// A refresh during the confirmation may have moved the row, or hidden
+	// it, so find it by name rather than by the cursor.
+	row := -1
+	if !bulk {
+		row = slices.IndexFunc(m.worktrees, func(wt worktree.WorktreeInfo) bool {
+			return worktreeName(wt) == m.confirmName
+		})
+	}
+	if row < 1 || len(m.ops) > 0 {
+		// Don't reshuffle rows under the animation while other removals
+		// finish; queue this one with them instead.
+		return m.startRemovals(names, force)
+	}
+
+	// Start dissolve animation.
+	m.cursor = row
+	m.dissolving = row
+	m.dissolveName = m.confirmName
+	m.dissolveForce = force
+	m.dissolveFrame = 1
+	m.dissolveRng = rand.New(rand.NewSource(int64(len(m.confirmName))))
+	return m, dissolveTickCmd()
+}
+
 func (m brModel) updateNewBranch(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "esc":
+		switch {
+		case key.Matches(msg, m.keys.Back):
 			m.state = brStateList
 			m.branchInput.Blur()
 			return m, nil
-		case "enter":
+		case key.Matches(msg, m.keys.Select):
 			name := strings.TrimSpace(m.branchInput.Value())
 			if name == "" {
 				return m, nil
@@ -271,20 +659,20 @@ func (m brModel) updateNewBranch(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m brModel) updateNewAction(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "esc":
+		switch {
+		case key.Matches(msg, m.keys.Back):
 			m.branchInput.Focus()
 			m.state = brStateNewBranch
 			return m, m.branchInput.Cursor.BlinkCmd()
-		case "up", "k":
+		case key.Matches(msg, m.keys.Up):
 			if m.actionCursor > 0 {
 				m.actionCursor--
 			}
-		case "down", "j":
+		case key.Matches(msg, m.keys.Down):
 			if m.actionCursor < 1 {
 				m.actionCursor++
 			}
-		case "enter":
+		case key.Matches(msg, m.keys.Select):
 			if m.actionCursor == 0 {
 				// "Create and go"
 				m.result.Action = BrActionNew
@@ -296,7 +684,7 @@ func (m brModel) updateNewAction(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.promptInput.Focus()
 			m.state = brStateNewPrompt
 			return m, m.promptInput.Cursor.BlinkCmd()
-		case "q", "ctrl+c":
+		case msg.String() == "ctrl+c", key.Matches(msg, m.keys.Quit):
 			m.quitting = true
 			return m, tea.Quit
 		}
@@ -307,12 +695,12 @@ func (m brModel) updateNewAction(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m brModel) updateNewPrompt(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "esc":
+		switch {
+		case key.Matches(msg, m.keys.Back):
 			m.state = brStateNewAction
 			m.promptInput.Blur()
 			return m, nil
-		case "enter":
+		case key.Matches(msg, m.keys.Select):
 			prompt := strings.TrimSpace(m.promptInput.Value())
 			if prompt == "" {
 				return m, nil
@@ -343,7 +731,9 @@ func (m brModel) View() string {
 		return m.viewNewAction()
 	case brStateNewPrompt:
 		return m.viewNewPrompt()
-	default:
+	case brStateLogs:
+		return m.viewLogs()
+	default: // brStateList, brStateFilter
 		return m.viewList()
 	}
 }
@@ -351,14 +741,40 @@ func (m brModel) View() string {
 func (m brModel) viewList() string {
 	var b strings.Builder
 
-	if len(m.worktrees) <= 1 {
+	if len(m.all) <= 1 {
 		b.WriteString("No worktrees.\n\n")
-		b.WriteString(dimStyle.Render("(n new worktree, q quit)"))
+		b.WriteString(dimStyle.Render(fmt.Sprintf("(%s new worktree, %s quit)", keyLabel(m.keys.New), keyLabel(m.keys.Quit))))
 		b.WriteString("\n")
 		return b.String()
 	}
 
-	b.WriteString("Worktrees (↑/↓ navigate, enter go, d remove, n new, p publish, q quit)\n\n")
+	if m.state == brStateFilter {
+		b.WriteString("Worktrees " + keyHints("type to filter", "↑/↓ navigate", keyHint(m.keys.Select, "keep filter"), keyHint(m.keys.Back, "clear")) + "\n")
+		b.WriteString(m.filterInput.View())
+		b.WriteString("\n")
+	} else {
+		b.WriteString("Worktrees\n")
+	}
+	if hint := m.filterHint(); hint != "" {
+		b.WriteString(dimStyle.Render(hint))
+		b.WriteString("\n")
+	}
+	if n := len(m.marked); n > 0 {
+		b.WriteString(dimStyle.Render(fmt.Sprintf("%d marked (%s remove, %s publish, %s stop)", n, keyLabel(m.keys.Delete), keyLabel(m.keys.Publish), keyLabel(m.keys.Stop))))
+		b.WriteString("\n")
+	}
+	if m.notice != "" {
+		b.WriteString(errorStyle.Render(m.notice))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	if len(m.worktrees) <= 1 {
+		b.WriteString("No worktrees match.\n\n")
+		b.WriteString(helpView(m.keys, m.width, m.showHelp))
+		b.WriteString("\n")
+		return b.String()
+	}
 
 	// Compute max branch name width for column alignment.
 	maxNameLen := 0
@@ -377,8 +793,13 @@ func (m brModel) viewList() string {
 		maxNameLen = 10
 	}
 
-	const prCol = 10
-	const statusCol = 10
+	prCol := 10
+	for _, wt := range m.worktrees[1:] {
+		if n := len(m.prLabel(wt)); n > prCol {
+			prCol = n
+		}
+	}
+	const statusCol = 16
 
 	// Column header
 	header := fmt.Sprintf("     %-*s  %-*s  %-*s  %s", maxNameLen, "BRANCH", prCol, "PR", statusCol, "STATUS", "MESSAGE")
@@ -395,23 +816,27 @@ func (m brModel) viewList() string {
 
 		isCurrent := (i == 0 && m.current == "root") || (i > 0 && m.current == wt.Name)
 
-		prefix := "     "
+		pointer, mark := " ", " "
 		style := dimStyle
 
 		if i == 0 {
 			if isCurrent {
-				prefix = "  *  "
+				pointer = "*"
 			}
 			style = dimStyle
 		} else {
 			if i == m.cursor {
-				prefix = "  ▸  "
+				pointer = "▸"
 				style = selectedStyle
 			} else if isCurrent {
-				prefix = "  *  "
-				style = lipgloss.NewStyle().Foreground(lipgloss.Color("33")) // cyan
+				pointer = "*"
+				style = currentStyle
+			}
+			if m.marked[name] {
+				mark = "✓"
 			}
 		}
+		prefix := "  " + pointer + mark + " "
 
 		displayName := name
 		if i == m.dissolving && m.dissolveRng != nil {
@@ -422,8 +847,13 @@ func (m brModel) viewList() string {
 		b.WriteString(style.Render(fmt.Sprintf("%s%-*s", prefix, maxNameLen, displayName)))
 
 		if i > 0 {
-			pr, statusText, message := m.badgeParts(wt)
-			if pr != "" || statusText != "" || message != "" {
+			_, statusText, message := m.badgeParts(wt)
+			opStatus, opMessage, hasOp := m.opStatus(name)
+			if hasOp {
+				statusText, message = opStatus, opMessage
+			}
+			pr := m.prLabel(wt)
+			if pr != "" || statusText != "" || message != "" || m.statuses[worktreeName(wt)].State != "" {
 				branch := wt.Branch
 				if branch == "" {
 					branch = wt.Name
@@ -440,21 +870,68 @@ func (m brModel) viewList() string {
 					prPadded = fmt.Sprintf("\x1b]8;;%s\x1b\\%-*s\x1b]8;;\x1b\\", st.PRURL, prCol, prDisplay)
 				}
 
-				meta := fmt.Sprintf("  %s  %-*s  %s", prPadded, statusCol, statusText, message)
-				b.WriteString(dimStyle.Render(meta))
+				meta := fmt.Sprintf("  %s  %-*s  ", prPadded, statusCol, statusText)
+				if _, busy := m.ops[name]; hasOp && !busy {
+					b.WriteString(errorStyle.Render(meta + message))
+				} else {
+					b.WriteString(dimStyle.Render(meta))
+					if badge := stateBadge(st.AgentState); badge != "" && !hasOp {
+						b.WriteString(stateStyle(st.State).Render("[" + badge + "]"))
+						b.WriteString(" ")
+					}
+					b.WriteString(dimStyle.Render(message))
+				}
 			}
 		}
 		b.WriteString("\n")
 	}
 
+	if m.showDetails && m.dissolving < 0 {
+		b.WriteString("\n")
+		b.WriteString(m.viewDetails())
+	}
+
+	b.WriteString("\n")
+	b.WriteString(helpView(m.keys, m.width, m.showHelp))
+	b.WriteString("\n")
 	return b.String()
 }
 
 func (m brModel) viewConfirmDelete() string {
 	var b strings.Builder
-	b.WriteString(promptStyle.Render(fmt.Sprintf("Remove worktree %q and its branch?", m.confirmName)))
-	b.WriteString("\n\n")
-	b.WriteString(dimStyle.Render("(y confirm, n/esc cancel)"))
+	if len(m.confirmNames) > 0 {
+		b.WriteString(promptStyle.Render(fmt.Sprintf("Remove %d worktrees and their branches?", len(m.confirmNames))))
+		b.WriteString("\n\n")
+		for _, name := range m.confirmNames {
+			b.WriteString("  " + name + "\n")
+			b.WriteString(m.viewPending(name))
+		}
+		b.WriteString("\n")
+	} else {
+		b.WriteString(promptStyle.Render(fmt.Sprintf("Remove worktree %q and its branch?", m.confirmName)))
+		b.WriteString("\n\n")
+		if pending := m.viewPending(m.confirmName); pending != "" {
+			b.WriteString(pending)
+			b.WriteString("\n")
+		}
+	}
+
+	targets, clean := m.confirmTargets(), m.cleanTargets()
+	cancel := keyHint(m.keys.No, "cancel")
+	switch {
+	case m.confirmChecking:
+		b.WriteString(dimStyle.Render("Checking for uncommitted changes and unpushed commits… " + keyHints(cancel)))
+	case len(clean) == len(targets):
+		b.WriteString(dimStyle.Render(keyHints(keyHint(m.keys.Yes, "confirm"), cancel)))
+	case len(clean) == 0:
+		b.WriteString(errorStyle.Render("Removing will lose the work listed above."))
+		b.WriteString("\n")
+		b.WriteString(dimStyle.Render(keyHints(keyHint(m.keys.Force, "force remove"), cancel)))
+	default:
+		b.WriteString(errorStyle.Render("Removing will lose the work listed above."))
+		b.WriteString("\n")
+		b.WriteString(dimStyle.Render(keyHints(keyHint(m.keys.Yes, fmt.Sprintf("remove the %d without unsaved work", len(clean))), keyHint(m.keys.Force, "force remove all"), cancel)))
+	}
 	b.WriteString("\n")
 	return b.String()
 }
@@ -465,7 +942,7 @@ func (m brModel) viewNewBranch() string {
 	b.WriteString("\n\n")
 	b.WriteString(fmt.Sprintf("Branch name: %s", m.branchInput.View()))
 	b.WriteString("\n\n")
-	b.WriteString(dimStyle.Render("(enter confirm, esc back)"))
+	b.WriteString(dimStyle.Render(keyHints(keyHint(m.keys.Select, "confirm"), keyHint(m.keys.Back, "back"))))
 	b.WriteString("\n")
 	return b.String()
 }
@@ -479,8 +956,8 @@ func (m brModel) viewNewAction() string {
 		label string
 		desc  string
 	}{
-		{"Create and go", "(open Copilot interactively)"},
-		{"Create and kickoff", "(run Copilot in background)"},
+		{"Create and go", fmt.Sprintf("(open %s interactively)", m.agent)},
+		{"Create and kickoff", fmt.Sprintf("(run %s in background)", m.agent)},
 	}
 	for i, opt := range options {
 		cursor := "  "
@@ -493,7 +970,7 @@ func (m brModel) viewNewAction() string {
 		b.WriteString("\n")
 	}
 	b.WriteString("\n")
-	b.WriteString(dimStyle.Render("(enter select, esc back)"))
+	b.WriteString(dimStyle.Render(keyHints(keyHint(m.keys.Select, "select"), keyHint(m.keys.Back, "back"))))
 	b.WriteString("\n")
 	return b.String()
 }
@@ -504,45 +981,116 @@ func (m brModel) viewNewPrompt() string {
 	b.WriteString("\n\n")
 	b.WriteString(fmt.Sprintf("Prompt: %s", m.promptInput.View()))
 	b.WriteString("\n\n")
-	b.WriteString(dimStyle.Render("(enter confirm, esc back)"))
+	b.WriteString(dimStyle.Render(keyHints(keyHint(m.keys.Select, "confirm"), keyHint(m.keys.Back, "back"))))
+	b.WriteString("\n")
+	return b.String()
+}
+
+func (m brModel) viewLogs() string {
+	var b strings.Builder
+	b.WriteString(promptStyle.Render(fmt.Sprintf("Logs for %q", m.logName)))
+	b.WriteString("\n\n")
+	b.WriteString(m.logView.View())
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render(fmt.Sprintf("%s %3.f%%", keyHints("↑/↓ scroll", "pgup/pgdn page", keyHint(m.keys.Back, "back")), m.logView.ScrollPercent()*100)))
 	b.WriteString("\n")
 	return b.String()
 }
 
+// prLabel returns the PR column for wt, with the PR's state once it has
+// been looked up for the PR sort.
+func (m brModel) prLabel(wt worktree.WorktreeInfo) string {
+	url := m.statuses[worktreeName(wt)].PRURL
+	if url == "" {
+		return ""
+	}
+	if state := m.prStates[url]; state != "" {
+		return formatPRLabel(url) + " " + state
+	}
+	return formatPRLabel(url)
+}
+
 // badgeParts returns the individual metadata fields for a worktree row.
 func (m brModel) badgeParts(wt worktree.WorktreeInfo) (pr, statusStr, message string) {
+	d := describeWorktree(wt, m.statuses, m.sessions, m.runs)
+	return d.PR, d.Status, d.Message
+}
+
+// worktreeDetails is the metadata shown for a worktree in `br list`.
+type worktreeDetails struct {
+	PR        string // short label, e.g. "PR #42"
+	PRURL     string
+	Status    string    // for display, e.g. "working" or "12m ago"
+	State     string    // for scripts: working, idle, session or a run state
+	UpdatedAt time.Time // last session activity, zero if unknown
+	Message   string
+	Agent     status.AgentState // as reported with `agent status --state`
+}
+
+// describeWorktree works out a worktree's PR, agent state and message from
+// the status store, agent sessions and background runs.
+func describeWorktree(wt worktree.WorktreeInfo, statuses map[string]status.BranchStatus, sessions map[string]session.SessionInfo, latestRuns map[string]runs.Run) worktreeDetails {
+	var d worktreeDetails
 	branch := wt.Branch
 	if branch == "" {
 		branch = wt.Name
 	}
 
-	st := m.statuses[branch]
+	st := statuses[branch]
 	if st.PRURL != "" {
-		pr = formatPRLabel(st.PRURL)
+		d.PR = formatPRLabel(st.PRURL)
+		d.PRURL = st.PRURL
 	}
+	d.Agent = st.AgentState
 
-	info, hasSession := m.sessions[wt.Path]
+	info, hasSession := sessions[wt.Path]
 	age := time.Duration(0)
 	if hasSession && info.SessionID != "" {
 		if info.UpdatedAt.IsZero() {
-			statusStr = "session"
+			d.Status, d.State = "session", "session"
 		} else {
+			d.UpdatedAt = info.UpdatedAt
 			age = time.Since(info.UpdatedAt)
 			if age < 2*time.Minute {
-				statusStr = "working"
+				d.Status, d.State = "working", "working"
 			} else {
-				statusStr = formatAge(age) + " ago"
+				d.Status, d.State = formatAge(age)+" ago", "idle"
 			}
 		}
 	}
 
+	// A recorded background run knows its real state; prefer it over
+	// guessing from session activity.
+	run, hasRun := latestRuns[branch]
+	if hasRun {
+		alive := isProcessAlive(run.PID)
+		d.Status, d.State = run.Label(alive), run.State(alive)
+	}
+
 	if st.Message != "" {
-		message = st.Message
+		d.Message = st.Message
 	} else if hasSession && info.SessionID != "" && info.Summary != "" && !info.UpdatedAt.IsZero() && age >= 2*time.Minute {
-		message = info.Summary
+		d.Message = info.Summary
+	} else if hasRun && run.Group != "" {
+		d.Message = "fanout " + run.Group
+		if run.Model != "" {
+			d.Message += " (" + run.Model + ")"
+		}
 	}
 
-	return
+	return d
+}
+
+// stateBadge is the short form of an agent's reported state, e.g. "blocked"
+// or "working 60%", or "" when it hasn't reported one.
+func stateBadge(s status.AgentState) string {
+	if s.State == "" {
+		return ""
+	}
+	if s.Progress != nil {
+		return fmt.Sprintf("%s %.0f%%", s.State, *s.Progress*100)
+	}
+	return s.State
 }
 
 func formatAge(d time.Duration) string {
@@ -1,12 +1,17 @@
 package cliapp
 
 import (
+	"errors"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 
+	"fitz/internal/config"
+	"fitz/internal/runs"
 	"fitz/internal/session"
 	"fitz/internal/status"
 	"fitz/internal/worktree"
@@ -135,6 +140,55 @@ func TestBrDeleteConfirmationYes(t *testing.T) {
 	}
 }
 
+func TestBrDeleteConfirmSurvivesRefresh(t *testing.T) {
+	worktrees := []worktree.WorktreeInfo{
+		{Path: "/repo", Name: "repo"},
+		{Path: "/wt/feature-1", Branch: "feature-1", Name: "feature-1"},
+		{Path: "/wt/feature-2", Branch: "feature-2", Name: "feature-2"},
+	}
+	var removed []string
+	m := newBrModel(worktrees, "root", nil)
+	m.onRemove = func(name string, force bool) error {
+		removed = append(removed, name)
+		return nil
+	}
+	m.cursor = 2
+
+	// A refresh while confirming moves the row down.
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	moved := append([]worktree.WorktreeInfo{worktrees[0], {Path: "/wt/feature-0", Branch: "feature-0", Name: "feature-0"}}, worktrees[1:]...)
+	updated, _ = updated.Update(brRefreshedMsg{snapshot: brSnapshot{worktrees: moved}})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	model := updated.(brModel)
+	if model.dissolving != 3 || model.dissolveName != "feature-2" {
+		t.Fatalf("dissolving = %d (%q), want row 3 (feature-2)", model.dissolving, model.dissolveName)
+	}
+	for i := 0; i <= dissolveFrames; i++ {
+		updated, _ = updated.Update(dissolveTickMsg{})
+	}
+	if len(removed) != 1 || removed[0] != "feature-2" {
+		t.Fatalf("removed %v, want [feature-2]", removed)
+	}
+
+	// A refresh while confirming drops the row and the cursor moves to
+	// another worktree: y still removes the confirmed one.
+	removed = nil
+	m.cursor = 2
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	updated, _ = updated.Update(brRefreshedMsg{snapshot: brSnapshot{worktrees: worktrees[:2]}})
+	if got := updated.(brModel).cursor; got != 1 {
+		t.Fatalf("cursor = %d, want 1 after feature-2 disappeared", got)
+	}
+	updated, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	if updated.(brModel).dissolving >= 0 {
+		t.Fatal("dissolving a row that isn't the confirmed worktree")
+	}
+	drainCmds(updated, cmd)
+	if len(removed) != 1 || removed[0] != "feature-2" {
+		t.Fatalf("removed %v, want [feature-2]", removed)
+	}
+}
+
 func TestBrNewFlowBranchInput(t *testing.T) {
 	worktrees := []worktree.WorktreeInfo{
 		{Path: "/repo", Branch: "", Name: "repo"},
@@ -171,6 +225,17 @@ func TestBrNewFlowActionChoice(t *testing.T) {
 	if model.result.BranchName != "my-feature" {
 		t.Fatalf("branchName = %q, want 'my-feature'", model.result.BranchName)
 	}
+	if view := model.View(); !strings.Contains(view, "(open Copilot interactively)") {
+		t.Fatalf("action choice should name the default agent:\n%s", view)
+	}
+
+	model.setAgent("Claude")
+	if view := model.View(); !strings.Contains(view, "(run Claude in background)") {
+		t.Fatalf("action choice should name the configured agent:\n%s", view)
+	}
+	if model.promptInput.Placeholder != "prompt for Claude" {
+		t.Fatalf("prompt placeholder = %q", model.promptInput.Placeholder)
+	}
 }
 
 func TestBrNewFlowGoAction(t *testing.T) {
@@ -321,7 +386,7 @@ func TestBrDissolveAnimationCompletesRemoval(t *testing.T) {
 		{Path: "/repo/.fitz/owner/repo/feature-2", Branch: "feature-2", Name: "feature-2"},
 	}
 	m := newBrModel(worktrees, "root", nil)
-	m.onRemove = func(name string) error { return nil } // Mock removal
+	m.onRemove = func(name string, _ bool) error { return nil } // Mock removal
 
 	// Start delete → confirm.
 	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
@@ -441,6 +506,50 @@ func TestViewListWorkingBadge(t *testing.T) {
 	}
 }
 
+func TestViewListRunStateBadge(t *testing.T) {
+	original := isProcessAlive
+	t.Cleanup(func() { isProcessAlive = original })
+	isProcessAlive = func(pid int) bool { return pid == 100 }
+
+	worktrees := []worktree.WorktreeInfo{
+		{Path: "/repo", Branch: "", Name: "repo"},
+		{Path: "/repo/.fitz/feature-a", Branch: "feature-a", Name: "feature-a"},
+		{Path: "/repo/.fitz/feature-b", Branch: "feature-b", Name: "feature-b"},
+		{Path: "/repo/.fitz/feature-c", Branch: "feature-c", Name: "feature-c"},
+	}
+	zero, one := 0, 1
+	m := newBrModel(worktrees, "root", nil)
+	m.runs = map[string]runs.Run{
+		"feature-a": {PID: 100},
+		"feature-b": {PID: 200, ExitCode: &one},
+		"feature-c": {PID: 300, ExitCode: &zero},
+	}
+
+	view := m.View()
+
+	for _, want := range []string{"running", "failed (exit 1)", "finished"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q badge, got:\n%s", want, view)
+		}
+	}
+}
+
+func TestViewListShowsFanoutModel(t *testing.T) {
+	worktrees := []worktree.WorktreeInfo{
+		{Path: "/repo", Branch: "", Name: "repo"},
+		{Path: "/repo/.fitz/feat-1", Branch: "feat-1", Name: "feat-1"},
+	}
+	zero := 0
+	m := newBrModel(worktrees, "root", nil)
+	m.runs = map[string]runs.Run{"feat-1": {Group: "feat", Model: "gpt-5", ExitCode: &zero}}
+
+	view := m.View()
+
+	if !strings.Contains(view, "fanout feat (gpt-5)") {
+		t.Errorf("expected fanout group and model, got:\n%s", view)
+	}
+}
+
 func TestViewListShowsColumnHeader(t *testing.T) {
 	worktrees := []worktree.WorktreeInfo{
 		{Path: "/repo", Branch: "", Name: "repo"},
@@ -487,3 +596,874 @@ func TestViewListShowsClickablePRLink(t *testing.T) {
 		t.Errorf("expected OSC 8 hyperlink in view, got:\n%s", view)
 	}
 }
+
+func TestBrLogsKeyOpensViewer(t *testing.T) {
+	worktrees := []worktree.WorktreeInfo{
+		{Path: "/repo", Branch: "", Name: "repo"},
+		{Path: "/repo/.fitz/feature-a", Branch: "feature-a", Name: "feature-a"},
+	}
+	m := newBrModel(worktrees, "root", nil)
+	var loaded string
+	m.loadLog = func(name string) (string, error) {
+		loaded = name
+		return "agent says hello", nil
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'l'}})
+	model := updated.(brModel)
+
+	if loaded != "feature-a" {
+		t.Fatalf("loaded log for %q, want feature-a", loaded)
+	}
+	if model.state != brStateLogs {
+		t.Fatalf("state = %d, want brStateLogs", model.state)
+	}
+	if view := model.View(); !strings.Contains(view, "agent says hello") || !strings.Contains(view, "feature-a") {
+		t.Fatalf("log view missing content, got:\n%s", view)
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	model = updated.(brModel)
+	if model.state != brStateList {
+		t.Fatalf("state after esc = %d, want brStateList", model.state)
+	}
+}
+
+func TestBrRefreshKeepsCursorOnWorktree(t *testing.T) {
+	worktrees := []worktree.WorktreeInfo{
+		{Path: "/repo", Name: "repo"},
+		{Path: "/wt/feature-1", Branch: "feature-1", Name: "feature-1"},
+		{Path: "/wt/feature-2", Branch: "feature-2", Name: "feature-2"},
+	}
+	m := newBrModel(worktrees, "root", nil)
+	m.cursor = 2 // feature-2
+
+	refreshed := brSnapshot{
+		worktrees: []worktree.WorktreeInfo{
+			{Path: "/repo", Name: "repo"},
+			{Path: "/wt/feature-0", Branch: "feature-0", Name: "feature-0"},
+			{Path: "/wt/feature-1", Branch: "feature-1", Name: "feature-1"},
+			{Path: "/wt/feature-2", Branch: "feature-2", Name: "feature-2"},
+		},
+		current:  "feature-1",
+		statuses: map[string]status.BranchStatus{"feature-2": {Message: "Writing tests"}},
+	}
+	updated, _ := m.Update(brRefreshedMsg{snapshot: refreshed})
+	model := updated.(brModel)
+	if model.cursor != 3 {
+		t.Fatalf("cursor = %d, want 3 (still on feature-2)", model.cursor)
+	}
+	if model.current != "feature-1" || !strings.Contains(model.View(), "Writing tests") {
+		t.Fatalf("refreshed data not applied:\n%s", model.View())
+	}
+
+	// When the selected worktree is gone, the cursor stays in range.
+	refreshed.worktrees = refreshed.worktrees[:2]
+	updated, _ = model.Update(brRefreshedMsg{snapshot: refreshed})
+	if model = updated.(brModel); model.cursor != 1 {
+		t.Fatalf("cursor = %d, want 1 after feature-2 disappeared", model.cursor)
+	}
+}
+
+func TestBrRefreshKeyAndTickReload(t *testing.T) {
+	worktrees := []worktree.WorktreeInfo{
+		{Path: "/repo", Name: "repo"},
+		{Path: "/wt/feature-1", Branch: "feature-1", Name: "feature-1"},
+	}
+	m := newBrModel(worktrees, "root", nil)
+	if m.Init() != nil {
+		t.Fatal("expected no refresh without a loader")
+	}
+
+	loads := 0
+	m.refresh = func() (brSnapshot, error) {
+		loads++
+		return brSnapshot{worktrees: worktrees, current: "feature-1"}, nil
+	}
+	if m.Init() == nil {
+		t.Fatal("expected Init to schedule a refresh")
+	}
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	if cmd == nil {
+		t.Fatal("expected r to start a refresh")
+	}
+	msg, ok := cmd().(brRefreshedMsg)
+	if !ok || loads != 1 || msg.snapshot.current != "feature-1" {
+		t.Fatalf("refresh msg = %#v, loads = %d", msg, loads)
+	}
+
+	if _, cmd := m.Update(brRefreshTickMsg{}); cmd == nil {
+		t.Fatal("expected tick to refresh and schedule the next tick")
+	}
+}
+
+func TestBrRefreshSkipsLoadsThatRacedARemoval(t *testing.T) {
+	worktrees := []worktree.WorktreeInfo{
+		{Path: "/repo", Name: "repo"},
+		{Path: "/wt/feature-1", Branch: "feature-1", Name: "feature-1"},
+	}
+	m := newBrModel(worktrees[:1], "root", nil)
+	m.refreshSeq = 1
+
+	updated, _ := m.Update(brRefreshedMsg{seq: 0, snapshot: brSnapshot{worktrees: worktrees}})
+	if got := len(updated.(brModel).worktrees); got != 1 {
+		t.Fatalf("stale refresh applied: %d worktrees", got)
+	}
+}
+
+func TestWatchDirsReportsChanges(t *testing.T) {
+	dir := t.TempDir()
+	changes, stop := watchDirs([]string{dir, "/does/not/exist"})
+	defer stop()
+	if changes == nil {
+		t.Skip("file watching unavailable")
+	}
+
+	if err := status.Save(dir+"/status.json", map[string]status.BranchStatus{"feat": {Message: "hi"}}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change reported")
+	}
+}
+
+func typeKeys(t *testing.T, m tea.Model, keys string) tea.Model {
+	t.Helper()
+	for _, r := range keys {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return m
+}
+
+func filterTestModel() brModel {
+	m := newBrModel([]worktree.WorktreeInfo{
+		{Path: "/repo", Name: "repo"},
+		{Path: "/wt/feat-auth", Branch: "feat/auth", Name: "feat-auth"},
+		{Path: "/wt/fix-login", Branch: "fix-login", Name: "fix-login"},
+		{Path: "/wt/docs", Branch: "docs", Name: "docs"},
+	}, "root", nil)
+	m.statuses = map[string]status.BranchStatus{
+		"docs": {Message: "Rewrite auth guide"},
+	}
+	return m
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		pattern, text string
+		want          bool
+	}{
+		{"", "anything", true},
+		{"fa", "feat/auth", true},
+		{"ATH fx", "feat/auth: fix redirect", true},
+		{"xf", "fix", false},
+		{"auth zzz", "feat/auth", false},
+	}
+	for _, tt := range tests {
+		if got := fuzzyMatch(tt.pattern, tt.text); got != tt.want {
+			t.Errorf("fuzzyMatch(%q, %q) = %v, want %v", tt.pattern, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestBrFilterNarrowsRowsAndKeepsCursor(t *testing.T) {
+	m := filterTestModel()
+	m.cursor = 3 // docs
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	updated = typeKeys(t, updated, "auth")
+	model := updated.(brModel)
+	if model.state != brStateFilter {
+		t.Fatalf("state = %d, want filter", model.state)
+	}
+	// feat/auth matches on its branch, docs on its status message.
+	if len(model.worktrees) != 3 || model.worktrees[1].Branch != "feat/auth" || model.worktrees[2].Branch != "docs" {
+		t.Fatalf("filtered rows = %+v", model.worktrees)
+	}
+	if model.cursor != 2 {
+		t.Fatalf("cursor = %d, want 2 (still on docs)", model.cursor)
+	}
+	if !strings.Contains(model.View(), "2 of 3 worktrees") {
+		t.Fatalf("view missing match count:\n%s", model.View())
+	}
+
+	// enter keeps the filter, and keys act on the filtered rows again.
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := updated.(brModel).result.Name; got != "docs" {
+		t.Fatalf("go target = %q, want docs", got)
+	}
+
+	// esc clears the filter.
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	model = updated.(brModel)
+	if model.state != brStateList || len(model.worktrees) != 4 || model.worktrees[model.cursor].Branch != "docs" {
+		t.Fatalf("after esc: state = %d, rows = %d, cursor = %d", model.state, len(model.worktrees), model.cursor)
+	}
+}
+
+func TestBrFilterWithNoMatches(t *testing.T) {
+	m := filterTestModel()
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	updated = typeKeys(t, updated, "zzz")
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model := updated.(brModel)
+	if model.cursor != 0 || !strings.Contains(model.View(), "No worktrees match") {
+		t.Fatalf("cursor = %d, view:\n%s", model.cursor, model.View())
+	}
+	// Keys that act on a worktree do nothing.
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	if updated.(brModel).state != brStateList {
+		t.Fatal("d should do nothing without a visible worktree")
+	}
+}
+
+func TestBrDissolveUnderFilter(t *testing.T) {
+	m := filterTestModel()
+	m.filter = "f"
+	m = m.updateView() // feat/auth, fix-login
+	m.cursor = 2
+
+	var removed string
+	m.onRemove = func(name string, _ bool) error { removed = name; return nil }
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	for i := 0; i <= dissolveFrames; i++ {
+		updated, _ = updated.Update(dissolveTickMsg{})
+	}
+	model := updated.(brModel)
+	if removed != "fix-login" {
+		t.Fatalf("removed = %q, want fix-login", removed)
+	}
+	if len(model.worktrees) != 2 || model.cursor != 1 || model.worktrees[1].Branch != "feat/auth" {
+		t.Fatalf("rows = %+v, cursor = %d", model.worktrees, model.cursor)
+	}
+	if len(model.all) != 3 {
+		t.Fatalf("all = %d worktrees, want 3", len(model.all))
+	}
+
+	// Clearing the filter doesn't bring the removed worktree back.
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if rows := updated.(brModel).worktrees; len(rows) != 3 {
+		t.Fatalf("rows after clearing filter = %+v", rows)
+	}
+}
+
+func TestBrSortModes(t *testing.T) {
+	now := time.Now()
+	m := filterTestModel()
+	m.sessions = map[string]session.SessionInfo{
+		"/wt/fix-login": {SessionID: "s1", UpdatedAt: now.Add(-time.Hour)},
+		"/wt/docs":      {SessionID: "s2", UpdatedAt: now.Add(-time.Minute)},
+	}
+	m.statuses = map[string]status.BranchStatus{
+		"feat/auth": {PRURL: "https://github.com/o/r/pull/7"},
+		"docs":      {PRURL: "https://github.com/o/r/pull/9"},
+	}
+	var looked []string
+	m.loadPRState = func(url string) string {
+		looked = append(looked, url)
+		if strings.HasSuffix(url, "/7") {
+			return "open"
+		}
+		return "merged"
+	}
+
+	order := func(m tea.Model) string {
+		var names []string
+		for _, wt := range m.(brModel).worktrees[1:] {
+			names = append(names, wt.Branch)
+		}
+		return strings.Join(names, ",")
+	}
+
+	updated := typeKeys(t, m, "s")
+	if got := order(updated); got != "docs,feat/auth,fix-login" {
+		t.Fatalf("name order = %s", got)
+	}
+	updated = typeKeys(t, updated, "s")
+	if got := order(updated); got != "docs,fix-login,feat/auth" {
+		t.Fatalf("activity order = %s", got)
+	}
+
+	updated, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	if cmd == nil {
+		t.Fatal("expected PR sort to look up PR states")
+	}
+	for _, msg := range cmd().(tea.BatchMsg) {
+		updated, _ = updated.Update(msg())
+	}
+	if len(looked) != 2 {
+		t.Fatalf("looked up %v", looked)
+	}
+	if got := order(updated); got != "feat/auth,docs,fix-login" {
+		t.Fatalf("PR order = %s", got)
+	}
+	view := updated.View()
+	if !strings.Contains(view, "PR #7 open") || !strings.Contains(view, "sort: PR state") {
+		t.Fatalf("view missing PR states:\n%s", view)
+	}
+
+	// The cycle wraps to default, and known states aren't looked up again.
+	updated = typeKeys(t, updated, "s")
+	if got := order(updated); got != "feat/auth,fix-login,docs" {
+		t.Fatalf("default order = %s", got)
+	}
+	updated = typeKeys(t, updated, "ss")
+	if _, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}}); cmd != nil {
+		t.Fatal("expected no lookups for known PR states")
+	}
+	if got := order(typeKeys(t, updated, "ss")); got != "feat/auth,fix-login,docs" {
+		t.Fatalf("default order = %s", got)
+	}
+}
+
+func TestBrOnlyActiveToggle(t *testing.T) {
+	m := filterTestModel()
+	m.activeWindow = 4 * time.Hour
+	m.sessions = map[string]session.SessionInfo{
+		"/wt/feat-auth": {SessionID: "s1", UpdatedAt: time.Now().Add(-5 * time.Hour)},
+		"/wt/fix-login": {SessionID: "s2", UpdatedAt: time.Now().Add(-time.Hour)},
+	}
+
+	updated := typeKeys(t, m, "a")
+	model := updated.(brModel)
+	if len(model.worktrees) != 2 || model.worktrees[1].Branch != "fix-login" {
+		t.Fatalf("active rows = %+v", model.worktrees)
+	}
+	if !strings.Contains(model.View(), "active in the last 4h") {
+		t.Fatalf("view missing active hint:\n%s", model.View())
+	}
+
+	if got := len(typeKeys(t, model, "a").(brModel).worktrees); got != 4 {
+		t.Fatalf("rows after toggling off = %d, want 4", got)
+	}
+}
+
+func TestBrDetailsLoadLazilyForSelectedRow(t *testing.T) {
+	m := filterTestModel()
+	m.sessions = map[string]session.SessionInfo{
+		"/wt/feat-auth": {SessionID: "s1", Summary: "Wire the OAuth callback and add a login page with tests"},
+	}
+	m.statuses["feat/auth"] = status.BranchStatus{Message: "Waiting on review", UpdatedAt: time.Now().Add(-10 * time.Minute)}
+	var loaded []string
+	m.loadDetails = func(path string) (worktree.Details, error) {
+		loaded = append(loaded, path)
+		return worktree.Details{
+			Path: path, Base: "origin/main", Ahead: 2, Behind: 1,
+			Changes: []string{" M main.go"},
+			Commits: []string{"abc1234 Add login"},
+		}, nil
+	}
+
+	// Nothing is loaded until a row is selected by a key press.
+	if len(loaded) != 0 {
+		t.Fatalf("loaded %v before any update", loaded)
+	}
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'k'}})
+	if cmd == nil {
+		t.Fatal("expected details to load for the selected row")
+	}
+	if !strings.Contains(updated.View(), "loading…") {
+		t.Fatalf("expected a loading placeholder:\n%s", updated.View())
+	}
+	updated, _ = updated.Update(cmd())
+	if len(loaded) != 1 || loaded[0] != "/wt/feat-auth" {
+		t.Fatalf("loaded = %v", loaded)
+	}
+
+	view := updated.View()
+	for _, want := range []string{
+		"/wt/feat-auth",
+		"origin/main (2 ahead, 1 behind)",
+		"M main.go",
+		"abc1234 Add login",
+		"Wire the OAuth callback and add a login page with tests",
+		"Waiting on review",
+	} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("view missing %q:\n%s", want, view)
+		}
+	}
+
+	// Cached details aren't reloaded; moving on loads the next row only.
+	if _, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'k'}}); cmd != nil {
+		t.Fatal("expected cached details to be reused")
+	}
+	updated, cmd = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	if cmd == nil {
+		t.Fatal("expected details to load for the next row")
+	}
+	updated, _ = updated.Update(cmd())
+	if len(loaded) != 2 || loaded[1] != "/wt/fix-login" {
+		t.Fatalf("loaded = %v", loaded)
+	}
+
+	// A refresh marks details stale; they reload but stay visible meanwhile.
+	updated, cmd = updated.Update(brRefreshedMsg{snapshot: brSnapshot{worktrees: m.all, statuses: m.statuses}})
+	if cmd == nil {
+		t.Fatal("expected stale details to reload")
+	}
+	if !strings.Contains(updated.View(), "abc1234 Add login") {
+		t.Fatalf("stale details hidden while reloading:\n%s", updated.View())
+	}
+}
+
+func TestBrDetailsShowRecentHistory(t *testing.T) {
+	m := filterTestModel()
+	m.loadDetails = func(path string) (worktree.Details, error) { return worktree.Details{Path: path}, nil }
+	var asked string
+	m.loadHistory = func(branch string) ([]status.Event, error) {
+		asked = branch
+		var events []status.Event
+		for i := 1; i <= brDetailHistory+2; i++ {
+			events = append(events, status.Event{Message: "step " + strconv.Itoa(i), At: time.Now().Add(-time.Hour)})
+		}
+		return append(events, status.Event{State: "waiting", Detail: "Use pg?", At: time.Now()}), nil
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'k'}})
+	updated, _ = updated.Update(cmd())
+	if asked != "feat/auth" {
+		t.Fatalf("history loaded for %q, want feat/auth", asked)
+	}
+	view := updated.View()
+	for _, want := range []string{"History", "step 7", "step 4", "[waiting] Use pg?", "1h ago"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("view missing %q:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "step 3") {
+		t.Fatalf("view should only list the last %d events:\n%s", brDetailHistory, view)
+	}
+}
+
+func TestBrDetailsToggle(t *testing.T) {
+	m := filterTestModel()
+	m.loadDetails = func(path string) (worktree.Details, error) {
+		return worktree.Details{}, errors.New("git status: exit status 128")
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	if cmd != nil || strings.Contains(updated.View(), "Path") {
+		t.Fatalf("expected i to hide the detail pane:\n%s", updated.View())
+	}
+
+	updated, cmd = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	if cmd == nil {
+		t.Fatal("expected details to load when the pane opens")
+	}
+	updated, _ = updated.Update(cmd())
+	if !strings.Contains(updated.View(), "exit status 128") {
+		t.Fatalf("expected the load error in the pane:\n%s", updated.View())
+	}
+}
+
+// drainCmds runs cmd and feeds the messages it produces back into m until
+// nothing is left to run.
+func drainCmds(m tea.Model, cmd tea.Cmd) tea.Model {
+	if cmd == nil {
+		return m
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		for _, c := range batch {
+			m = drainCmds(m, c)
+		}
+		return m
+	}
+	if msg == nil {
+		return m
+	}
+	m, next := m.Update(msg)
+	return drainCmds(m, next)
+}
+
+func TestBrBulkDeleteReportsPerRowFailures(t *testing.T) {
+	m := filterTestModel()
+	m.onRemove = func(name string, _ bool) error {
+		if name == "fix-login" {
+			return errors.New("worktree has uncommitted changes")
+		}
+		return nil
+	}
+
+	// Mark feat/auth and fix-login; space moves down after marking.
+	updated := typeKeys(t, m, "  ")
+	if !strings.Contains(updated.View(), "2 marked") {
+		t.Fatalf("view missing mark count:\n%s", updated.View())
+	}
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	view := updated.View()
+	if !strings.Contains(view, "Remove 2 worktrees") || !strings.Contains(view, "feat/auth") || !strings.Contains(view, "fix-login") {
+		t.Fatalf("confirm view = %s", view)
+	}
+
+	updated, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	if !strings.Contains(updated.View(), "removing…") {
+		t.Fatalf("expected per-row progress:\n%s", updated.View())
+	}
+	updated = drainCmds(updated, cmd)
+
+	model := updated.(brModel)
+	if len(model.all) != 3 || len(model.worktrees) != 3 {
+		t.Fatalf("rows = %+v, want feat/auth removed only", model.worktrees)
+	}
+	view = model.View()
+	if !strings.Contains(view, "failed") || !strings.Contains(view, "worktree has uncommitted changes") {
+		t.Fatalf("view missing the failure:\n%s", view)
+	}
+	if len(model.marked) != 0 || len(model.ops) != 0 {
+		t.Fatalf("marked = %v, ops = %v", model.marked, model.ops)
+	}
+}
+
+func TestBrBulkDeleteLimitsConcurrency(t *testing.T) {
+	worktrees := []worktree.WorktreeInfo{{Path: "/repo", Name: "repo"}}
+	for i := 1; i <= brRemoveConcurrency+2; i++ {
+		name := "feature-" + string(rune('0'+i))
+		worktrees = append(worktrees, worktree.WorktreeInfo{Path: "/wt/" + name, Branch: name, Name: name})
+	}
+	m := newBrModel(worktrees, "root", nil)
+	m.onRemove = func(string, bool) error { return nil }
+	for _, wt := range worktrees[1:] {
+		m.marked[wt.Branch] = true
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	updated, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	model := updated.(brModel)
+	removing, queued := 0, 0
+	for _, op := range model.ops {
+		switch op {
+		case brOpRemoving:
+			removing++
+		case brOpQueued:
+			queued++
+		}
+	}
+	if removing != brRemoveConcurrency || queued != 2 {
+		t.Fatalf("removing = %d, queued = %d", removing, queued)
+	}
+
+	// Quitting waits for the removals to finish.
+	if _, quit := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}}); quit != nil {
+		t.Fatal("expected q to wait for running removals")
+	}
+
+	model = drainCmds(model, cmd).(brModel)
+	if len(model.worktrees) != 1 || len(model.ops) != 0 {
+		t.Fatalf("rows = %d, ops = %v", len(model.worktrees), model.ops)
+	}
+}
+
+func TestBrBulkPublishAndStop(t *testing.T) {
+	origAlive := isProcessAlive
+	t.Cleanup(func() { isProcessAlive = origAlive })
+	isProcessAlive = func(int) bool { return true }
+
+	m := filterTestModel()
+	m.runs = map[string]runs.Run{"docs": {PID: 42}}
+	var stopped []string
+	m.onStop = func(name string) error {
+		stopped = append(stopped, name)
+		return nil
+	}
+	m.marked["feat/auth"] = true
+	m.marked["docs"] = true
+
+	// Only worktrees with a running agent are stopped.
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	if !strings.Contains(updated.View(), "stopping…") {
+		t.Fatalf("expected per-row progress:\n%s", updated.View())
+	}
+	updated = drainCmds(updated, cmd)
+	if len(stopped) != 1 || stopped[0] != "docs" {
+		t.Fatalf("stopped = %v", stopped)
+	}
+	if updated.(brModel).marked["docs"] || !updated.(brModel).marked["feat/auth"] {
+		t.Fatalf("marked = %v", updated.(brModel).marked)
+	}
+
+	updated = typeKeys(t, updated, "x")
+	if !strings.Contains(updated.View(), "no running agents to stop") {
+		t.Fatalf("expected a notice:\n%s", updated.View())
+	}
+
+	updated.(brModel).marked["fix-login"] = true
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	result := updated.(brModel).result
+	if result.Action != BrActionPublish || strings.Join(result.Names, ",") != "feat/auth,fix-login" {
+		t.Fatalf("result = %+v", result)
+	}
+}
+
+func TestBrSingleDeleteFailureKeepsRow(t *testing.T) {
+	m := filterTestModel()
+	m.onRemove = func(string, bool) error { return errors.New("branch is checked out elsewhere") }
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	for i := 0; i <= dissolveFrames; i++ {
+		updated, _ = updated.Update(dissolveTickMsg{})
+	}
+	model := updated.(brModel)
+	if len(model.worktrees) != 4 || model.dissolving != -1 {
+		t.Fatalf("rows = %d, dissolving = %d", len(model.worktrees), model.dissolving)
+	}
+	if !strings.Contains(model.View(), "branch is checked out elsewhere") {
+		t.Fatalf("view missing the failure:\n%s", model.View())
+	}
+}
+
+func TestBrDeleteWarnsAboutUnsavedWork(t *testing.T) {
+	m := filterTestModel()
+	m.loadPending = func(path, branch string) (worktree.Pending, error) {
+		if branch != "feat/auth" || path != "/wt/feat-auth" {
+			t.Fatalf("checked %s at %s", branch, path)
+		}
+		return worktree.Pending{Changes: []string{" M main.go", "?? notes.md"}, Unpushed: []string{"abc1234 Add login"}}, nil
+	}
+	var force bool
+	m.onRemove = func(_ string, f bool) error { force = f; return nil }
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	if cmd == nil || !strings.Contains(updated.View(), "Checking for uncommitted changes") {
+		t.Fatalf("expected a check before confirming:\n%s", updated.View())
+	}
+	// Confirming waits for the check.
+	if next, _ := updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}}); next.(brModel).state != brStateConfirmDelete {
+		t.Fatal("y accepted before the check finished")
+	}
+
+	updated, _ = updated.Update(cmd())
+	view := updated.View()
+	for _, want := range []string{"uncommitted", "M main.go", "?? notes.md", "unpushed", "abc1234 Add login", "f force remove"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("confirm view missing %q:\n%s", want, view)
+		}
+	}
+
+	// y doesn't remove a worktree with unsaved work; f does, with force.
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	if updated.(brModel).state != brStateConfirmDelete {
+		t.Fatal("y removed a worktree with unsaved work")
+	}
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'f'}})
+	for i := 0; i <= dissolveFrames; i++ {
+		updated, _ = updated.Update(dissolveTickMsg{})
+	}
+	if !force || len(updated.(brModel).worktrees) != 3 {
+		t.Fatalf("force = %v, rows = %d", force, len(updated.(brModel).worktrees))
+	}
+}
+
+func TestBrBulkDeleteSkipsDirtyWithoutForce(t *testing.T) {
+	m := filterTestModel()
+	m.loadPending = func(_, branch string) (worktree.Pending, error) {
+		switch branch {
+		case "fix-login":
+			return worktree.Pending{Changes: []string{" M login.go"}}, nil
+		case "docs":
+			return worktree.Pending{}, errors.New("git status: not a git repository")
+		}
+		return worktree.Pending{}, nil
+	}
+	var removed []string
+	m.onRemove = func(name string, force bool) error {
+		if force {
+			t.Fatalf("%s removed with force", name)
+		}
+		removed = append(removed, name)
+		return nil
+	}
+	for _, name := range []string{"feat/auth", "fix-login", "docs"} {
+		m.marked[name] = true
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	updated, _ = updated.Update(cmd())
+	view := updated.View()
+	if !strings.Contains(view, "y remove the 1 without unsaved work") || !strings.Contains(view, "could not check for unsaved work") {
+		t.Fatalf("confirm view = %s", view)
+	}
+
+	updated, cmd = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	model := drainCmds(updated, cmd).(brModel)
+	if strings.Join(removed, ",") != "feat/auth" || len(model.worktrees) != 3 {
+		t.Fatalf("removed = %v, rows = %d", removed, len(model.worktrees))
+	}
+}
+
+func TestBrDeleteFailureShowsBanner(t *testing.T) {
+	m := filterTestModel()
+	m.onRemove = func(string, bool) error {
+		return errors.New("git [worktree remove]: exit status 128: fatal: contains modified or untracked files\nuse --force")
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	for i := 0; i <= dissolveFrames; i++ {
+		updated, _ = updated.Update(dissolveTickMsg{})
+	}
+	model := updated.(brModel)
+	if len(model.worktrees) != 4 || model.worktrees[1].Branch != "feat/auth" {
+		t.Fatalf("row not restored: %+v", model.worktrees)
+	}
+	if want := "could not remove feat/auth: git [worktree remove]: exit status 128: fatal: contains modified or untracked files"; model.notice != want {
+		t.Fatalf("banner = %q, want %q", model.notice, want)
+	}
+	if !strings.Contains(model.View(), model.notice) {
+		t.Fatalf("banner not shown:\n%s", model.View())
+	}
+
+	// The banner goes away with the next key; the row stays marked failed.
+	updated = typeKeys(t, model, "j")
+	if updated.(brModel).notice != "" || !strings.Contains(updated.View(), "failed") {
+		t.Fatalf("after a key: banner = %q\n%s", updated.(brModel).notice, updated.View())
+	}
+}
+
+func TestBrDeleteIgnoresCheckForClosedConfirmation(t *testing.T) {
+	m := filterTestModel()
+	m.loadPending = func(string, string) (worktree.Pending, error) {
+		return worktree.Pending{Changes: []string{" M main.go"}}, nil
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	updated, _ = updated.Update(cmd())
+	if model := updated.(brModel); model.confirmPending != nil || model.state != brStateList {
+		t.Fatalf("stale check applied: %+v", model.confirmPending)
+	}
+}
+
+func TestBrCustomKeyBindings(t *testing.T) {
+	m := filterTestModel()
+	m.keys = newBrKeyMap(config.Config{KeyBindings: map[string]string{"br.delete": "D", "br.mark": "m,space"}})
+
+	updated := typeKeys(t, m, "d")
+	if got := updated.(brModel).state; got != brStateList {
+		t.Fatalf("d with delete rebound: state = %d, want list", got)
+	}
+
+	updated = typeKeys(t, updated, "m")
+	if got := updated.(brModel); !got.marked["feat/auth"] {
+		t.Fatalf("m should mark feat/auth, marked = %v", got.marked)
+	}
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeySpace})
+	if got := updated.(brModel); !got.marked["fix-login"] {
+		t.Fatalf("space should still mark, marked = %v", got.marked)
+	}
+
+	view := updated.View()
+	if !strings.Contains(view, "D remove") || !strings.Contains(view, "m/space mark") {
+		t.Fatalf("help footer should show the rebound keys:\n%s", view)
+	}
+	if !strings.Contains(view, "2 marked (D remove") {
+		t.Fatalf("marked hint should show the rebound key:\n%s", view)
+	}
+
+	updated = typeKeys(t, updated, "D")
+	if got := updated.(brModel).state; got != brStateConfirmDelete {
+		t.Fatalf("D: state = %d, want confirm delete", got)
+	}
+}
+
+func TestBrCustomPromptKeyBindings(t *testing.T) {
+	m := filterTestModel()
+	m.keys = newBrKeyMap(config.Config{KeyBindings: map[string]string{"br.yes": "Y", "br.no": "N", "br.back": "ctrl+b", "br.select": "tab"}})
+
+	updated := typeKeys(t, m, "d")
+	if view := updated.View(); !strings.Contains(view, "(Y confirm, N cancel)") {
+		t.Fatalf("confirmation should show the rebound keys:\n%s", view)
+	}
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if got := updated.(brModel); got.state != brStateConfirmDelete || got.dissolving >= 0 {
+		t.Fatalf("y and esc should do nothing once rebound: state = %d, dissolving = %d", got.state, got.dissolving)
+	}
+	updated = typeKeys(t, updated, "N")
+	if got := updated.(brModel).state; got != brStateList {
+		t.Fatalf("N: state = %d, want list", got)
+	}
+
+	updated = typeKeys(t, updated, "n")
+	updated = typeKeys(t, updated, "feat")
+	if view := updated.View(); !strings.Contains(view, "(tab confirm, ctrl+b back)") {
+		t.Fatalf("branch prompt should show the rebound keys:\n%s", view)
+	}
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if got := updated.(brModel).state; got != brStateNewAction {
+		t.Fatalf("tab: state = %d, want new action", got)
+	}
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyCtrlB})
+	if got := updated.(brModel).state; got != brStateNewBranch {
+		t.Fatalf("ctrl+b: state = %d, want new branch", got)
+	}
+}
+
+func TestBrHelpFooterToggle(t *testing.T) {
+	m := filterTestModel()
+	if view := m.View(); !strings.Contains(view, "? more keys") || strings.Contains(view, "active only") {
+		t.Fatalf("short help should list the common keys only:\n%s", view)
+	}
+
+	updated := typeKeys(t, m, "?")
+	if view := updated.View(); !strings.Contains(view, "active only") {
+		t.Fatalf("? should show every key:\n%s", view)
+	}
+}
+
+func TestUseTheme(t *testing.T) {
+	t.Cleanup(func() { useTheme("") })
+
+	useTheme("light")
+	if got := selectedStyle.GetForeground(); got != lipgloss.Color("163") {
+		t.Fatalf("light selected color = %v, want 163", got)
+	}
+
+	useTheme("no-such-theme")
+	if _, ok := selectedStyle.GetForeground().(lipgloss.AdaptiveColor); !ok {
+		t.Fatalf("unknown theme should fall back to auto, got %v", selectedStyle.GetForeground())
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	useTheme("dark")
+	for _, style := range []lipgloss.Style{selectedStyle, dimStyle, promptStyle, currentStyle, errorStyle} {
+		if _, ok := style.GetForeground().(lipgloss.NoColor); !ok {
+			t.Fatalf("NO_COLOR should drop colors, got %v", style.GetForeground())
+		}
+	}
+}
+
+func TestBrDefaultSortPutsWaitingAgentsFirst(t *testing.T) {
+	m := filterTestModel()
+	m.statuses = map[string]status.BranchStatus{
+		"feat/auth": {AgentState: status.AgentState{State: status.StateDone}},
+		"docs":      {Message: "Which style guide?", AgentState: status.AgentState{State: status.StateNeedsInput, Detail: "Two conflict."}},
+	}
+	m = m.updateView()
+
+	var names []string
+	for _, wt := range m.worktrees[1:] {
+		names = append(names, wt.Branch)
+	}
+	if got := strings.Join(names, ","); got != "docs,feat/auth,fix-login" {
+		t.Fatalf("default order = %s, want docs first", got)
+	}
+
+	m.cursor = 1
+	m.showDetails = true
+	view := m.View()
+	if !strings.Contains(view, "[needs-input]") || !strings.Contains(view, "[done]") {
+		t.Fatalf("view missing state badges:\n%s", view)
+	}
+	if !strings.Contains(view, "Two conflict.") {
+		t.Fatalf("details missing state detail:\n%s", view)
+	}
+}
@@ -0,0 +1,113 @@
+package cliapp
+
+import (
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fsnotify/fsnotify"
+
+	"fitz/internal/runs"
+	"fitz/internal/session"
+	"fitz/internal/status"
+	"fitz/internal/worktree"
+)
+
+// brRefreshInterval is how often the br TUI reloads worktrees, sessions and
+// statuses, so ages such as "12m ago" keep moving even without file changes.
+var brRefreshInterval = 5 * time.Second
+
+// brSnapshot is everything the br TUI shows, loaded in one go.
+type brSnapshot struct {
+	worktrees []worktree.WorktreeInfo
+	current   string
+	sessions  map[string]session.SessionInfo
+	statuses  map[string]status.BranchStatus
+	runs      map[string]runs.Run
+}
+
+type brRefreshTickMsg struct{}
+
+type brWatchMsg struct{}
+
+// brRefreshedMsg carries a loaded snapshot. seq is the model's refreshSeq
+// when the load started, so loads that raced a removal can be dropped.
+type brRefreshedMsg struct {
+	seq      int
+	snapshot brSnapshot
+	err      error
+}
+
+func brRefreshTickCmd() tea.Cmd {
+	return tea.Tick(brRefreshInterval, func(time.Time) tea.Msg {
+		return brRefreshTickMsg{}
+	})
+}
+
+// brRefreshCmd loads a fresh snapshot off the UI goroutine.
+func brRefreshCmd(load func() (brSnapshot, error), seq int) tea.Cmd {
+	return func() tea.Msg {
+		snapshot, err := load()
+		return brRefreshedMsg{seq: seq, snapshot: snapshot, err: err}
+	}
+}
+
+// brWatchCmd waits for the next change reported by a watcher.
+func brWatchCmd(changes <-chan struct{}) tea.Cmd {
+	if changes == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		if _, ok := <-changes; !ok {
+			return nil
+		}
+		return brWatchMsg{}
+	}
+}
+
+// watchDirs reports changes to files in dirs on the returned channel. Bursts
+// of events are coalesced, so several writes to status.json trigger a
+// single refresh. Directories that don't exist are skipped. When watching
+// is unavailable the channel is nil and stop does nothing; callers then
+// rely on periodic refreshes alone.
+func watchDirs(dirs []string) (changes <-chan struct{}, stop func()) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, func() {}
+	}
+	watching := 0
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+		if watcher.Add(dir) == nil {
+			watching++
+		}
+	}
+	if watching == 0 {
+		_ = watcher.Close()
+		return nil, func() {}
+	}
+
+	ch := make(chan struct{}, 1)
+	go func() {
+		defer close(ch)
+		for {
+			select {
+			case _, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				select {
+				case ch <- struct{}{}:
+				default: // a refresh is already pending
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+	return ch, func() { _ = watcher.Close() }
+}
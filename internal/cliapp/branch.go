@@ -12,13 +12,18 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
 	"fitz/internal/config"
+	"fitz/internal/runs"
 	"fitz/internal/session"
 	"fitz/internal/status"
 	"fitz/internal/worktree"
@@ -55,29 +60,47 @@ var runGh = func(dir string, args ...string) (string, error) {
 	return stdout.String(), nil
 }
 
-var runCopilot = func(dir string, args ...string) (string, error) {
-	copilotPath, err := exec.LookPath("copilot")
+// runAgent runs an agent binary headlessly in dir and returns its stdout.
+var runAgent = func(binary, dir string, args ...string) (string, error) {
+	agentPath, err := exec.LookPath(binary)
 	if err != nil {
-		return "", errors.New("copilot not found in PATH")
+		return "", fmt.Errorf("%s not found in PATH", binary)
 	}
-	cmd := exec.Command(copilotPath, args...)
+	cmd := exec.Command(agentPath, args...)
 	cmd.Dir = dir
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("copilot %v: %w: %s", args, err, stderr.String())
+		return "", fmt.Errorf("%s %v: %w: %s", binary, args, err, stderr.String())
 	}
 	return stdout.String(), nil
 }
 
-var runBackground = func(binary string, args []string, dir string) error {
+// runBackground starts binary detached from fitz with stdout and stderr
+// appended to logPath, and returns its PID.
+var runBackground = func(binary string, args []string, dir, logPath string) (int, error) {
+	if err := os.MkdirAll(filepath.Dir(logPath), 0o755); err != nil {
+		return 0, fmt.Errorf("create log directory: %w", err)
+	}
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return 0, fmt.Errorf("open log: %w", err)
+	}
+	defer logFile.Close()
+
 	cmd := exec.Command(binary, args[1:]...)
 	cmd.Dir = dir
 	cmd.Stdin = nil
-	cmd.Stdout = nil
-	cmd.Stderr = nil
-	return cmd.Start()
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = detachedProcAttr()
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	pid := cmd.Process.Pid
+	_ = cmd.Process.Release()
+	return pid, nil
 }
 
 var runCommand = func(binary string, args []string, dir string) error {
@@ -112,7 +135,7 @@ func parsePRNumber(input string) (int, error) {
 
 	n, err := strconv.Atoi(input)
 	if err != nil || n <= 0 {
-		return 0, fmt.Errorf("invalid PR number: %s", input)
+		return 0, Usagef("invalid PR number: %s", input)
 	}
 	return n, nil
 }
@@ -184,6 +207,7 @@ func BrCheckout(ctx context.Context, w io.Writer, pr string) error {
 	if err != nil {
 		return fmt.Errorf("create worktree: %w", err)
 	}
+	recordRepo(cwd)
 
 	// Store PR URL so br list shows it.
 	if statusPath, err := resolveAgentStatusPath(); err == nil {
@@ -196,11 +220,34 @@ func BrCheckout(ctx context.Context, w io.Writer, pr string) error {
 	return launchBranchInteractive(w, path, info.HeadRefName, repo, cfg)
 }
 
+// BrNew creates a worktree and either kicks off prompt in the background or,
+// without a prompt, opens the agent interactively in it.
 func BrNew(ctx context.Context, w io.Writer, name, base, prompt string) error {
+	result, err := NewBranch(ctx, name, base, prompt)
+	if err != nil {
+		return err
+	}
+	if result.AgentStarted {
+		result.WriteText(w)
+		return nil
+	}
+
 	cwd, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("get working directory: %w", err)
 	}
+	_, repo, _ := worktree.RepoID(worktree.ShellGit{}, cwd)
+	return launchBranchInteractive(w, result.Path, name, repo, loadEffectiveConfig(cwd))
+}
+
+// NewBranch creates a worktree for name from base (the default branch when
+// empty) and, with a prompt, starts a background agent in it. It never opens
+// an interactive session.
+func NewBranch(_ context.Context, name, base, prompt string) (BrNewResult, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return BrNewResult{}, fmt.Errorf("get working directory: %w", err)
+	}
 
 	git := worktree.ShellGit{}
 	mgr := &worktree.Manager{Git: git}
@@ -213,28 +260,258 @@ func BrNew(ctx context.Context, w io.Writer, name, base, prompt string) error {
 
 	path, err := mgr.Create(cwd, name, base)
 	if err != nil {
-		return fmt.Errorf("create worktree: %w", err)
+		return BrNewResult{}, fmt.Errorf("create worktree: %w", err)
+	}
+	recordRepo(cwd)
+	result := BrNewResult{Name: name, Branch: name, Path: path, Base: base}
+	if prompt == "" {
+		return result, nil
+	}
+
+	cfg := loadEffectiveConfig(cwd)
+	driver, err := resolveAgentDriver(cfg)
+	if err != nil {
+		return result, err
+	}
+	agentPath, err := lookPath(driver.Binary())
+	if err != nil {
+		return result, fmt.Errorf("%s not found in PATH", driver.Binary())
+	}
+	run, err := startAgentRun(runs.Run{
+		Branch: name,
+		Dir:    path,
+		Binary: agentPath,
+		Args:   driver.PromptArgs(cfg.Model, prompt),
+		Prompt: prompt,
+		Model:  cfg.Model,
+	})
+	if err != nil {
+		return result, fmt.Errorf("start %s: %w", driver.Binary(), err)
+	}
+
+	result.AgentStarted = true
+	result.Agent = driver.Binary()
+	result.Queued = run.Queued
+	result.RunID = run.ID
+	result.LogPath = run.LogPath
+	return result, nil
+}
+
+// BrFanout creates count worktrees named <name>-1..<name>-N from the same
+// base and kicks off prompt in each, cycling through models so the results
+// can be compared. The runs share a fanout group named after name, which
+// `br list` uses to show them together and `br pick` uses to clean up.
+func BrFanout(ctx context.Context, w io.Writer, name, base string, count int, models []string, prompt string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
 	}
 
-	_, repo, _ := worktree.RepoID(git, cwd)
 	cfg := loadEffectiveConfig(cwd)
+	assigned, err := fanoutModels(count, models, cfg.Model)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, len(assigned))
+	for i := range assigned {
+		names[i] = fmt.Sprintf("%s-%d", name, i+1)
+		if err := worktree.ValidateName(names[i]); err != nil {
+			return err
+		}
+	}
+
+	driver, err := resolveAgentDriver(cfg)
+	if err != nil {
+		return err
+	}
+	agentPath, err := lookPath(driver.Binary())
+	if err != nil {
+		return fmt.Errorf("%s not found in PATH", driver.Binary())
+	}
+
+	git := worktree.ShellGit{}
+	mgr := &worktree.Manager{Git: git}
 
-	if prompt != "" {
-		copilotPath, err := lookPath("copilot")
+	// Fetch latest from origin so every worktree starts from the same base.
+	_, _ = git.Run(cwd, "fetch", "origin")
+	if base == "" {
+		base = "origin/" + detectDefaultBranch(git, cwd)
+	}
+
+	// A failure partway leaves the worktrees made so far running; name them
+	// so they can be kept or cleaned up.
+	var created []string
+	fail := func(err error) error {
+		if len(created) == 0 {
+			return err
+		}
+		return fmt.Errorf("%w (already created: %s; remove them with `fitz br rm <name>`)", err, strings.Join(created, ", "))
+	}
+	for i, wtName := range names {
+		path, err := mgr.Create(cwd, wtName, base)
+		if err != nil {
+			return fail(fmt.Errorf("create worktree %s: %w", wtName, err))
+		}
+		created = append(created, wtName)
+		recordRepo(cwd)
+		model := assigned[i]
+		run, err := startAgentRun(runs.Run{
+			Branch: wtName,
+			Dir:    path,
+			Binary: agentPath,
+			Args:   driver.PromptArgs(model, prompt),
+			Prompt: prompt,
+			Model:  model,
+			Group:  name,
+		})
 		if err != nil {
-			return errors.New("copilot not found in PATH")
+			return fail(fmt.Errorf("start %s in %s: %w", driver.Binary(), wtName, err))
 		}
-		args := append(copilotBaseArgs(cfg), "--yolo", "-p", prompt)
-		if err := runBackground(copilotPath, args, path); err != nil {
-			return fmt.Errorf("start copilot: %w", err)
+
+		label := model
+		if label == "" {
+			label = "default model"
 		}
-		fmt.Fprintf(w, "worktree created: %s\n", name)
-		fmt.Fprintf(w, "copilot is working on it in the background\n")
-		fmt.Fprintf(w, "run `fitz br go %s` to navigate to it\n", name)
-		return nil
+		if run.Queued {
+			label += ", queued"
+		}
+		fmt.Fprintf(w, "worktree created: %s (%s)\n", wtName, label)
+	}
+
+	fmt.Fprintf(w, "%s is working on %d worktrees in the background\n", driver.Binary(), len(names))
+	fmt.Fprintf(w, "run `fitz br pick <name>` to keep one and remove the rest\n")
+	return nil
+}
+
+// fanoutModels returns the model for each fanout worktree. With no count,
+// one worktree is created per model; models are reused in order when count
+// is larger than the list, and a smaller count is refused rather than
+// dropping models. Without models, every worktree uses defaultModel.
+func fanoutModels(count int, models []string, defaultModel string) ([]string, error) {
+	if count > 0 && count < len(models) {
+		return nil, Usagef("--count %d is less than the %d models given; each model needs a worktree", count, len(models))
+	}
+	if count <= 0 {
+		count = len(models)
+	}
+	if count <= 0 {
+		return nil, Usagef("fanout needs --count or --models")
 	}
 
-	return launchBranchInteractive(w, path, name, repo, cfg)
+	assigned := make([]string, count)
+	for i := range assigned {
+		if len(models) > 0 {
+			assigned[i] = models[i%len(models)]
+		} else {
+			assigned[i] = defaultModel
+		}
+	}
+	return assigned, nil
+}
+
+// BrPick keeps the named fanout worktree and removes the other worktrees in
+// its fanout group, stopping their agents and discarding their changes.
+func BrPick(ctx context.Context, w io.Writer, name string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+
+	storePath, err := resolveRunStorePath()
+	if err != nil {
+		return err
+	}
+	all, err := runs.Load(storePath)
+	if err != nil {
+		return err
+	}
+
+	git := worktree.ShellGit{}
+	mgr := &worktree.Manager{Git: git}
+
+	list, err := mgr.List(cwd)
+	if err != nil {
+		return fmt.Errorf("list worktrees: %w", err)
+	}
+	existing := make(map[string]bool, len(list))
+	for _, wt := range list {
+		existing[wt.Name] = true
+		existing[wt.Branch] = true
+	}
+
+	siblings, err := fanoutSiblings(runs.Latest(all), name)
+	if err != nil {
+		return err
+	}
+	for _, sibling := range siblings {
+		if !existing[sibling] {
+			continue // already removed
+		}
+		if err := ensureNoLiveAgents(w, []string{sibling}, true); err != nil {
+			return err
+		}
+		if err := mgr.Remove(cwd, sibling, true); err != nil {
+			return fmt.Errorf("remove worktree %s: %w", sibling, err)
+		}
+		fmt.Fprintf(w, "removed worktree and branch: %s\n", sibling)
+		for _, item := range cleanupRemoved(cwd, []string{sibling}) {
+			fmt.Fprintf(w, "todo done: %s (PR for %s merged)\n", item.Text, item.Branch)
+		}
+	}
+
+	fmt.Fprintf(w, "kept %s\n", name)
+	return nil
+}
+
+// fanoutSiblings returns the other branches in name's fanout group, sorted.
+func fanoutSiblings(latest map[string]runs.Run, name string) ([]string, error) {
+	run, ok := latest[name]
+	if !ok || run.Group == "" {
+		return nil, fmt.Errorf("%s is not part of a fanout", name)
+	}
+
+	var siblings []string
+	for branch, r := range latest {
+		if branch != name && r.Group == run.Group {
+			siblings = append(siblings, branch)
+		}
+	}
+	sort.Strings(siblings)
+	return siblings, nil
+}
+
+// groupFanoutWorktrees reorders list so the members of each fanout group
+// sit together, at the position of the group's first member. The root
+// worktree stays first.
+func groupFanoutWorktrees(list []worktree.WorktreeInfo, latest map[string]runs.Run) []worktree.WorktreeInfo {
+	groupOf := func(wt worktree.WorktreeInfo) string {
+		branch := wt.Branch
+		if branch == "" {
+			branch = wt.Name
+		}
+		return latest[branch].Group
+	}
+
+	grouped := make([]worktree.WorktreeInfo, 0, len(list))
+	placed := make(map[string]bool)
+	for i, wt := range list {
+		group := groupOf(wt)
+		if i == 0 || group == "" {
+			grouped = append(grouped, wt)
+			continue
+		}
+		if placed[group] {
+			continue
+		}
+		placed[group] = true
+		for j, member := range list {
+			if j > 0 && groupOf(member) == group {
+				grouped = append(grouped, member)
+			}
+		}
+	}
+	return grouped
 }
 
 // copilotConfigDir returns the Copilot configuration directory (~/.copilot).
@@ -258,40 +535,36 @@ var loadEffectiveConfig = func(dir string) config.Config {
 	return cfg
 }
 
-// copilotBaseArgs returns the base args for invoking copilot with model if configured.
-func copilotBaseArgs(cfg config.Config) []string {
-	args := []string{"copilot"}
-	if cfg.Model != "" {
-		args = append(args, "--model", cfg.Model)
-	}
-	return args
-}
-
 func launchBranchInteractive(w io.Writer, path, name, repo string, cfg config.Config) error {
 	mode := strings.TrimSpace(cfg.BranchOpenMode)
 	if mode == "" {
 		mode = "zellij"
 	}
 
+	driver, err := resolveAgentDriver(cfg)
+	if err != nil {
+		return err
+	}
+
 	switch mode {
 	case "standard":
-		copilotPath, err := lookPath("copilot")
+		agentPath, err := lookPath(driver.Binary())
 		if err != nil {
-			return errors.New("copilot not found in PATH")
+			return fmt.Errorf("%s not found in PATH", driver.Binary())
 		}
 		if err := os.Chdir(path); err != nil {
 			return fmt.Errorf("cd to worktree: %w", err)
 		}
-		return runExec(copilotPath, copilotBaseArgs(cfg), os.Environ())
+		return runExec(agentPath, driver.InteractiveArgs(cfg.Model), os.Environ())
 	case "zellij":
-		return openBranchInZellij(w, path, name, repo, cfg)
+		return openBranchInZellij(w, path, name, repo, driver, cfg)
 	default:
 		return fmt.Errorf("invalid branch-open-mode: %s (valid values: zellij, standard)", mode)
 	}
 }
 
-// openZellijTab opens a new Zellij tab running copilot with the given args.
-func openZellijTab(path, name, repo string, copilotArgs []string, cfg config.Config) error {
+// openZellijTab opens a new Zellij tab running the agent with the given args.
+func openZellijTab(path, name, repo string, agentArgs []string, cfg config.Config) error {
 	sessionName := zellijSessionName()
 	if !isZellij() && sessionName == "" {
 		return errors.New("zellij mode requires an active zellij session; run from inside zellij or set branch-open-mode=standard")
@@ -302,7 +575,7 @@ func openZellijTab(path, name, repo string, copilotArgs []string, cfg config.Con
 		return err
 	}
 
-	layoutPath, err := writeZellijBranchLayout(copilotArgs, splitDirection)
+	layoutPath, err := writeZellijBranchLayout(agentArgs, splitDirection)
 	if err != nil {
 		return fmt.Errorf("create zellij layout: %w", err)
 	}
@@ -323,11 +596,11 @@ func openZellijTab(path, name, repo string, copilotArgs []string, cfg config.Con
 	return nil
 }
 
-func openBranchInZellij(w io.Writer, path, name, repo string, cfg config.Config) error {
-	if _, err := lookPath("copilot"); err != nil {
-		return errors.New("copilot not found in PATH")
+func openBranchInZellij(w io.Writer, path, name, repo string, driver AgentDriver, cfg config.Config) error {
+	if _, err := lookPath(driver.Binary()); err != nil {
+		return fmt.Errorf("%s not found in PATH", driver.Binary())
 	}
-	if err := openZellijTab(path, name, repo, copilotBaseArgs(cfg), cfg); err != nil {
+	if err := openZellijTab(path, name, repo, driver.InteractiveArgs(cfg.Model), cfg); err != nil {
 		return err
 	}
 	fmt.Fprintf(w, "worktree created: %s\n", name)
@@ -346,13 +619,13 @@ func zellijSplitDirection(cfg config.Config) (string, error) {
 	return layout, nil
 }
 
-func writeZellijBranchLayout(copilotArgs []string, splitDirection string) (string, error) {
+func writeZellijBranchLayout(agentArgs []string, splitDirection string) (string, error) {
 	file, err := os.CreateTemp("", "fitz-zellij-*.kdl")
 	if err != nil {
 		return "", err
 	}
 	layoutPath := file.Name()
-	if _, err := file.WriteString(zellijBranchLayout(copilotArgs, splitDirection)); err != nil {
+	if _, err := file.WriteString(zellijBranchLayout(agentArgs, splitDirection)); err != nil {
 		file.Close()
 		_ = os.Remove(layoutPath)
 		return "", err
@@ -364,15 +637,15 @@ func writeZellijBranchLayout(copilotArgs []string, splitDirection string) (strin
 	return layoutPath, nil
 }
 
-func zellijBranchLayout(copilotArgs []string, splitDirection string) string {
-	if len(copilotArgs) == 0 {
-		copilotArgs = []string{"copilot"}
+func zellijBranchLayout(agentArgs []string, splitDirection string) string {
+	if len(agentArgs) == 0 {
+		agentArgs = copilotDriver{}.InteractiveArgs("")
 	}
 
 	argsLine := ""
-	if len(copilotArgs) > 1 {
-		quotedArgs := make([]string, 0, len(copilotArgs)-1)
-		for _, arg := range copilotArgs[1:] {
+	if len(agentArgs) > 1 {
+		quotedArgs := make([]string, 0, len(agentArgs)-1)
+		for _, arg := range agentArgs[1:] {
 			quotedArgs = append(quotedArgs, strconv.Quote(arg))
 		}
 		argsLine = fmt.Sprintf("            args %s\n", strings.Join(quotedArgs, " "))
@@ -391,7 +664,7 @@ func zellijBranchLayout(copilotArgs []string, splitDirection string) string {
         plugin location="status-bar"
     }
 }
-`, strconv.Quote(splitDirection), strconv.Quote(copilotArgs[0]), argsLine)
+`, strconv.Quote(splitDirection), strconv.Quote(agentArgs[0]), argsLine)
 }
 
 func BrGo(ctx context.Context, w io.Writer, name string) error {
@@ -399,20 +672,29 @@ func BrGo(ctx context.Context, w io.Writer, name string) error {
 	if err != nil {
 		return fmt.Errorf("get working directory: %w", err)
 	}
+	return openWorktree(w, cwd, name)
+}
 
+// openWorktree opens the agent in worktree name of the repository at dir,
+// resuming its latest session when there is one.
+func openWorktree(w io.Writer, dir, name string) error {
 	git := worktree.ShellGit{}
 	mgr := &worktree.Manager{Git: git}
 
-	path, err := mgr.Path(cwd, name)
+	path, err := mgr.Path(dir, name)
 	if err != nil {
 		return fmt.Errorf("get worktree path: %w", err)
 	}
 
-	cfg := loadEffectiveConfig(cwd)
-	args := copilotBaseArgs(cfg)
-	if configDir := copilotConfigDir(); configDir != "" {
-		if sessionID, err := session.FindLatestSession(configDir, path); err == nil && sessionID != "" {
-			args = append(args, "--resume", sessionID)
+	cfg := loadEffectiveConfig(dir)
+	driver, err := resolveAgentDriver(cfg)
+	if err != nil {
+		return err
+	}
+	args := driver.InteractiveArgs(cfg.Model)
+	if sessions, err := driver.FindSessions([]string{path}); err == nil {
+		if info := sessions[path]; info.SessionID != "" {
+			args = driver.ResumeArgs(cfg.Model, info.SessionID)
 		}
 	}
 
@@ -423,110 +705,146 @@ func BrGo(ctx context.Context, w io.Writer, name string) error {
 
 	switch mode {
 	case "zellij":
-		_, repo, _ := worktree.RepoID(git, cwd)
+		_, repo, _ := worktree.RepoID(git, dir)
 		if err := openZellijTab(path, name, repo, args, cfg); err != nil {
 			return err
 		}
 		fmt.Fprintln(w, "opened in zellij")
 		return nil
 	case "standard":
-		copilotPath, err := lookPath("copilot")
+		agentPath, err := lookPath(driver.Binary())
 		if err != nil {
-			return errors.New("copilot not found in PATH")
+			return fmt.Errorf("%s not found in PATH", driver.Binary())
 		}
 		if err := os.Chdir(path); err != nil {
 			return fmt.Errorf("cd to worktree: %w", err)
 		}
-		return runExec(copilotPath, args, os.Environ())
+		return runExec(agentPath, args, os.Environ())
 	default:
 		return fmt.Errorf("invalid branch-open-mode: %s (valid values: zellij, standard)", mode)
 	}
 }
 
-func BrRemove(ctx context.Context, w io.Writer, name string, force bool) error {
+// BrRemove removes a worktree and its branch. Progress such as stopping a
+// running agent is written to w.
+func BrRemove(ctx context.Context, w io.Writer, name string, force bool) (BrRemoveResult, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
-		return fmt.Errorf("get working directory: %w", err)
+		return BrRemoveResult{}, fmt.Errorf("get working directory: %w", err)
 	}
 
 	git := worktree.ShellGit{}
 	mgr := &worktree.Manager{Git: git}
 
+	path, err := mgr.Path(cwd, name)
+	if err != nil {
+		return BrRemoveResult{}, err
+	}
+	if err := prepareRemoval(w, git, []worktree.WorktreeInfo{{Path: path, Branch: name, Name: name}}, force); err != nil {
+		return BrRemoveResult{}, err
+	}
+
 	if err := mgr.Remove(cwd, name, force); err != nil {
-		return fmt.Errorf("remove worktree: %w", err)
+		return BrRemoveResult{}, fmt.Errorf("remove worktree: %w", err)
 	}
 
-	fmt.Fprintf(w, "removed worktree and branch: %s\n", name)
-	return nil
+	return BrRemoveResult{
+		Removed:        []string{name},
+		CompletedTodos: cleanupRemoved(cwd, []string{name}),
+	}, nil
 }
 
-func BrRemoveAll(ctx context.Context, w io.Writer, force bool) error {
+func BrRemoveAll(ctx context.Context, w io.Writer, force bool) (BrRemoveResult, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
-		return fmt.Errorf("get working directory: %w", err)
+		return BrRemoveResult{}, fmt.Errorf("get working directory: %w", err)
 	}
 
 	git := worktree.ShellGit{}
 	mgr := &worktree.Manager{Git: git}
 
-	removed, err := mgr.RemoveAll(cwd, force)
+	list, err := mgr.List(cwd)
 	if err != nil {
-		return fmt.Errorf("remove worktrees: %w", err)
+		return BrRemoveResult{}, fmt.Errorf("list worktrees: %w", err)
+	}
+	// The root is never removed. Each worktree is checked on its own, so one
+	// that can't be removed is reported without holding back the others.
+	var result BrRemoveResult
+	var removable []worktree.WorktreeInfo
+	for _, wt := range list[min(1, len(list)):] {
+		if err := prepareRemoval(w, git, []worktree.WorktreeInfo{wt}, force); err != nil {
+			result.Refused = append(result.Refused, BrRemoveRefusal{Name: worktreeName(wt), Reason: err.Error()})
+			continue
+		}
+		removable = append(removable, wt)
 	}
 
-	if len(removed) == 0 {
-		fmt.Fprintln(w, "no worktrees to remove")
-		return nil
+	removed, err := mgr.RemoveWorktrees(cwd, removable, force)
+	result.Removed = removed
+	result.CompletedTodos = cleanupRemoved(cwd, removed)
+	if err != nil {
+		return result, fmt.Errorf("remove worktrees: %w", err)
 	}
+	return result, nil
+}
 
-	for _, name := range removed {
-		fmt.Fprintf(w, "removed worktree and branch: %s\n", name)
+// BrList shows the worktrees in the interactive TUI, or prints them in
+// format (table, json or tsv). Without a format, a table is printed when
+// stdin or stdout is not a terminal.
+func BrList(ctx context.Context, stdin io.Reader, stdout io.Writer, format string) error {
+	if format != "" && !slices.Contains(BrListFormats, format) {
+		return Usagef("invalid format: %s (valid values: %s)", format, strings.Join(BrListFormats, ", "))
+	}
+	if format == "" && (!isTerminal(stdin) || !isTerminal(stdout)) {
+		format = "table"
 	}
-	return nil
-}
 
-func BrList(ctx context.Context, stdin io.Reader, stdout io.Writer) error {
 	cwd, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("get working directory: %w", err)
 	}
 
-	git := worktree.ShellGit{}
-	mgr := &worktree.Manager{Git: git}
-
-	current, err := mgr.Current(cwd)
+	mgr := &worktree.Manager{Git: worktree.ShellGit{}}
+	load := func() (brSnapshot, error) { return loadBrSnapshot(mgr, cwd) }
+	snap, err := load()
 	if err != nil {
-		return fmt.Errorf("get current worktree: %w", err)
+		return err
 	}
-
-	list, err := mgr.List(cwd)
-	if err != nil {
-		return fmt.Errorf("list worktrees: %w", err)
+	if format != "" {
+		return writeBrList(stdout, format, brListRows(snap.worktrees, snap.current, snap.statuses, snap.sessions, snap.runs))
 	}
 
-	// Collect worktree paths and look up session info in a single pass.
-	cwds := make([]string, len(list))
-	for i, wt := range list {
-		cwds[i] = wt.Path
-	}
-	sessions := map[string]session.SessionInfo{}
-	if configDir := copilotConfigDir(); configDir != "" {
-		if s, err := session.FindAllSessionInfos(configDir, cwds); err == nil {
-			sessions = s
-		}
-	}
-	statuses := map[string]status.BranchStatus{}
-	if statusPath, err := resolveAgentStatusPath(); err == nil {
-		if s, err := status.Load(statusPath); err == nil {
-			statuses = s
-		}
-	}
+	// Launch interactive TUI, refreshing as statuses and sessions change.
+	changes, stopWatching := watchDirs(brWatchDirs(cwd, snap))
+	defer stopWatching()
 
-	// Launch interactive TUI.
-	model := newBrModel(list, current, sessions)
-	model.statuses = statuses
-	model.onRemove = func(name string) error {
-		return mgr.Remove(cwd, name, false)
+	cfg := loadEffectiveConfig(cwd)
+	useTheme(cfg.Theme)
+	model := newBrModel(snap.worktrees, snap.current, snap.sessions)
+	model.keys = newBrKeyMap(cfg)
+	model.setAgent(agentDisplayName(cfg))
+	model.statuses = snap.statuses
+	model.runs = snap.runs
+	model.refresh = load
+	model.changes = changes
+	model.loadLog = loadBranchLog
+	model.activeWindow = time.Duration(cfg.ActiveHours()) * time.Hour
+	model.loadPRState = func(prURL string) string { return pullRequestState(cwd, prURL) }
+	model.loadDetails = func(path string) (worktree.Details, error) {
+		return worktree.LoadDetails(mgr.Git, path, "origin/"+detectDefaultBranch(mgr.Git, path), brDetailCommits)
+	}
+	model.loadHistory = func(branch string) ([]status.Event, error) {
+		result, err := BrHistory(ctx, branch)
+		return result.Events, err
+	}
+	model.onRemove = func(name string, force bool) error {
+		return removeWorktree(mgr, cwd, name, force)
+	}
+	model.loadPending = func(path, branch string) (worktree.Pending, error) {
+		return worktree.LoadPending(mgr.Git, path, branch)
+	}
+	model.onStop = func(name string) error {
+		return BrStop(ctx, io.Discard, name, false)
 	}
 
 	p := tea.NewProgram(model, tea.WithInput(stdin), tea.WithOutput(stdout))
@@ -549,12 +867,86 @@ func BrList(ctx context.Context, stdin io.Reader, stdout io.Writer) error {
 	case BrActionNewKickoff:
 		return BrNew(ctx, stdout, m.result.BranchName, "", m.result.Prompt)
 	case BrActionPublish:
-		return BrPublish(ctx, stdout, m.result.Name)
+		if len(m.result.Names) == 0 {
+			return BrPublish(ctx, stdout, m.result.Name)
+		}
+		var errs []error
+		for _, name := range m.result.Names {
+			if err := BrPublish(ctx, stdout, name); err != nil {
+				errs = append(errs, fmt.Errorf("publish %s: %w", name, err))
+			}
+		}
+		return errors.Join(errs...)
 	}
 
 	return nil
 }
 
+// loadBrSnapshot reads the worktrees of the repository at cwd along with
+// their agent sessions, statuses and background runs.
+func loadBrSnapshot(mgr *worktree.Manager, cwd string) (brSnapshot, error) {
+	current, err := mgr.Current(cwd)
+	if err != nil {
+		return brSnapshot{}, fmt.Errorf("get current worktree: %w", err)
+	}
+
+	list, err := mgr.List(cwd)
+	if err != nil {
+		return brSnapshot{}, fmt.Errorf("list worktrees: %w", err)
+	}
+
+	// Collect worktree paths and look up session info in a single pass.
+	cwds := make([]string, len(list))
+	for i, wt := range list {
+		cwds[i] = wt.Path
+	}
+	sessions := map[string]session.SessionInfo{}
+	if driver, err := resolveAgentDriver(loadEffectiveConfig(cwd)); err == nil {
+		if s, err := driver.FindSessions(cwds); err == nil {
+			sessions = s
+		}
+	}
+	statuses := map[string]status.BranchStatus{}
+	if statusPath, err := resolveAgentStatusPath(); err == nil {
+		if s, err := status.Load(statusPath); err == nil {
+			statuses = s
+		}
+	}
+
+	latestRuns := loadLatestRuns()
+	return brSnapshot{
+		worktrees: groupFanoutWorktrees(list, latestRuns),
+		current:   current,
+		sessions:  sessions,
+		statuses:  statuses,
+		runs:      latestRuns,
+	}, nil
+}
+
+// brWatchDirs returns the directories whose changes should refresh the br
+// TUI: the repo's data directory (status.json and runs.json) and, for
+// Copilot, its session-state directory and the sessions being shown.
+func brWatchDirs(cwd string, snap brSnapshot) []string {
+	var dirs []string
+	if statusPath, err := resolveAgentStatusPath(); err == nil {
+		dirs = append(dirs, filepath.Dir(statusPath))
+	}
+	driver, err := resolveAgentDriver(loadEffectiveConfig(cwd))
+	if _, isCopilot := driver.(copilotDriver); err != nil || !isCopilot {
+		return dirs
+	}
+	if configDir := copilotConfigDir(); configDir != "" {
+		stateDir := filepath.Join(configDir, "session-state")
+		dirs = append(dirs, stateDir)
+		for _, info := range snap.sessions {
+			if info.SessionID != "" {
+				dirs = append(dirs, filepath.Join(stateDir, info.SessionID))
+			}
+		}
+	}
+	return dirs
+}
+
 func BrCurrent(ctx context.Context, w io.Writer) error {
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -595,6 +987,323 @@ func BrCd(ctx context.Context, w io.Writer, name string) error {
 	return nil
 }
 
+// BrLogs prints the captured output of a worktree's background agent run:
+// the most recent run, or runID when given. With follow, it keeps streaming
+// new output until the run finishes.
+func BrLogs(ctx context.Context, w io.Writer, name, runID string, follow bool, tail int) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+
+	git := worktree.ShellGit{}
+	mgr := &worktree.Manager{Git: git}
+
+	path, err := mgr.Path(cwd, name)
+	if err != nil {
+		return fmt.Errorf("get worktree path: %w", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("worktree not found: %s", name)
+	}
+
+	storePath, err := resolveRunStorePath()
+	if err != nil {
+		return err
+	}
+	run, err := findRun(storePath, name, runID)
+	if err != nil {
+		return err
+	}
+
+	data, err := readRunLog(run)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(tailLines(data, tail)); err != nil {
+		return err
+	}
+
+	if !follow {
+		return nil
+	}
+	return followRunLog(ctx, w, storePath, run, int64(len(data)))
+}
+
+// BrHistory returns every status update recorded for a branch, so the
+// progression of an agent's work can be followed after the fact.
+func BrHistory(_ context.Context, name string) (BrHistoryResult, error) {
+	storePath, err := resolveAgentStatusStorePath()
+	if err != nil {
+		return BrHistoryResult{}, err
+	}
+	events, err := status.LoadHistory(storePath, name)
+	if err != nil {
+		return BrHistoryResult{}, err
+	}
+	return BrHistoryResult{Branch: name, Events: events}, nil
+}
+
+// BrStop stops the background agent running in a worktree, or in every
+// worktree when all is set.
+func BrStop(ctx context.Context, w io.Writer, name string, all bool) error {
+	storePath, err := resolveRunStorePath()
+	if err != nil {
+		return err
+	}
+	live, err := liveRuns(storePath)
+	if err != nil {
+		return err
+	}
+
+	if !all {
+		run, ok := live[name]
+		if !ok {
+			return fmt.Errorf("no running agent for %s", name)
+		}
+		return stopBranchRun(w, storePath, name, run)
+	}
+
+	if len(live) == 0 {
+		fmt.Fprintln(w, "no running agents")
+		return nil
+	}
+	branches := make([]string, 0, len(live))
+	for branch := range live {
+		branches = append(branches, branch)
+	}
+	sort.Strings(branches)
+
+	var errs []error
+	for _, branch := range branches {
+		if err := stopBranchRun(w, storePath, branch, live[branch]); err != nil {
+			errs = append(errs, fmt.Errorf("stop %s: %w", branch, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func stopBranchRun(w io.Writer, storePath, branch string, run runs.Run) error {
+	if err := stopAgentRun(storePath, run); err != nil {
+		return err
+	}
+	if statusPath, err := resolveAgentStatusStorePath(); err == nil {
+		_, _ = status.SetStatus(statusPath, branch, "Stopped by user")
+	}
+	fmt.Fprintf(w, "stopped agent on %s\n", branch)
+	return nil
+}
+
+// BrQueue lists running and queued background runs in start order. Queued
+// runs with a free slot are started first, so the queue recovers if a
+// supervisor died before handing its slot on.
+func BrQueue(ctx context.Context, w io.Writer) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+	storePath, err := resolveRunStorePath()
+	if err != nil {
+		return err
+	}
+	limit := loadEffectiveConfig(cwd).AgentLimit()
+	if _, err := dispatchQueuedRuns(storePath, limit); err != nil {
+		return err
+	}
+
+	all, err := runs.Load(storePath)
+	if err != nil {
+		return err
+	}
+	var running []runs.Run
+	for _, r := range all {
+		if !r.Queued && runIsLive(r) {
+			running = append(running, r)
+		}
+	}
+	queued := runs.Queued(all)
+
+	if len(running) == 0 && len(queued) == 0 {
+		fmt.Fprintln(w, "no running or queued agents")
+		return nil
+	}
+
+	limitStr := "no limit"
+	if limit > 0 {
+		limitStr = strconv.Itoa(limit)
+	}
+	fmt.Fprintf(w, "max-concurrent-agents: %s (%d running, %d queued)\n\n", limitStr, len(running), len(queued))
+
+	nameWidth := len("BRANCH")
+	for _, r := range append(running, queued...) {
+		nameWidth = max(nameWidth, len(r.Branch))
+	}
+	fmt.Fprintf(w, "%-3s %-*s  %-8s  %s\n", "#", nameWidth, "BRANCH", "STATE", "PROMPT")
+	for _, r := range running {
+		fmt.Fprintf(w, "%-3s %-*s  %-8s  %s\n", "-", nameWidth, r.Branch, runs.StateRunning, truncateStatusMessage(firstPromptLine(r.Prompt)))
+	}
+	for i, r := range queued {
+		fmt.Fprintf(w, "%-3d %-*s  %-8s  %s\n", i+1, nameWidth, r.Branch, runs.StateQueued, truncateStatusMessage(firstPromptLine(r.Prompt)))
+	}
+	return nil
+}
+
+// BrQueueCancel removes a queued run, named by branch or run id, from the
+// queue. It is recorded as cancelled.
+func BrQueueCancel(ctx context.Context, w io.Writer, target string) error {
+	storePath, err := resolveRunStorePath()
+	if err != nil {
+		return err
+	}
+	run, err := findQueuedRun(storePath, target)
+	if err != nil {
+		return err
+	}
+	if _, err := runs.Cancel(storePath, run.ID); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "cancelled queued run for %s\n", run.Branch)
+	return nil
+}
+
+// BrQueueMove moves a queued run, named by branch or run id, to position
+// (1 is next to start).
+func BrQueueMove(ctx context.Context, w io.Writer, target string, position int) error {
+	storePath, err := resolveRunStorePath()
+	if err != nil {
+		return err
+	}
+	run, err := findQueuedRun(storePath, target)
+	if err != nil {
+		return err
+	}
+	if err := runs.MoveQueued(storePath, run.ID, position); err != nil {
+		return err
+	}
+	return BrQueue(ctx, w)
+}
+
+func findQueuedRun(storePath, target string) (runs.Run, error) {
+	all, err := runs.Load(storePath)
+	if err != nil {
+		return runs.Run{}, err
+	}
+	for _, r := range runs.Queued(all) {
+		if r.ID == target || r.Branch == target {
+			return r, nil
+		}
+	}
+	return runs.Run{}, fmt.Errorf("no queued run for %s", target)
+}
+
+func firstPromptLine(prompt string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(prompt), "\n")
+	return line
+}
+
+// prepareRemoval gets the worktrees in wts ready to be removed: it checks
+// first that the removal can go ahead, so that nothing is stopped or
+// cancelled for a removal that then fails, and then stops their agents and
+// cancels their queued kickoffs (see ensureNoLiveAgents). Without force,
+// worktrees with uncommitted changes are refused, as git would refuse them.
+func prepareRemoval(w io.Writer, git worktree.GitRunner, wts []worktree.WorktreeInfo, force bool) error {
+	names := make([]string, 0, len(wts))
+	for _, wt := range wts {
+		name := worktreeName(wt)
+		names = append(names, name)
+		if force {
+			continue
+		}
+		if _, err := os.Stat(wt.Path); errors.Is(err, os.ErrNotExist) {
+			continue // already deleted, so there are no changes to lose
+		}
+		dirty, err := worktree.Dirty(git, wt.Path)
+		if err != nil {
+			return fmt.Errorf("check %s: %w", name, err)
+		}
+		if dirty {
+			return fmt.Errorf("%s has uncommitted changes; commit or discard them, or pass --force", name)
+		}
+	}
+	return ensureNoLiveAgents(w, names, force)
+}
+
+// worktreeRemoveMu serializes the git side of worktree removals: git locks
+// files in the shared .git (config, packed-refs), so removals running at the
+// same time in one clone fail on each other's locks.
+var worktreeRemoveMu sync.Mutex
+
+// removeWorktree removes the worktree name and its branch for the br TUI.
+// Stopping its agent and cleaning up its status run concurrently with other
+// removals; only the git commands run one removal at a time.
+func removeWorktree(mgr *worktree.Manager, cwd, name string, force bool) error {
+	path, err := mgr.Path(cwd, name)
+	if err != nil {
+		return err
+	}
+	if err := prepareRemoval(io.Discard, mgr.Git, []worktree.WorktreeInfo{{Path: path, Branch: name, Name: name}}, force); err != nil {
+		return err
+	}
+	worktreeRemoveMu.Lock()
+	err = mgr.Remove(cwd, name, force)
+	worktreeRemoveMu.Unlock()
+	if err != nil {
+		return err
+	}
+	cleanupRemoved(cwd, []string{name})
+	return nil
+}
+
+// ensureNoLiveAgents refuses to go on while an agent is still running in any
+// of the named worktrees. With force, those agents are stopped instead.
+// Queued kickoffs for the worktrees are cancelled, since they would
+// otherwise start in a worktree that is gone.
+func ensureNoLiveAgents(w io.Writer, names []string, force bool) error {
+	storePath, err := resolveRunStorePath()
+	if err != nil {
+		return nil // no run registry for this repo, so nothing to guard
+	}
+	live, err := liveRuns(storePath)
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		for _, name := range names {
+			if _, ok := live[name]; ok {
+				return fmt.Errorf("an agent is still running in %s; stop it with `fitz br stop %s` or pass --force", name, name)
+			}
+		}
+	}
+
+	all, err := runs.Load(storePath)
+	if err != nil {
+		return err
+	}
+	removing := make(map[string]bool, len(names))
+	for _, name := range names {
+		removing[name] = true
+	}
+	for _, r := range runs.Queued(all) {
+		if !removing[r.Branch] {
+			continue
+		}
+		if _, err := runs.Cancel(storePath, r.ID); err != nil {
+			return fmt.Errorf("cancel queued run for %s: %w", r.Branch, err)
+		}
+		fmt.Fprintf(w, "cancelled queued run for %s\n", r.Branch)
+	}
+
+	for _, name := range names {
+		if run, ok := live[name]; ok {
+			if err := stopBranchRun(w, storePath, name, run); err != nil {
+				return fmt.Errorf("stop agent in %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
 func BrPublish(ctx context.Context, w io.Writer, name string) error {
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -630,8 +1339,11 @@ func BrPublish(ctx context.Context, w io.Writer, name string) error {
 	fmt.Fprintf(w, "pushed %s to origin\n", branch)
 
 	cfg := loadEffectiveConfig(cwd)
-	copilotArgs := append(copilotBaseArgs(cfg)[1:], "--yolo", "-p", "Create a PR for this branch")
-	output, err := runCopilot(cwd, copilotArgs...)
+	driver, err := resolveAgentDriver(cfg)
+	if err != nil {
+		return err
+	}
+	output, err := runAgent(driver.Binary(), cwd, driver.PromptArgs(cfg.Model, publishPrompt(branch))[1:]...)
 	if err != nil {
 		return fmt.Errorf("create pull request: %w", err)
 	}
@@ -641,9 +1353,19 @@ func BrPublish(ctx context.Context, w io.Writer, name string) error {
 	return nil
 }
 
+// publishPrompt asks the agent to open a pull request, closing the GitHub
+// issue behind the branch's todo when there is one.
+func publishPrompt(branch string) string {
+	prompt := "Create a PR for this branch"
+	if issue := linkedIssue(branch); issue != 0 {
+		prompt += fmt.Sprintf(". Include \"Closes #%d\" in the PR description", issue)
+	}
+	return prompt
+}
+
 // detectDefaultBranch returns the repo's default branch by inspecting
 // origin/HEAD. Falls back to "main" if the ref is not set.
-func detectDefaultBranch(git worktree.ShellGit, dir string) string {
+func detectDefaultBranch(git worktree.GitRunner, dir string) string {
 	out, err := git.Run(dir, "symbolic-ref", "refs/remotes/origin/HEAD")
 	if err == nil {
 		ref := strings.TrimSpace(out)
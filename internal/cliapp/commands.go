@@ -14,9 +14,8 @@ import (
 	"time"
 )
 
-func Version(_ context.Context, w io.Writer, version string) error {
-	_, err := fmt.Fprintf(w, "fitz %s\n", version)
-	return err
+func Version(_ context.Context, version string) VersionResult {
+	return VersionResult{Version: version}
 }
 
 func Update(ctx context.Context, w io.Writer, currentVersion string, preview bool) error {
@@ -213,7 +212,7 @@ func downloadAndReplace(ctx context.Context, downloadURL, exePath string) error
 
 func Completion(_ context.Context, w io.Writer, args []string) error {
 	if len(args) != 1 {
-		return errors.New("usage: fitz completion <bash|zsh>")
+		return Usagef("usage: fitz completion <bash|zsh>")
 	}
 
 	var script string
@@ -223,7 +222,7 @@ func Completion(_ context.Context, w io.Writer, args []string) error {
 	case "zsh":
 		script = zshCompletionScript
 	default:
-		return errors.New("usage: fitz completion <bash|zsh>")
+		return Usagef("usage: fitz completion <bash|zsh>")
 	}
 
 	_, err := io.WriteString(w, script)
@@ -246,7 +245,7 @@ _fitz_completion() {
   prev="${COMP_WORDS[COMP_CWORD-1]}"
 
   if [[ ${COMP_CWORD} -eq 1 ]]; then
-    COMPREPLY=( $(compgen -W "help version update completion agent br review todo" -- "$cur") )
+    COMPREPLY=( $(compgen -W "help version update completion gc agent br ls review todo" -- "$cur") )
     return
   fi
 
@@ -256,7 +255,7 @@ _fitz_completion() {
   fi
 
   if [[ ${COMP_CWORD} -eq 2 && "$prev" == "br" ]]; then
-    COMPREPLY=( $(compgen -W "new go rm list cd publish help" -- "$cur") )
+    COMPREPLY=( $(compgen -W "new go rm stop list cd logs history fanout pick queue publish help" -- "$cur") )
     return
   fi
 
@@ -266,7 +265,12 @@ _fitz_completion() {
   fi
 
   if [[ ${COMP_CWORD} -eq 2 && "$prev" == "todo" ]]; then
-    COMPREPLY=( $(compgen -W "list help" -- "$cur") )
+    COMPREPLY=( $(compgen -W "list edit import kickoff help" -- "$cur") )
+    return
+  fi
+
+  if [[ ${COMP_CWORD} -eq 2 && "$prev" == "ls" ]]; then
+    COMPREPLY=( $(compgen -W "go help" -- "$cur") )
     return
   fi
 }
@@ -287,12 +291,13 @@ fitz() {
 }
 
 _fitz() {
-  local -a commands shells br_cmds agent_cmds todo_cmds
-  commands=(help version update completion agent br review todo)
+  local -a commands shells br_cmds agent_cmds todo_cmds ls_cmds
+  commands=(help version update completion gc agent br ls review todo)
   shells=(bash zsh)
-  br_cmds=(new go rm list cd publish help)
+  br_cmds=(new go rm stop list cd logs history fanout pick queue publish help)
   agent_cmds=(status notify help)
-  todo_cmds=(list help)
+  todo_cmds=(list edit import kickoff help)
+  ls_cmds=(go help)
 
   if (( CURRENT == 2 )); then
     compadd -- $commands
@@ -318,6 +323,11 @@ _fitz() {
     compadd -- $todo_cmds
     return
   fi
+
+  if (( CURRENT == 3 )) && [[ "${words[2]}" == "ls" ]]; then
+    compadd -- $ls_cmds
+    return
+  fi
 }
 
 compdef _fitz fitz
@@ -0,0 +1,226 @@
+package cliapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"fitz/internal/status"
+	"fitz/internal/worktree"
+)
+
+// forgetBranches drops the status and history of removed branches, so a
+// branch created later with the same name doesn't inherit a stale PR link
+// or message. Best effort: the worktrees are already gone.
+func forgetBranches(branches []string) {
+	if len(branches) == 0 {
+		return
+	}
+	path, err := resolveAgentStatusStorePath()
+	if err != nil {
+		return
+	}
+	_ = status.Remove(path, branches...)
+}
+
+// cleanupRemoved finishes removing branches: todos linked to a merged PR
+// are marked done, then the branches' status and history are dropped (the
+// merge check needs the PR link kept in the status). It returns the todos it
+// completed.
+func cleanupRemoved(dir string, branches []string) []TodoItem {
+	completed := completeMergedTodos(dir, branches)
+	forgetBranches(branches)
+	return completed
+}
+
+// GC removes what fitz keeps for worktrees that no longer exist in the
+// repository at the working directory. With dryRun, it only reports what it
+// would remove.
+func GC(_ context.Context, dryRun bool) (GCResult, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return GCResult{}, fmt.Errorf("get working directory: %w", err)
+	}
+
+	mgr := &worktree.Manager{Git: worktree.ShellGit{}}
+	list, err := mgr.List(cwd)
+	if err != nil {
+		return GCResult{}, fmt.Errorf("list worktrees: %w", err)
+	}
+	commonDir, err := worktree.CommonDir(mgr.Git, cwd)
+	if err != nil {
+		return GCResult{}, fmt.Errorf("find git directory: %w", err)
+	}
+
+	statusPath, err := resolveAgentStatusStorePath()
+	if err != nil {
+		return GCResult{}, err
+	}
+	todoPath, err := resolveTodoStorePath()
+	if err != nil {
+		return GCResult{}, err
+	}
+	return collectGarbage(list, commonDir, statusPath, todoPath, dryRun)
+}
+
+// collectGarbage removes, for the worktrees in list:
+//   - status entries and history of branches without a worktree,
+//   - links from todos that aren't done to those branches, reopening them,
+//   - directories next to the status store that are left over from a
+//     worktree of the repository at commonDir (see leftoverDir).
+//
+// Other directories there, such as worktrees of another clone of the same
+// repository, are only listed as skipped. Dot directories such as .runs and
+// .history, and files, are left alone.
+func collectGarbage(list []worktree.WorktreeInfo, commonDir, statusPath, todoPath string, dryRun bool) (GCResult, error) {
+	result := GCResult{DryRun: dryRun, Branches: []string{}, Todos: []TodoItem{}, Directories: []string{}, Skipped: []string{}}
+
+	live := make(map[string]bool, 2*len(list))
+	livePaths := make(map[string]bool, len(list))
+	for _, wt := range list {
+		live[wt.Branch] = true
+		live[wt.Name] = true
+		livePaths[realPath(wt.Path)] = true
+	}
+
+	statuses, err := status.Load(statusPath)
+	if err != nil {
+		return result, err
+	}
+	histories, err := status.HistoryBranches(statusPath)
+	if err != nil {
+		return result, err
+	}
+	for branch := range statuses {
+		if !live[branch] {
+			result.Branches = append(result.Branches, branch)
+		}
+	}
+	for _, branch := range histories {
+		if !live[branch] && !slices.Contains(result.Branches, branch) {
+			result.Branches = append(result.Branches, branch)
+		}
+	}
+	slices.Sort(result.Branches)
+	if len(result.Branches) > 0 && !dryRun {
+		if err := status.Remove(statusPath, result.Branches...); err != nil {
+			return result, fmt.Errorf("remove status: %w", err)
+		}
+	}
+
+	stale := func(item TodoItem) bool {
+		return item.Branch != "" && item.Status() != TodoDone && !live[item.Branch]
+	}
+	items, err := LoadTodos(todoPath)
+	if err != nil {
+		return result, err
+	}
+	for _, item := range items {
+		if stale(item) {
+			result.Todos = append(result.Todos, item)
+		}
+	}
+	if len(result.Todos) > 0 && !dryRun {
+		err := MutateTodos(todoPath, func(items []TodoItem) ([]TodoItem, error) {
+			for i := range items {
+				if stale(items[i]) {
+					items[i].Branch = ""
+					items[i].State = TodoOpen
+				}
+			}
+			return items, nil
+		})
+		if err != nil {
+			return result, fmt.Errorf("unlink todos: %w", err)
+		}
+	}
+
+	repoDir := filepath.Dir(statusPath)
+	entries, err := os.ReadDir(repoDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return result, fmt.Errorf("read %s: %w", repoDir, err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		dir := filepath.Join(repoDir, entry.Name())
+		if livePaths[realPath(dir)] {
+			continue
+		}
+		if !leftoverDir(dir, commonDir, list) {
+			result.Skipped = append(result.Skipped, dir)
+			continue
+		}
+		if !dryRun {
+			if err := os.RemoveAll(dir); err != nil {
+				return result, fmt.Errorf("remove %s: %w", dir, err)
+			}
+		}
+		result.Directories = append(result.Directories, dir)
+	}
+
+	return result, nil
+}
+
+// leftoverDir reports whether dir, which git doesn't list as a worktree, is
+// safe to remove: it has no .git at all, or its .git file points at a
+// worktree of the repository at commonDir that git has pruned or would
+// prune. A .git directory, or a .git file pointing anywhere else, belongs to
+// someone else's checkout.
+func leftoverDir(dir, commonDir string, list []worktree.WorktreeInfo) bool {
+	info, err := os.Lstat(filepath.Join(dir, ".git"))
+	if errors.Is(err, os.ErrNotExist) {
+		return true
+	}
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	data, err := os.ReadFile(filepath.Join(dir, ".git"))
+	if err != nil {
+		return false
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return false
+	}
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(dir, gitDir)
+	}
+	if realPath(filepath.Dir(gitDir)) != realPath(filepath.Join(commonDir, "worktrees")) {
+		return false
+	}
+	adminDir := filepath.Clean(gitDir)
+
+	// The administrative directory is gone: git has already pruned it.
+	data, err = os.ReadFile(filepath.Join(adminDir, "gitdir"))
+	if errors.Is(err, os.ErrNotExist) {
+		_, err := os.Stat(adminDir)
+		return errors.Is(err, os.ErrNotExist)
+	}
+	if err != nil {
+		return false
+	}
+	// Otherwise git must list the worktree it records as prunable.
+	path := filepath.Dir(filepath.Clean(strings.TrimSpace(string(data))))
+	for _, wt := range list {
+		if wt.Prunable && filepath.Clean(wt.Path) == path {
+			return true
+		}
+	}
+	return false
+}
+
+// realPath resolves symlinks in path so that worktree paths reported by git
+// compare equal to the directories fitz created.
+func realPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
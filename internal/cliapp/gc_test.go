@@ -0,0 +1,218 @@
+package cliapp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fitz/internal/status"
+	"fitz/internal/worktree"
+)
+
+// gcTestRepo lays out ~/.fitz/<owner>/<repo>/ with a live worktree, a
+// leftover directory, and status, history and todos for both.
+func gcTestRepo(t *testing.T) (list []worktree.WorktreeInfo, commonDir, statusPath, todoPath string) {
+	t.Helper()
+	root := t.TempDir()
+	commonDir = filepath.Join(root, "src", "api", ".git")
+	repoDir := filepath.Join(root, ".fitz", "acme", "api")
+	for _, dir := range []string{"feat-auth", "old-feature", ".runs"} {
+		if err := os.MkdirAll(filepath.Join(repoDir, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(commonDir, "worktrees"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	list = []worktree.WorktreeInfo{
+		{Path: filepath.Join(root, "src", "api"), Branch: "main", Name: "api"},
+		{Path: filepath.Join(repoDir, "feat-auth"), Branch: "feat/auth", Name: "feat-auth"},
+	}
+
+	statusPath = filepath.Join(repoDir, "status.json")
+	for _, branch := range []string{"feat/auth", "old/feature"} {
+		if _, err := status.SetPR(statusPath, branch, "https://github.com/acme/api/pull/1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A history left behind without a status entry.
+	if err := status.AppendEvent(statusPath, "gone", status.Event{Message: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+
+	todoPath = filepath.Join(repoDir, "todos.json")
+	todos := []TodoItem{
+		{ID: "a", Text: "auth", Branch: "feat/auth", State: TodoInProgress},
+		{ID: "b", Text: "old", Branch: "old/feature", State: TodoInProgress},
+		{ID: "c", Text: "open"},
+	}
+	if err := SaveTodos(todoPath, todos); err != nil {
+		t.Fatal(err)
+	}
+	return list, commonDir, statusPath, todoPath
+}
+
+// writeGitFile makes dir look like a worktree whose git directory is gitDir.
+func writeGitFile(t *testing.T, dir, gitDir string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".git"), []byte("gitdir: "+gitDir+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCollectGarbage(t *testing.T) {
+	list, commonDir, statusPath, todoPath := gcTestRepo(t)
+	repoDir := filepath.Dir(statusPath)
+
+	result, err := collectGarbage(list, commonDir, statusPath, todoPath, false)
+	if err != nil {
+		t.Fatalf("gc error: %v", err)
+	}
+	if len(result.Branches) != 2 || result.Branches[0] != "gone" || result.Branches[1] != "old/feature" {
+		t.Fatalf("branches = %v", result.Branches)
+	}
+	if len(result.Todos) != 1 || result.Todos[0].ID != "b" || result.Todos[0].Branch != "old/feature" {
+		t.Fatalf("todos = %+v", result.Todos)
+	}
+	if len(result.Directories) != 1 || result.Directories[0] != filepath.Join(repoDir, "old-feature") {
+		t.Fatalf("directories = %v", result.Directories)
+	}
+
+	statuses, err := status.Load(statusPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := statuses["feat/auth"]; len(statuses) != 1 || !ok {
+		t.Fatalf("statuses = %v, want only feat/auth", statuses)
+	}
+	if branches, _ := status.HistoryBranches(statusPath); len(branches) != 1 || branches[0] != "feat/auth" {
+		t.Fatalf("history branches = %v", branches)
+	}
+	items, err := LoadTodos(todoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items[0].Branch != "feat/auth" || items[1].Branch != "" || items[1].Status() != TodoOpen {
+		t.Fatalf("todos = %+v", items)
+	}
+	for _, dir := range []string{"feat-auth", ".runs"} {
+		if _, err := os.Stat(filepath.Join(repoDir, dir)); err != nil {
+			t.Fatalf("%s was removed: %v", dir, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(repoDir, "old-feature")); !os.IsNotExist(err) {
+		t.Fatalf("old-feature still exists: %v", err)
+	}
+}
+
+func TestCollectGarbageDryRunRemovesNothing(t *testing.T) {
+	list, commonDir, statusPath, todoPath := gcTestRepo(t)
+
+	result, err := collectGarbage(list, commonDir, statusPath, todoPath, true)
+	if err != nil {
+		t.Fatalf("gc error: %v", err)
+	}
+	if !result.DryRun || len(result.Branches) != 2 || len(result.Todos) != 1 || len(result.Directories) != 1 {
+		t.Fatalf("result = %+v", result)
+	}
+
+	if statuses, _ := status.Load(statusPath); len(statuses) != 2 {
+		t.Fatalf("statuses = %v, want both kept", statuses)
+	}
+	if items, _ := LoadTodos(todoPath); items[1].Branch != "old/feature" {
+		t.Fatalf("todos = %+v, want links kept", items)
+	}
+	if _, err := os.Stat(result.Directories[0]); err != nil {
+		t.Fatalf("directory removed in dry run: %v", err)
+	}
+}
+
+func TestCollectGarbageKeepsForeignWorktrees(t *testing.T) {
+	list, commonDir, statusPath, todoPath := gcTestRepo(t)
+	repoDir := filepath.Dir(statusPath)
+	worktrees := filepath.Join(commonDir, "worktrees")
+
+	// A worktree of another clone of acme/api, with uncommitted work.
+	otherClone := filepath.Join(t.TempDir(), "api", ".git")
+	writeGitFile(t, filepath.Join(repoDir, "their-feature"), filepath.Join(otherClone, "worktrees", "their-feature"))
+	if err := os.WriteFile(filepath.Join(repoDir, "their-feature", "wip.go"), []byte("package wip\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A full clone someone put there.
+	if err := os.MkdirAll(filepath.Join(repoDir, "clone", ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// A worktree of this clone that is being created: git hasn't listed it.
+	writeGitFile(t, filepath.Join(repoDir, "new-feature"), filepath.Join(worktrees, "new-feature"))
+	if err := os.MkdirAll(filepath.Join(worktrees, "new-feature"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(worktrees, "new-feature", "gitdir"), []byte(filepath.Join(repoDir, "new-feature", ".git")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Worktrees of this clone that git has pruned, or would prune.
+	writeGitFile(t, filepath.Join(repoDir, "pruned"), filepath.Join(worktrees, "pruned"))
+	writeGitFile(t, filepath.Join(repoDir, "moved"), filepath.Join(worktrees, "moved"))
+	if err := os.MkdirAll(filepath.Join(worktrees, "moved"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(worktrees, "moved", "gitdir"), []byte("/gone/moved/.git\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	list = append(list, worktree.WorktreeInfo{Path: "/gone/moved", Branch: "moved", Name: "moved", Prunable: true})
+
+	result, err := collectGarbage(list, commonDir, statusPath, todoPath, false)
+	if err != nil {
+		t.Fatalf("gc error: %v", err)
+	}
+
+	wantRemoved := []string{"moved", "old-feature", "pruned"}
+	wantSkipped := []string{"clone", "new-feature", "their-feature"}
+	if len(result.Directories) != len(wantRemoved) || len(result.Skipped) != len(wantSkipped) {
+		t.Fatalf("directories = %v, skipped = %v", result.Directories, result.Skipped)
+	}
+	for i, name := range wantRemoved {
+		if result.Directories[i] != filepath.Join(repoDir, name) {
+			t.Errorf("directories[%d] = %s, want %s", i, result.Directories[i], name)
+		}
+		if _, err := os.Stat(filepath.Join(repoDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s still exists: %v", name, err)
+		}
+	}
+	for i, name := range wantSkipped {
+		if result.Skipped[i] != filepath.Join(repoDir, name) {
+			t.Errorf("skipped[%d] = %s, want %s", i, result.Skipped[i], name)
+		}
+		if _, err := os.Stat(filepath.Join(repoDir, name)); err != nil {
+			t.Errorf("%s was removed: %v", name, err)
+		}
+	}
+}
+
+func TestForgetBranches(t *testing.T) {
+	orig := resolveAgentStatusStorePath
+	t.Cleanup(func() { resolveAgentStatusStorePath = orig })
+	statusPath := filepath.Join(t.TempDir(), "status.json")
+	resolveAgentStatusStorePath = func() (string, error) { return statusPath, nil }
+
+	for _, branch := range []string{"feat/auth", "docs"} {
+		if _, err := status.SetStatus(statusPath, branch, "working"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	forgetBranches([]string{"feat/auth"})
+
+	statuses, err := status.Load(statusPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := statuses["docs"]; len(statuses) != 1 || !ok {
+		t.Fatalf("statuses = %v, want only docs", statuses)
+	}
+	if events, _ := status.LoadHistory(statusPath, "feat/auth"); len(events) != 0 {
+		t.Fatalf("feat/auth history = %v, want none", events)
+	}
+}
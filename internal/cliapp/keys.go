@@ -0,0 +1,173 @@
+package cliapp
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+
+	"fitz/internal/config"
+)
+
+// newBinding builds the binding for a config.DefaultKeyBindings action from
+// the keys cfg assigns it, labelled for the help footer.
+func newBinding(cfg config.Config, action, desc string) key.Binding {
+	keys := cfg.KeyBinding(action)
+	labels := make([]string, len(keys))
+	for i, k := range keys {
+		switch k {
+		case "space":
+			keys[i] = " "
+		case "up":
+			labels[i] = "↑"
+			continue
+		case "down":
+			labels[i] = "↓"
+			continue
+		}
+		labels[i] = k
+	}
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(strings.Join(labels, "/"), desc))
+}
+
+// keyLabel returns how the help footer shows b's keys.
+func keyLabel(b key.Binding) string {
+	return b.Help().Key
+}
+
+// keyHint describes what b's keys do on one screen, e.g. "enter confirm".
+func keyHint(b key.Binding, desc string) string {
+	return keyLabel(b) + " " + desc
+}
+
+// keyHints renders the hint line of a prompt or confirmation, e.g.
+// "(enter confirm, esc back)".
+func keyHints(hints ...string) string {
+	return "(" + strings.Join(hints, ", ") + ")"
+}
+
+// helpView renders the help footer for keys, listing every binding when all
+// is set. Lines are truncated to width when it is known.
+func helpView(keys help.KeyMap, width int, all bool) string {
+	h := help.New()
+	h.Width = width
+	h.ShowAll = all
+	h.Styles.ShortKey = promptStyle
+	h.Styles.ShortDesc = dimStyle
+	h.Styles.ShortSeparator = dimStyle
+	h.Styles.FullKey = promptStyle
+	h.Styles.FullDesc = dimStyle
+	h.Styles.FullSeparator = dimStyle
+	h.Styles.Ellipsis = dimStyle
+	return h.View(keys)
+}
+
+// brKeyMap holds the key bindings of the `fitz br` worktree list, and of
+// its prompts and confirmations (Select, Back, Yes, No and Force).
+type brKeyMap struct {
+	Up, Down, Go, Delete, New, Publish, Logs, Details key.Binding
+	Mark, Stop, Refresh, Filter, Sort, Active         key.Binding
+	Help, Quit                                        key.Binding
+	Select, Back, Yes, No, Force                      key.Binding
+}
+
+func newBrKeyMap(cfg config.Config) brKeyMap {
+	b := func(action, desc string) key.Binding { return newBinding(cfg, "br."+action, desc) }
+	return brKeyMap{
+		Up:      b("up", "up"),
+		Down:    b("down", "down"),
+		Go:      b("go", "go"),
+		Delete:  b("delete", "remove"),
+		New:     b("new", "new"),
+		Publish: b("publish", "publish"),
+		Logs:    b("logs", "logs"),
+		Details: b("details", "details"),
+		Mark:    b("mark", "mark"),
+		Stop:    b("stop", "stop agent"),
+		Refresh: b("refresh", "refresh"),
+		Filter:  b("filter", "filter"),
+		Sort:    b("sort", "sort"),
+		Active:  b("active", "active only"),
+		Help:    b("help", "more keys"),
+		Quit:    b("quit", "quit"),
+		Select:  b("select", "select"),
+		Back:    b("back", "back"),
+		Yes:     b("yes", "confirm"),
+		No:      b("no", "cancel"),
+		Force:   b("force", "force remove"),
+	}
+}
+
+func (k brKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Go, k.Delete, k.New, k.Publish, k.Mark, k.Filter, k.Help, k.Quit}
+}
+
+func (k brKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.Go, k.Mark},
+		{k.New, k.Delete, k.Publish, k.Stop},
+		{k.Logs, k.Details, k.Refresh},
+		{k.Filter, k.Sort, k.Active},
+		{k.Help, k.Quit},
+	}
+}
+
+// todoKeyMap holds the key bindings of the `fitz todo` list, and of its
+// prompts (Select and Back).
+type todoKeyMap struct {
+	Up, Down, Go, Mark, Kickoff, Tag, Done key.Binding
+	Help, Quit                             key.Binding
+	Select, Back                           key.Binding
+}
+
+func newTodoKeyMap(cfg config.Config) todoKeyMap {
+	b := func(action, desc string) key.Binding { return newBinding(cfg, "todo."+action, desc) }
+	return todoKeyMap{
+		Up:      b("up", "up"),
+		Down:    b("down", "down"),
+		Go:      b("go", "create worktree"),
+		Mark:    b("mark", "mark"),
+		Kickoff: b("kickoff", "kickoff marked"),
+		Tag:     b("tag", "filter tag"),
+		Done:    b("done", "done"),
+		Help:    b("help", "more keys"),
+		Quit:    b("quit", "quit"),
+		Select:  b("select", "select"),
+		Back:    b("back", "back"),
+	}
+}
+
+func (k todoKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Go, k.Mark, k.Kickoff, k.Done, k.Help, k.Quit}
+}
+
+func (k todoKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.Go},
+		{k.Mark, k.Kickoff, k.Tag, k.Done},
+		{k.Help, k.Quit},
+	}
+}
+
+// lsKeyMap holds the key bindings of the `fitz ls` dashboard.
+type lsKeyMap struct {
+	Up, Down, Go, Quit key.Binding
+}
+
+func newLsKeyMap(cfg config.Config) lsKeyMap {
+	b := func(action, desc string) key.Binding { return newBinding(cfg, "ls."+action, desc) }
+	return lsKeyMap{
+		Up:   b("up", "up"),
+		Down: b("down", "down"),
+		Go:   b("go", "go"),
+		Quit: b("quit", "quit"),
+	}
+}
+
+func (k lsKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.Go, k.Quit}
+}
+
+func (k lsKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
@@ -0,0 +1,326 @@
+package cliapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"fitz/internal/repos"
+	"fitz/internal/runs"
+	"fitz/internal/session"
+	"fitz/internal/status"
+	"fitz/internal/worktree"
+)
+
+var resolveRepoStorePath = func() (string, error) {
+	path, err := repos.StorePath("")
+	if err != nil {
+		return "", fmt.Errorf("resolve repo registry path: %w", err)
+	}
+	return path, nil
+}
+
+// recordRepo adds the repository at dir to the registry `fitz ls` reads.
+// It is called whenever fitz creates a worktree and never fails the caller.
+var recordRepo = func(dir string) {
+	git := worktree.ShellGit{}
+	mgr := &worktree.Manager{Git: git}
+	list, err := mgr.List(dir)
+	if err != nil || len(list) == 0 {
+		return
+	}
+	path, err := resolveRepoStorePath()
+	if err != nil {
+		return
+	}
+	owner, repo, _ := worktree.RepoID(git, dir)
+	_, _ = repos.Record(path, repos.Repo{Owner: owner, Name: repo, Root: list[0].Path})
+}
+
+// lsRepo is one repository in the `fitz ls` dashboard.
+type lsRepo struct {
+	Repo      string      `json:"repo"`
+	Root      string      `json:"root"`
+	Worktrees []brListRow `json:"worktrees"`
+}
+
+// Ls shows the worktrees of every repository fitz knows about, grouped by
+// repo, in the interactive TUI or printed in format (table, json or tsv).
+// Without a format, a table is printed when stdin or stdout is not a
+// terminal.
+func Ls(ctx context.Context, stdin io.Reader, stdout io.Writer, format string) error {
+	if format != "" && !slices.Contains(BrListFormats, format) {
+		return Usagef("invalid format: %s (valid values: %s)", format, strings.Join(BrListFormats, ", "))
+	}
+	if format == "" && (!isTerminal(stdin) || !isTerminal(stdout)) {
+		format = "table"
+	}
+
+	dashboard, err := loadDashboard()
+	if err != nil {
+		return err
+	}
+	if format != "" {
+		return writeLs(stdout, format, dashboard)
+	}
+
+	cwd, _ := os.Getwd()
+	cfg := loadEffectiveConfig(cwd)
+	useTheme(cfg.Theme)
+	model := newLsModel(dashboard)
+	model.keys = newLsKeyMap(cfg)
+	p := tea.NewProgram(model, tea.WithInput(stdin), tea.WithOutput(stdout))
+	finalModel, err := p.Run()
+	if err != nil {
+		return err
+	}
+	m, ok := finalModel.(lsModel)
+	if !ok || m.chosen == nil {
+		return nil
+	}
+	return openWorktree(stdout, m.chosen.root, m.chosen.name)
+}
+
+// LsGo opens a worktree from any repository, regardless of the current
+// directory. target is "<name>", "<repo>/<name>" or "<owner>/<repo>/<name>";
+// a bare name must be unique across repos.
+func LsGo(ctx context.Context, w io.Writer, target string) error {
+	dashboard, err := loadDashboard()
+	if err != nil {
+		return err
+	}
+	match, err := findLsTarget(dashboard, target)
+	if err != nil {
+		return err
+	}
+	return openWorktree(w, match.root, match.name)
+}
+
+// lsTarget identifies a worktree in the dashboard.
+type lsTarget struct {
+	repo string
+	root string
+	name string
+}
+
+func findLsTarget(dashboard []lsRepo, target string) (lsTarget, error) {
+	var matches []lsTarget
+	for _, r := range dashboard {
+		short := r.Repo[strings.LastIndex(r.Repo, "/")+1:]
+		for _, row := range r.Worktrees {
+			if target == row.Name || target == r.Repo+"/"+row.Name || target == short+"/"+row.Name {
+				matches = append(matches, lsTarget{repo: r.Repo, root: r.Root, name: row.Name})
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return lsTarget{}, fmt.Errorf("worktree not found: %s", target)
+	case 1:
+		return matches[0], nil
+	default:
+		names := make([]string, len(matches))
+		for i, m := range matches {
+			names[i] = m.repo + "/" + m.name
+		}
+		return lsTarget{}, fmt.Errorf("%s matches several worktrees: %s", target, strings.Join(names, ", "))
+	}
+}
+
+// loadDashboard collects the worktrees of every registered repository.
+// Repositories whose root has gone away are skipped.
+func loadDashboard() ([]lsRepo, error) {
+	storePath, err := resolveRepoStorePath()
+	if err != nil {
+		return nil, err
+	}
+	registry, err := repos.Load(storePath)
+	if err != nil {
+		return nil, err
+	}
+	fitzDir := filepath.Dir(storePath)
+	for _, repo := range discoverRepos(fitzDir, registry) {
+		if recorded, err := repos.Record(storePath, repo); err == nil {
+			registry[repo.ID()] = recorded
+		}
+	}
+
+	git := worktree.ShellGit{}
+	mgr := &worktree.Manager{Git: git}
+	current := ""
+	if cwd, err := os.Getwd(); err == nil {
+		current, _ = worktree.GitRoot(git, cwd)
+	}
+
+	var dashboard []lsRepo
+	for _, repo := range repos.Sorted(registry) {
+		if _, err := os.Stat(repo.Root); err != nil {
+			continue
+		}
+		list, err := mgr.List(repo.Root)
+		if err != nil || len(list) <= 1 {
+			continue
+		}
+		dataDir := filepath.Join(fitzDir, repo.ID())
+		rows := repoDashboardRows(list, current, dataDir, repo.Root)
+		dashboard = append(dashboard, lsRepo{Repo: repo.ID(), Root: repo.Root, Worktrees: rows})
+	}
+	return dashboard, nil
+}
+
+// repoDashboardRows builds the `br list` rows for one repo from its data
+// directory under ~/.fitz, leaving out the repository root.
+func repoDashboardRows(list []worktree.WorktreeInfo, currentPath, dataDir, root string) []brListRow {
+	statuses, err := status.Load(filepath.Join(dataDir, "status.json"))
+	if err != nil {
+		statuses = map[string]status.BranchStatus{}
+	}
+	latestRuns := map[string]runs.Run{}
+	if all, err := runs.Load(filepath.Join(dataDir, "runs.json")); err == nil {
+		latestRuns = runs.Latest(all)
+	}
+
+	paths := make([]string, len(list))
+	for i, wt := range list {
+		paths[i] = wt.Path
+	}
+	sessions := map[string]session.SessionInfo{}
+	if driver, err := resolveAgentDriver(loadEffectiveConfig(root)); err == nil {
+		if s, err := driver.FindSessions(paths); err == nil {
+			sessions = s
+		}
+	}
+
+	current := ""
+	for i, wt := range list {
+		if wt.Path == currentPath {
+			current = wt.Name
+			if i == 0 {
+				current = "root"
+			}
+		}
+	}
+
+	list = groupFanoutWorktrees(list, latestRuns)
+	return brListRows(list, current, statuses, sessions, latestRuns)[1:]
+}
+
+// discoverRepos finds repositories with worktrees under fitzDir that are
+// missing from the registry, such as those created before it existed. A
+// repo's data directory is ~/.fitz/<owner>/<repo>, or ~/.fitz/<repo> without
+// an owner, and its root is found through one of its worktrees.
+func discoverRepos(fitzDir string, registry map[string]repos.Repo) []repos.Repo {
+	mgr := &worktree.Manager{Git: worktree.ShellGit{}}
+
+	var found []repos.Repo
+	consider := func(owner, name, dataDir string) bool {
+		wt, ok := firstWorktreeDir(dataDir)
+		if !ok {
+			return false
+		}
+		repo := repos.Repo{Owner: owner, Name: name}
+		if _, known := registry[repo.ID()]; known {
+			return true
+		}
+		list, err := mgr.List(wt)
+		if err != nil || len(list) == 0 {
+			return true
+		}
+		repo.Root = list[0].Path
+		found = append(found, repo)
+		return true
+	}
+
+	for _, first := range visibleDirs(fitzDir) {
+		firstDir := filepath.Join(fitzDir, first)
+		if consider("", first, firstDir) {
+			continue
+		}
+		for _, second := range visibleDirs(firstDir) {
+			consider(first, second, filepath.Join(firstDir, second))
+		}
+	}
+	return found
+}
+
+// firstWorktreeDir returns a child of dir that is a git worktree, which is
+// recognised by its .git file.
+func firstWorktreeDir(dir string) (string, bool) {
+	for _, name := range visibleDirs(dir) {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(filepath.Join(path, ".git")); err == nil && !info.IsDir() {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// visibleDirs lists the subdirectories of dir, skipping hidden ones such as
+// .runs.
+func visibleDirs(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			names = append(names, e.Name())
+		}
+	}
+	return names
+}
+
+// writeLs renders the dashboard in format: a table grouped by repo, JSON,
+// or header-less TSV with a leading repo column followed by the `br list`
+// columns.
+func writeLs(w io.Writer, format string, dashboard []lsRepo) error {
+	switch format {
+	case "json":
+		if dashboard == nil {
+			dashboard = []lsRepo{}
+		}
+		data, err := json.MarshalIndent(dashboard, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encode repos: %w", err)
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	case "tsv":
+		for _, r := range dashboard {
+			for _, row := range r.Worktrees {
+				fmt.Fprintln(w, strings.Join(append([]string{r.Repo}, row.tsvFields()...), "\t"))
+			}
+		}
+		return nil
+	default:
+		if len(dashboard) == 0 {
+			fmt.Fprintln(w, "no worktrees")
+			return nil
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  REPO\tBRANCH\tPR\tSTATUS\tMESSAGE")
+		for _, r := range dashboard {
+			for i, row := range r.Worktrees {
+				marker, repo := " ", ""
+				if row.Current {
+					marker = "*"
+				}
+				if i == 0 {
+					repo = r.Repo
+				}
+				fmt.Fprintf(tw, "%s %s\t%s\t%s\t%s\t%s\n", marker, repo, row.Name, orDash(row.pr), orDash(row.status), row.Message)
+			}
+		}
+		return tw.Flush()
+	}
+}
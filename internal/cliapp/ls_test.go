@@ -0,0 +1,200 @@
+package cliapp
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"fitz/internal/config"
+	"fitz/internal/repos"
+	"fitz/internal/status"
+)
+
+func testDashboard() []lsRepo {
+	return []lsRepo{
+		{Repo: "acme/api", Root: "/src/api", Worktrees: []brListRow{
+			{Name: "feat/auth", Path: "/home/u/.fitz/acme/api/feat-auth", Branch: "feat/auth", Current: true, State: "idle", Message: "Implementing auth", pr: "PR #42", status: "5m ago"},
+			{Name: "fix-login", Path: "/home/u/.fitz/acme/api/fix-login", Branch: "fix-login"},
+		}},
+		{Repo: "acme/web", Root: "/src/web", Worktrees: []brListRow{
+			{Name: "fix-login", Path: "/home/u/.fitz/acme/web/fix-login", Branch: "fix-login", State: "running", status: "running"},
+		}},
+	}
+}
+
+func TestWriteLsTable(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeLs(&out, "table", testDashboard()); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want header + 3:\n%s", len(lines), out.String())
+	}
+	if !strings.HasPrefix(lines[1], "* acme/api") || !strings.Contains(lines[1], "feat/auth") || !strings.Contains(lines[1], "PR #42") {
+		t.Fatalf("first row = %q", lines[1])
+	}
+	if strings.Contains(lines[2], "acme/api") || !strings.Contains(lines[2], "--") {
+		t.Fatalf("second row = %q, want repo only on the first row of a group", lines[2])
+	}
+	if !strings.Contains(lines[3], "acme/web") {
+		t.Fatalf("third row = %q", lines[3])
+	}
+}
+
+func TestWriteLsJSONAndTSV(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeLs(&out, "json", testDashboard()); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	var decoded []struct {
+		Repo      string           `json:"repo"`
+		Root      string           `json:"root"`
+		Worktrees []map[string]any `json:"worktrees"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, out.String())
+	}
+	if len(decoded) != 2 || decoded[0].Root != "/src/api" || decoded[0].Worktrees[0]["branch"] != "feat/auth" {
+		t.Fatalf("decoded = %+v", decoded)
+	}
+
+	out.Reset()
+	if err := writeLs(&out, "json", nil); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if strings.TrimSpace(out.String()) != "[]" {
+		t.Fatalf("empty json = %q, want []", out.String())
+	}
+
+	out.Reset()
+	if err := writeLs(&out, "tsv", testDashboard()); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	fields := strings.Split(lines[2], "\t")
+	if len(lines) != 3 || len(fields) != 11 || fields[0] != "acme/web" || fields[1] != "fix-login" || fields[6] != "running" {
+		t.Fatalf("tsv = %q", out.String())
+	}
+}
+
+func TestFindLsTarget(t *testing.T) {
+	dashboard := testDashboard()
+	tests := []struct {
+		target   string
+		wantRepo string
+		wantErr  string
+	}{
+		{target: "feat/auth", wantRepo: "acme/api"},
+		{target: "acme/web/fix-login", wantRepo: "acme/web"},
+		{target: "api/fix-login", wantRepo: "acme/api"},
+		{target: "fix-login", wantErr: "matches several worktrees: acme/api/fix-login, acme/web/fix-login"},
+		{target: "nope", wantErr: "worktree not found: nope"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			got, err := findLsTarget(dashboard, tt.target)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.repo != tt.wantRepo {
+				t.Fatalf("repo = %q, want %q", got.repo, tt.wantRepo)
+			}
+		})
+	}
+}
+
+func TestLsModelEnterChoosesAcrossRepos(t *testing.T) {
+	m := newLsModel(testDashboard())
+	if len(m.targets) != 3 {
+		t.Fatalf("got %d targets, want 3", len(m.targets))
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyDown})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyDown}) // stays on the last row
+	updated, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("expected quit command")
+	}
+	chosen := updated.(lsModel).chosen
+	if chosen == nil || chosen.repo != "acme/web" || chosen.root != "/src/web" || chosen.name != "fix-login" {
+		t.Fatalf("chosen = %+v", chosen)
+	}
+}
+
+func TestLsModelViewGroupsByRepo(t *testing.T) {
+	view := newLsModel(testDashboard()).View()
+	api := strings.Index(view, "acme/api")
+	web := strings.Index(view, "acme/web")
+	auth := strings.Index(view, "feat/auth")
+	if api < 0 || web < 0 || !(api < auth && auth < web) {
+		t.Fatalf("view not grouped by repo:\n%s", view)
+	}
+}
+
+func TestLoadDashboardDiscoversUnregisteredRepos(t *testing.T) {
+	origStore, origCfg, origCopilot := resolveRepoStorePath, loadEffectiveConfig, copilotConfigDir
+	t.Cleanup(func() {
+		resolveRepoStorePath, loadEffectiveConfig, copilotConfigDir = origStore, origCfg, origCopilot
+	})
+
+	fitzDir := t.TempDir()
+	storePath := filepath.Join(fitzDir, "repos.json")
+	resolveRepoStorePath = func() (string, error) { return storePath, nil }
+	loadEffectiveConfig = func(string) config.Config { return config.Config{} }
+	sessionsDir := t.TempDir()
+	copilotConfigDir = func() string { return sessionsDir }
+
+	root := t.TempDir()
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git(root, "init", "--initial-branch=main")
+	git(root, "-c", "user.email=test@test.com", "-c", "user.name=Test", "commit", "--allow-empty", "-m", "init")
+	wtPath := filepath.Join(fitzDir, "acme", "api", "feat-auth")
+	git(root, "worktree", "add", wtPath, "-b", "feat/auth")
+	if err := os.MkdirAll(filepath.Join(fitzDir, "acme", "api", ".runs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	_ = status.Save(filepath.Join(fitzDir, "acme", "api", "status.json"), map[string]status.BranchStatus{
+		"feat/auth": {Message: "Implementing auth"},
+	})
+
+	dashboard, err := loadDashboard()
+	if err != nil {
+		t.Fatalf("load error: %v", err)
+	}
+	if len(dashboard) != 1 || dashboard[0].Repo != "acme/api" {
+		t.Fatalf("dashboard = %+v", dashboard)
+	}
+	rows := dashboard[0].Worktrees
+	if len(rows) != 1 || rows[0].Name != "feat/auth" || rows[0].Message != "Implementing auth" {
+		t.Fatalf("rows = %+v", rows)
+	}
+
+	registry, err := repos.Load(storePath)
+	if err != nil {
+		t.Fatalf("load registry: %v", err)
+	}
+	if got := registry["acme/api"].Root; filepath.Base(got) != filepath.Base(root) {
+		t.Fatalf("registered root = %q, want %q", got, root)
+	}
+}
@@ -0,0 +1,121 @@
+package cliapp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"fitz/internal/config"
+)
+
+// lsModel is the `fitz ls` TUI: every repo's worktrees, grouped by repo,
+// with enter opening the selected one.
+type lsModel struct {
+	repos    []lsRepo
+	targets  []lsTarget // selectable rows in display order
+	cursor   int
+	keys     lsKeyMap
+	quitting bool
+
+	// result
+	chosen *lsTarget
+}
+
+func newLsModel(dashboard []lsRepo) lsModel {
+	m := lsModel{repos: dashboard, keys: newLsKeyMap(config.Config{})}
+	for _, r := range dashboard {
+		for _, row := range r.Worktrees {
+			m.targets = append(m.targets, lsTarget{repo: r.Repo, root: r.Root, name: row.Name})
+		}
+	}
+	return m
+}
+
+func (m lsModel) Init() tea.Cmd { return nil }
+
+func (m lsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case keyMsg.String() == "ctrl+c", key.Matches(keyMsg, m.keys.Quit):
+		m.quitting = true
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Up):
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case key.Matches(keyMsg, m.keys.Down):
+		if m.cursor < len(m.targets)-1 {
+			m.cursor++
+		}
+	case key.Matches(keyMsg, m.keys.Go):
+		if len(m.targets) == 0 {
+			return m, nil
+		}
+		chosen := m.targets[m.cursor]
+		m.chosen = &chosen
+		m.quitting = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m lsModel) View() string {
+	if m.quitting {
+		return ""
+	}
+
+	var b strings.Builder
+	if len(m.targets) == 0 {
+		b.WriteString("No worktrees in any repo.\n\n")
+		b.WriteString(dimStyle.Render(fmt.Sprintf("(%s quit)", keyLabel(m.keys.Quit))))
+		b.WriteString("\n")
+		return b.String()
+	}
+
+	b.WriteString("All worktrees\n")
+
+	maxNameLen := 10
+	for _, t := range m.targets {
+		maxNameLen = max(maxNameLen, len(t.name))
+	}
+	const prCol = 10
+	const statusCol = 16
+
+	i := 0
+	for _, r := range m.repos {
+		b.WriteString("\n")
+		b.WriteString(promptStyle.Render(r.Repo))
+		b.WriteString(dimStyle.Render("  " + r.Root))
+		b.WriteString("\n")
+
+		for _, row := range r.Worktrees {
+			prefix := "     "
+			style := dimStyle
+			if i == m.cursor {
+				prefix = "  ▸  "
+				style = selectedStyle
+			} else if row.Current {
+				prefix = "  *  "
+				style = currentStyle
+			}
+			b.WriteString(style.Render(fmt.Sprintf("%s%-*s", prefix, maxNameLen, row.Name)))
+
+			prPadded := fmt.Sprintf("%-*s", prCol, orDash(row.pr))
+			if row.PRURL != "" {
+				prPadded = fmt.Sprintf("\x1b]8;;%s\x1b\\%-*s\x1b]8;;\x1b\\", row.PRURL, prCol, orDash(row.pr))
+			}
+			b.WriteString(dimStyle.Render(fmt.Sprintf("  %s  %-*s  %s", prPadded, statusCol, row.status, row.Message)))
+			b.WriteString("\n")
+			i++
+		}
+	}
+	b.WriteString("\n")
+	b.WriteString(helpView(m.keys, 0, false))
+	b.WriteString("\n")
+	return b.String()
+}
@@ -7,10 +7,14 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"fitz/internal/notify"
+	"fitz/internal/status"
 )
 
 var getwd = os.Getwd
 var userHomeDir = os.UserHomeDir
+var newNotifySink = notify.New
 
 func AgentNotify(w io.Writer, clear bool) error {
 	cwd, err := getwd()
@@ -39,15 +43,47 @@ func AgentNotify(w io.Writer, clear bool) error {
 		tabName = branch
 	}
 
+	inZellij := true
 	if err := zellijRun("action", "rename-tab", tabName); err != nil {
-		if errors.Is(err, errNotInZellij) {
-			// Fall back to terminal bell for non-Zellij environments.
-			if !clear {
-				fmt.Fprint(w, "\a")
-			}
-			return nil
+		if !errors.Is(err, errNotInZellij) {
+			return fmt.Errorf("rename tab: %w", err)
 		}
-		return fmt.Errorf("rename tab: %w", err)
+		inZellij = false
+	}
+	if clear {
+		return nil
+	}
+
+	// The renamed tab already shows the agent is waiting, so the bell is
+	// only a fallback for terminals outside Zellij.
+	sinkName := loadEffectiveConfig(cwd).Notify
+	if inZellij && (sinkName == "" || sinkName == "bell") {
+		return nil
+	}
+	sink, err := newNotifySink(sinkName, w)
+	if err != nil {
+		return err
+	}
+	if err := sink.Notify(branchNotification(fitzDir, cwd, branch)); err != nil {
+		return fmt.Errorf("send notification: %w", err)
 	}
 	return nil
 }
+
+// branchNotification describes branch for a notification. The repo comes
+// from cwd, which is a worktree at ~/.fitz/<owner>/<repo>/<name>; the
+// message is the branch's last status, when it can be read.
+func branchNotification(fitzDir, cwd, branch string) notify.Notification {
+	n := notify.Notification{Branch: branch}
+	if rel, err := filepath.Rel(fitzDir, cwd); err == nil {
+		if parts := strings.Split(filepath.ToSlash(rel), "/"); len(parts) >= 2 {
+			n.Repo = parts[0] + "/" + parts[1]
+		}
+	}
+	if path, err := resolveAgentStatusStorePath(); err == nil {
+		if entries, err := status.Load(path); err == nil {
+			n.Message = entries[branch].Message
+		}
+	}
+	return n
+}
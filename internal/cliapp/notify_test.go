@@ -2,19 +2,32 @@ package cliapp
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"fitz/internal/config"
+	"fitz/internal/notify"
+	"fitz/internal/status"
 )
 
 func stubFitzDir(t *testing.T) {
 	t.Helper()
 	origGetwd := getwd
 	origHome := userHomeDir
+	origConfig := loadEffectiveConfig
+	origStatusPath := resolveAgentStatusStorePath
 	t.Cleanup(func() {
 		getwd = origGetwd
 		userHomeDir = origHome
+		loadEffectiveConfig = origConfig
+		resolveAgentStatusStorePath = origStatusPath
 	})
+	loadEffectiveConfig = func(string) config.Config { return config.DefaultConfig() }
+	resolveAgentStatusStorePath = func() (string, error) { return "", errors.New("no status store") }
 	home := "/fake/home"
 	userHomeDir = func() (string, error) { return home, nil }
 	getwd = func() (string, error) { return home + "/.fitz/owner/repo/branch", nil }
@@ -177,3 +190,125 @@ func TestAgentNotifyNoOpWhenNotInRepo(t *testing.T) {
 		t.Fatalf("stdout = %q, want empty", out.String())
 	}
 }
+
+// fakeSink records the notifications sent through the configured sink.
+func fakeSink(t *testing.T, name string) *[]notify.Notification {
+	t.Helper()
+	origConfig := loadEffectiveConfig
+	origSink := newNotifySink
+	t.Cleanup(func() {
+		loadEffectiveConfig = origConfig
+		newNotifySink = origSink
+	})
+	loadEffectiveConfig = func(string) config.Config {
+		cfg := config.DefaultConfig()
+		cfg.Notify = name
+		return cfg
+	}
+	var sent []notify.Notification
+	newNotifySink = func(got string, _ io.Writer) (notify.Sink, error) {
+		if got != name {
+			t.Fatalf("sink = %q, want %q", got, name)
+		}
+		return notify.SinkFunc(func(n notify.Notification) error {
+			sent = append(sent, n)
+			return nil
+		}), nil
+	}
+	return &sent
+}
+
+func TestAgentNotifySendsToConfiguredSink(t *testing.T) {
+	stubFitzDir(t)
+	origBranch := resolveCurrentBranch
+	origRun := zellijRun
+	t.Cleanup(func() {
+		resolveCurrentBranch = origBranch
+		zellijRun = origRun
+	})
+	resolveCurrentBranch = func() (string, error) { return "feature-auth", nil }
+	statusPath := filepath.Join(t.TempDir(), "status.json")
+	resolveAgentStatusStorePath = func() (string, error) { return statusPath, nil }
+	if _, err := status.SetStatus(statusPath, "feature-auth", "Which database?"); err != nil {
+		t.Fatal(err)
+	}
+	sent := fakeSink(t, "desktop")
+
+	for _, inZellij := range []bool{false, true} {
+		renamed := false
+		zellijRun = func(args ...string) error {
+			if !inZellij {
+				return errNotInZellij
+			}
+			renamed = true
+			return nil
+		}
+		*sent = nil
+
+		var out bytes.Buffer
+		if err := AgentNotify(&out, false); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if renamed != inZellij {
+			t.Fatalf("in zellij = %v: renamed = %v", inZellij, renamed)
+		}
+		want := notify.Notification{Repo: "owner/repo", Branch: "feature-auth", Message: "Which database?"}
+		if len(*sent) != 1 || (*sent)[0] != want {
+			t.Fatalf("in zellij = %v: sent %+v, want %+v", inZellij, *sent, want)
+		}
+		if out.String() != "" {
+			t.Fatalf("stdout = %q, want no bell", out.String())
+		}
+	}
+
+	// Clearing only resets the tab.
+	*sent = nil
+	if err := AgentNotify(io.Discard, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*sent) != 0 {
+		t.Fatalf("sent %+v on clear", *sent)
+	}
+}
+
+func TestAgentNotifyDefaultSinkSkippedInZellij(t *testing.T) {
+	stubFitzDir(t)
+	origBranch := resolveCurrentBranch
+	origRun := zellijRun
+	t.Cleanup(func() {
+		resolveCurrentBranch = origBranch
+		zellijRun = origRun
+	})
+	resolveCurrentBranch = func() (string, error) { return "feature-auth", nil }
+	zellijRun = func(args ...string) error { return nil }
+	sent := fakeSink(t, "")
+
+	if err := AgentNotify(io.Discard, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*sent) != 0 {
+		t.Fatalf("sent %+v, want the renamed tab only", *sent)
+	}
+}
+
+func TestAgentNotifyReportsSinkErrors(t *testing.T) {
+	stubFitzDir(t)
+	origBranch := resolveCurrentBranch
+	origRun := zellijRun
+	origSink := newNotifySink
+	t.Cleanup(func() {
+		resolveCurrentBranch = origBranch
+		zellijRun = origRun
+		newNotifySink = origSink
+	})
+	resolveCurrentBranch = func() (string, error) { return "feature-auth", nil }
+	zellijRun = func(args ...string) error { return errNotInZellij }
+	newNotifySink = func(string, io.Writer) (notify.Sink, error) {
+		return notify.SinkFunc(func(notify.Notification) error { return errors.New("no notification service") }), nil
+	}
+
+	err := AgentNotify(io.Discard, false)
+	if err == nil || !strings.Contains(err.Error(), "no notification service") {
+		t.Fatalf("error = %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+//go:build !windows
+
+package cliapp
+
+import (
+	"errors"
+	"syscall"
+)
+
+// detachedProcAttr starts a process in its own session so it outlives fitz
+// and can be signalled as a process group.
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
+
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// signalProcessGroup sends an interrupt (or, with terminate, SIGTERM) to the
+// process group led by pid.
+func signalProcessGroup(pid int, terminate bool) error {
+	sig := syscall.SIGINT
+	if terminate {
+		sig = syscall.SIGTERM
+	}
+	return syscall.Kill(-pid, sig)
+}
@@ -0,0 +1,44 @@
+//go:build windows
+
+package cliapp
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// detachedProcAttr starts a process in a new process group so it is not
+// torn down with the console that launched fitz.
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	const processQueryLimitedInformation = 0x1000
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(h)
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); err != nil {
+		return false
+	}
+	const stillActive = 259
+	return code == stillActive
+}
+
+// signalProcessGroup asks the process tree rooted at pid to close or, with
+// terminate, forcibly ends it. Windows has no SIGINT for detached process
+// groups, so taskkill stands in for both signals.
+func signalProcessGroup(pid int, terminate bool) error {
+	args := []string{"/T", "/PID", strconv.Itoa(pid)}
+	if terminate {
+		args = append([]string{"/F"}, args...)
+	}
+	return exec.Command("taskkill", args...).Run()
+}
@@ -0,0 +1,180 @@
+package cliapp
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"fitz/internal/status"
+)
+
+// Result is the structured outcome of a command. WriteText prints it the
+// way fitz always has; with --json the value itself is encoded instead.
+type Result interface {
+	WriteText(w io.Writer)
+}
+
+// UsageError is a mistake in the arguments or flags a command was given, as
+// opposed to a failure running it. fitz exits with a distinct code for it.
+type UsageError struct{ Err error }
+
+func (e UsageError) Error() string { return e.Err.Error() }
+func (e UsageError) Unwrap() error { return e.Err }
+
+// Usagef formats a UsageError.
+func Usagef(format string, args ...any) error {
+	return UsageError{fmt.Errorf(format, args...)}
+}
+
+// VersionResult is the output of `fitz version`.
+type VersionResult struct {
+	Version string `json:"version"`
+}
+
+func (r VersionResult) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "fitz %s\n", r.Version)
+}
+
+// BrNewResult describes a worktree created by `br new` and the background
+// agent started in it, if any.
+type BrNewResult struct {
+	Name         string `json:"name"`
+	Branch       string `json:"branch"`
+	Path         string `json:"path"`
+	Base         string `json:"base"`
+	AgentStarted bool   `json:"agent_started"`
+	Agent        string `json:"agent,omitempty"`
+	Queued       bool   `json:"queued"`
+	RunID        string `json:"run_id,omitempty"`
+	LogPath      string `json:"log_path,omitempty"`
+}
+
+func (r BrNewResult) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "worktree created: %s\n", r.Name)
+	if !r.AgentStarted {
+		return
+	}
+	if r.Queued {
+		fmt.Fprintf(w, "%s is queued and will start when a running agent finishes (see `fitz br queue`)\n", r.Agent)
+	} else {
+		fmt.Fprintf(w, "%s is working on it in the background (log: %s)\n", r.Agent, r.LogPath)
+	}
+	fmt.Fprintf(w, "run `fitz br go %s` to navigate to it\n", r.Name)
+}
+
+// BrRemoveResult lists the worktrees removed by `br rm`, the todos that
+// were completed because their branch's PR had merged, and, for `br rm
+// --all`, the worktrees left in place and why.
+type BrRemoveResult struct {
+	Removed        []string          `json:"removed"`
+	CompletedTodos []TodoItem        `json:"completed_todos"`
+	Refused        []BrRemoveRefusal `json:"refused"`
+}
+
+// BrRemoveRefusal is a worktree `br rm --all` did not remove.
+type BrRemoveRefusal struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+func (r BrRemoveResult) WriteText(w io.Writer) {
+	if len(r.Removed) == 0 && len(r.Refused) == 0 {
+		fmt.Fprintln(w, "no worktrees to remove")
+		return
+	}
+	for _, name := range r.Removed {
+		fmt.Fprintf(w, "removed worktree and branch: %s\n", name)
+	}
+	for _, refusal := range r.Refused {
+		fmt.Fprintf(w, "not removed: %s (%s)\n", refusal.Name, refusal.Reason)
+	}
+	for _, item := range r.CompletedTodos {
+		fmt.Fprintf(w, "todo done: %s (PR for %s merged)\n", item.Text, item.Branch)
+	}
+}
+
+// GCResult lists what `fitz gc` removed, or would remove with --dry-run:
+// the branches whose status and history were dropped, the todos unlinked
+// from them (with the branch they pointed to) and leftover directories. It
+// also lists the directories it refused to touch because they may belong to
+// another checkout.
+type GCResult struct {
+	DryRun      bool       `json:"dry_run"`
+	Branches    []string   `json:"branches"`
+	Todos       []TodoItem `json:"todos"`
+	Directories []string   `json:"directories"`
+	Skipped     []string   `json:"skipped"`
+}
+
+func (r GCResult) WriteText(w io.Writer) {
+	for _, dir := range r.Skipped {
+		fmt.Fprintf(w, "skipped directory: %s (not a worktree of this clone)\n", dir)
+	}
+	if len(r.Branches) == 0 && len(r.Todos) == 0 && len(r.Directories) == 0 {
+		fmt.Fprintln(w, "nothing to clean up")
+		return
+	}
+	verb := "removed"
+	if r.DryRun {
+		verb = "would remove"
+	}
+	for _, branch := range r.Branches {
+		fmt.Fprintf(w, "%s status: %s\n", verb, branch)
+	}
+	for _, item := range r.Todos {
+		fmt.Fprintf(w, "%s todo link: %s (%s)\n", verb, item.Text, item.Branch)
+	}
+	for _, dir := range r.Directories {
+		fmt.Fprintf(w, "%s directory: %s\n", verb, dir)
+	}
+}
+
+// BrHistoryResult is the status timeline of a branch, oldest first.
+type BrHistoryResult struct {
+	Branch string         `json:"branch"`
+	Events []status.Event `json:"events"`
+}
+
+func (r BrHistoryResult) WriteText(w io.Writer) {
+	if len(r.Events) == 0 {
+		fmt.Fprintf(w, "no status history for %s\n", r.Branch)
+		return
+	}
+	for _, e := range r.Events {
+		fmt.Fprintf(w, "%s  %s\n", e.At.Local().Format("2006-01-02 15:04:05"), eventText(e))
+	}
+}
+
+// eventText describes a status event: its message, the PR it linked, or
+// the state the agent reported.
+func eventText(e status.Event) string {
+	switch {
+	case e.State != "":
+		if e.Detail != "" {
+			return "[" + e.State + "] " + strings.ReplaceAll(e.Detail, "\n", " ")
+		}
+		return "[" + e.State + "]"
+	case e.Message == "" && e.PRURL != "":
+		return "PR: " + e.PRURL
+	}
+	return e.Message
+}
+
+// TodoAddResult is the todo created by `fitz todo <text>`.
+type TodoAddResult struct {
+	TodoItem
+}
+
+func (r TodoAddResult) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "added: %s (%s)\n", r.Text, r.ID)
+}
+
+// AgentStatusResult is the status entry stored by `agent status`.
+type AgentStatusResult struct {
+	Branch string `json:"branch"`
+	status.BranchStatus
+}
+
+func (r AgentStatusResult) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "updated status for %s\n", r.Branch)
+}
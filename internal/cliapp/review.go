@@ -14,19 +14,19 @@ import (
 	"fitz/internal/worktree"
 )
 
-// copilotResult holds the output and error from a background Copilot run.
-type copilotResult struct {
+// agentResult holds the output and error from a background agent run.
+type agentResult struct {
 	output string
 	err    error
 }
 
-// runCopilotAsync launches Copilot asynchronously and sends the result on the
+// runAgentAsync launches the agent asynchronously and sends the result on the
 // returned channel when it completes.
-var runCopilotAsync = func(dir string, args ...string) <-chan copilotResult {
-	ch := make(chan copilotResult, 1)
+var runAgentAsync = func(binary, dir string, args ...string) <-chan agentResult {
+	ch := make(chan agentResult, 1)
 	go func() {
-		output, err := runCopilot(dir, args...)
-		ch <- copilotResult{output: output, err: err}
+		output, err := runAgent(binary, dir, args...)
+		ch <- agentResult{output: output, err: err}
 	}()
 	return ch
 }
@@ -61,6 +61,7 @@ func Review(_ context.Context, w io.Writer, focus string) error {
 		if err != nil {
 			return fmt.Errorf("create review worktree: %w", err)
 		}
+		recordRepo(cwd)
 		reviewDir = path
 		branch = name
 		fmt.Fprintf(w, "created review worktree: %s\n", name)
@@ -75,21 +76,29 @@ func Review(_ context.Context, w io.Writer, focus string) error {
 
 	prompt := buildReviewPrompt(focus, diff)
 	cfg := loadEffectiveConfig(cwd)
-	args := append(copilotBaseArgs(cfg)[1:], "--yolo", "-p", prompt)
+	driver, err := resolveAgentDriver(cfg)
+	if err != nil {
+		return err
+	}
+	args := driver.PromptArgs(cfg.Model, prompt)[1:]
 
 	// Resolve status path for polling.
 	statusPath, statusBranch := resolveReviewStatusInfo(cwd, branch)
 
 	fmt.Fprintf(w, "⟳ Review: starting\n")
 
-	resultCh := runCopilotAsync(reviewDir, args...)
+	resultCh := runAgentAsync(driver.Binary(), reviewDir, args...)
 
-	// Poll status.json for live updates.
-	var lastMessage string
+	// Poll the branch's status history for live updates, printing every
+	// event so quick phase changes aren't skipped.
+	seen := 0
+	if statusPath != "" {
+		seen = len(readStatusEvents(statusPath, statusBranch))
+	}
 	for {
 		select {
 		case result := <-resultCh:
-			// Copilot finished — print final output.
+			// Agent finished — print final output.
 			if result.err != nil {
 				fmt.Fprintf(w, "✗ Review: failed\n")
 				return fmt.Errorf("run review: %w", result.err)
@@ -107,11 +116,7 @@ func Review(_ context.Context, w io.Writer, focus string) error {
 			if statusPath == "" {
 				continue
 			}
-			msg := readLatestStatus(statusPath, statusBranch)
-			if msg != "" && msg != lastMessage {
-				lastMessage = msg
-				fmt.Fprintf(w, "⟳ %s\n", msg)
-			}
+			seen = printStatusEvents(w, statusPath, statusBranch, seen)
 		}
 	}
 }
@@ -177,16 +182,23 @@ func resolveReviewStatusInfo(cwd, branch string) (string, string) {
 	return path, branch
 }
 
-// readLatestStatus reads the current status message for a branch.
-func readLatestStatus(path, branch string) string {
-	entries, err := status.Load(path)
-	if err != nil {
-		return ""
+// printStatusEvents prints the status events of branch after the first
+// seen, in order, and returns the number of events seen so far.
+func printStatusEvents(w io.Writer, path, branch string, seen int) int {
+	events := readStatusEvents(path, branch)
+	for _, e := range events[min(seen, len(events)):] {
+		fmt.Fprintf(w, "⟳ %s\n", eventText(e))
 	}
-	if entry, ok := entries[branch]; ok {
-		return entry.Message
+	return max(seen, len(events))
+}
+
+// readStatusEvents reads the status history of a branch, oldest first.
+func readStatusEvents(path, branch string) []status.Event {
+	events, err := status.LoadHistory(path, branch)
+	if err != nil {
+		return nil
 	}
-	return ""
+	return events
 }
 
 // computeBranchDiffCmd is used for testing — wraps exec.Command for diff.
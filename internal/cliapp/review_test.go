@@ -64,12 +64,12 @@ func (m mockGitForReview) Run(dir string, args ...string) (string, error) {
 }
 
 func TestReviewOnFeatureBranch(t *testing.T) {
-	originalRunCopilotAsync := runCopilotAsync
+	originalRunAgentAsync := runAgentAsync
 	originalLoadConfig := loadEffectiveConfig
 	originalReviewGit := reviewGit
 	originalInterval := reviewStatusInterval
 	t.Cleanup(func() {
-		runCopilotAsync = originalRunCopilotAsync
+		runAgentAsync = originalRunAgentAsync
 		loadEffectiveConfig = originalLoadConfig
 		reviewGit = originalReviewGit
 		reviewStatusInterval = originalInterval
@@ -83,15 +83,15 @@ func TestReviewOnFeatureBranch(t *testing.T) {
 
 	reviewGit = func() worktree.ShellGit {
 		// We can't return a mockGitForReview here because ShellGit is a struct,
-		// but we override runCopilotAsync to avoid actual git calls.
+		// but we override runAgentAsync to avoid actual git calls.
 		return worktree.ShellGit{}
 	}
 
 	var gotArgs []string
-	runCopilotAsync = func(dir string, args ...string) <-chan copilotResult {
+	runAgentAsync = func(_, dir string, args ...string) <-chan agentResult {
 		gotArgs = args
-		ch := make(chan copilotResult, 1)
-		ch <- copilotResult{output: "- [high] main.go:10 - nil deref\n", err: nil}
+		ch := make(chan agentResult, 1)
+		ch <- agentResult{output: "- [high] main.go:10 - nil deref\n", err: nil}
 		return ch
 	}
 
@@ -128,18 +128,18 @@ func TestReviewOnFeatureBranch(t *testing.T) {
 }
 
 func TestReviewPropagatesCopilotErrors(t *testing.T) {
-	originalRunCopilotAsync := runCopilotAsync
+	originalRunAgentAsync := runAgentAsync
 	originalInterval := reviewStatusInterval
 	t.Cleanup(func() {
-		runCopilotAsync = originalRunCopilotAsync
+		runAgentAsync = originalRunAgentAsync
 		reviewStatusInterval = originalInterval
 	})
 
 	reviewStatusInterval = 10 * time.Millisecond
 
-	runCopilotAsync = func(_ string, args ...string) <-chan copilotResult {
-		ch := make(chan copilotResult, 1)
-		ch <- copilotResult{output: "", err: errors.New("boom")}
+	runAgentAsync = func(_, _ string, args ...string) <-chan agentResult {
+		ch := make(chan agentResult, 1)
+		ch <- agentResult{output: "", err: errors.New("boom")}
 		return ch
 	}
 
@@ -156,23 +156,35 @@ func TestReviewPropagatesCopilotErrors(t *testing.T) {
 	// Other errors (e.g. branch detection) are fine in test env
 }
 
-func TestReadLatestStatus(t *testing.T) {
+func TestPrintStatusEventsPrintsEveryNewEvent(t *testing.T) {
 	dir := t.TempDir()
 	path := dir + "/status.json"
 
-	msg := readLatestStatus(path, "test-branch")
-	if msg != "" {
-		t.Fatalf("expected empty, got %q", msg)
+	var out strings.Builder
+	seen := printStatusEvents(&out, path, "test-branch", 0)
+	if seen != 0 || out.Len() != 0 {
+		t.Fatalf("no history: seen = %d, out = %q", seen, out.String())
 	}
 
-	_, err := status.SetStatus(path, "test-branch", "Review: running reviewers")
-	if err != nil {
-		t.Fatal(err)
+	// Several phases between two polls are all printed, in order.
+	for _, msg := range []string{"Review: running reviewers", "Review: adjudicating findings", "Review: finalizing"} {
+		if _, err := status.SetStatus(path, "test-branch", msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	seen = printStatusEvents(&out, path, "test-branch", seen)
+	want := "⟳ Review: running reviewers\n⟳ Review: adjudicating findings\n⟳ Review: finalizing\n"
+	if seen != 3 || out.String() != want {
+		t.Fatalf("seen = %d, out = %q; want 3, %q", seen, out.String(), want)
 	}
 
-	msg = readLatestStatus(path, "test-branch")
-	if msg != "Review: running reviewers" {
-		t.Fatalf("got %q, want %q", msg, "Review: running reviewers")
+	out.Reset()
+	if _, err := status.SetPR(path, "test-branch", "https://github.com/acme/repo/pull/3"); err != nil {
+		t.Fatal(err)
+	}
+	seen = printStatusEvents(&out, path, "test-branch", seen)
+	if seen != 4 || out.String() != "⟳ PR: https://github.com/acme/repo/pull/3\n" {
+		t.Fatalf("seen = %d, out = %q; want only the new PR event", seen, out.String())
 	}
 }
 
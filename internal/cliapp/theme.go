@@ -0,0 +1,112 @@
+package cliapp
+
+import (
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"fitz/internal/status"
+)
+
+// tuiTheme is the set of styles the TUIs render with.
+type tuiTheme struct {
+	selected lipgloss.Style // the row under the cursor
+	dim      lipgloss.Style // secondary text
+	prompt   lipgloss.Style // prompts and group headings
+	current  lipgloss.Style // the worktree you are in
+	error    lipgloss.Style // failures and warnings
+	success  lipgloss.Style // finished work
+}
+
+func colorTheme(selected, dim, prompt, current, error, success lipgloss.TerminalColor) tuiTheme {
+	return tuiTheme{
+		selected: lipgloss.NewStyle().Foreground(selected).Bold(true),
+		dim:      lipgloss.NewStyle().Foreground(dim),
+		prompt:   lipgloss.NewStyle().Foreground(prompt),
+		current:  lipgloss.NewStyle().Foreground(current),
+		error:    lipgloss.NewStyle().Foreground(error),
+		success:  lipgloss.NewStyle().Foreground(success),
+	}
+}
+
+var (
+	darkTheme  = colorTheme(lipgloss.Color("212"), lipgloss.Color("241"), lipgloss.Color("229"), lipgloss.Color("33"), lipgloss.Color("196"), lipgloss.Color("42"))
+	lightTheme = colorTheme(lipgloss.Color("163"), lipgloss.Color("244"), lipgloss.Color("130"), lipgloss.Color("25"), lipgloss.Color("160"), lipgloss.Color("28"))
+
+	// autoTheme picks the dark or light color for the terminal background.
+	autoTheme = colorTheme(
+		lipgloss.AdaptiveColor{Light: "163", Dark: "212"},
+		lipgloss.AdaptiveColor{Light: "244", Dark: "241"},
+		lipgloss.AdaptiveColor{Light: "130", Dark: "229"},
+		lipgloss.AdaptiveColor{Light: "25", Dark: "33"},
+		lipgloss.AdaptiveColor{Light: "160", Dark: "196"},
+		lipgloss.AdaptiveColor{Light: "28", Dark: "42"},
+	)
+
+	// highContrastTheme keeps text in the terminal's own foreground color
+	// and marks rows with reverse video, bold and underline instead.
+	highContrastTheme = tuiTheme{
+		selected: lipgloss.NewStyle().Reverse(true).Bold(true),
+		dim:      lipgloss.NewStyle(),
+		prompt:   lipgloss.NewStyle().Bold(true),
+		current:  lipgloss.NewStyle().Underline(true),
+		error:    lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true),
+		success:  lipgloss.NewStyle().Bold(true),
+	}
+
+	// noColorTheme is used when NO_COLOR is set (https://no-color.org).
+	noColorTheme = tuiTheme{
+		selected: lipgloss.NewStyle().Reverse(true).Bold(true),
+		dim:      lipgloss.NewStyle(),
+		prompt:   lipgloss.NewStyle().Bold(true),
+		current:  lipgloss.NewStyle().Underline(true),
+		error:    lipgloss.NewStyle().Bold(true),
+		success:  lipgloss.NewStyle(),
+	}
+)
+
+// tuiThemes maps the config.Themes names to their styles.
+var tuiThemes = map[string]tuiTheme{
+	"auto":          autoTheme,
+	"dark":          darkTheme,
+	"light":         lightTheme,
+	"high-contrast": highContrastTheme,
+}
+
+var (
+	selectedStyle = autoTheme.selected
+	dimStyle      = autoTheme.dim
+	promptStyle   = autoTheme.prompt
+	currentStyle  = autoTheme.current
+	errorStyle    = autoTheme.error
+	successStyle  = autoTheme.success
+)
+
+// useTheme switches the TUI styles to the named theme, falling back to
+// "auto" for an empty or unknown name. NO_COLOR overrides the theme.
+func useTheme(name string) {
+	t, ok := tuiThemes[name]
+	if !ok {
+		t = autoTheme
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		t = noColorTheme
+	}
+	selectedStyle, dimStyle, promptStyle, currentStyle, errorStyle = t.selected, t.dim, t.prompt, t.current, t.error
+	successStyle = t.success
+}
+
+// stateStyle is the style of an agent state badge: states that need a
+// person stand out, finished ones are calm.
+func stateStyle(state string) lipgloss.Style {
+	switch state {
+	case status.StateBlocked, status.StateFailed:
+		return errorStyle.Bold(true)
+	case status.StateNeedsInput:
+		return promptStyle.Bold(true)
+	case status.StateDone:
+		return successStyle
+	default:
+		return currentStyle
+	}
+}
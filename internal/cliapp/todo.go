@@ -2,29 +2,169 @@ package cliapp
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"fitz/internal/runs"
+	"fitz/internal/status"
 	"fitz/internal/worktree"
 )
 
 var resolveTodoStorePath = resolveTodoPath
 
-func TodoAdd(_ context.Context, w io.Writer, text string) error {
+// runEditor opens path in the user's $VISUAL or $EDITOR and waits for it to
+// exit.
+var runEditor = func(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func TodoAdd(_ context.Context, text string) (TodoAddResult, error) {
 	storePath, err := resolveTodoStorePath()
 	if err != nil {
-		return err
+		return TodoAddResult{}, err
 	}
 
 	item, err := AddTodoItem(storePath, text)
 	if err != nil {
-		return fmt.Errorf("add todo: %w", err)
+		return TodoAddResult{}, fmt.Errorf("add todo: %w", err)
+	}
+	return TodoAddResult{item}, nil
+}
+
+// TodoEdit replaces the text of the todo with the given id. Without text it
+// opens the todo in an editor: the first line is the text (including any
+// priority and #tags) and everything after it is the notes.
+func TodoEdit(_ context.Context, w io.Writer, id, text string) error {
+	storePath, err := resolveTodoStorePath()
+	if err != nil {
+		return err
+	}
+
+	items, err := LoadTodos(storePath)
+	if err != nil {
+		return fmt.Errorf("load todos: %w", err)
+	}
+	idx := slices.IndexFunc(items, func(item TodoItem) bool { return item.ID == id })
+	if idx < 0 {
+		return fmt.Errorf("todo %q not found", id)
 	}
 
-	fmt.Fprintf(w, "added: %s (%s)\n", item.Text, item.ID)
+	notes := items[idx].Notes
+	if text == "" {
+		text, notes, err = editTodoInEditor(items[idx])
+		if err != nil {
+			return err
+		}
+		if text == "" {
+			return fmt.Errorf("todo text is empty, edit aborted")
+		}
+	}
+
+	item, err := UpdateTodoItem(storePath, id, func(item *TodoItem) {
+		item.setText(text)
+		item.Notes = notes
+	})
+	if err != nil {
+		return fmt.Errorf("update todo: %w", err)
+	}
+
+	fmt.Fprintf(w, "updated: %s (%s)\n", item.Text, item.ID)
+	return nil
+}
+
+func editTodoInEditor(item TodoItem) (string, string, error) {
+	f, err := os.CreateTemp("", "fitz-todo-*.md")
+	if err != nil {
+		return "", "", fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(formatTodoForEdit(item))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("write temp file: %w", err)
+	}
+
+	if err := runEditor(f.Name()); err != nil {
+		return "", "", fmt.Errorf("run editor: %w", err)
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", "", fmt.Errorf("read temp file: %w", err)
+	}
+	text, notes := parseTodoEdit(string(data))
+	return text, notes, nil
+}
+
+// formatTodoForEdit renders a todo as its text line, with priority and tags
+// written back inline, followed by a blank line and the notes.
+func formatTodoForEdit(item TodoItem) string {
+	line := item.Text
+	if item.Priority != "" {
+		line = item.Priority + " " + line
+	}
+	for _, tag := range item.Tags {
+		line += " #" + tag
+	}
+	return line + "\n\n" + item.Notes
+}
+
+func parseTodoEdit(content string) (string, string) {
+	text, notes, _ := strings.Cut(strings.TrimLeft(content, "\n"), "\n")
+	return strings.TrimSpace(text), strings.TrimSpace(notes)
+}
+
+// TodoListDone prints the archive of done todos, most recent first.
+func TodoListDone(_ context.Context, w io.Writer) error {
+	storePath, err := resolveTodoStorePath()
+	if err != nil {
+		return err
+	}
+
+	items, err := LoadTodos(TodoArchivePath(storePath))
+	if err != nil {
+		return fmt.Errorf("load done todos: %w", err)
+	}
+	if len(items) == 0 {
+		fmt.Fprintln(w, "No done todos.")
+		return nil
+	}
+
+	for i := len(items) - 1; i >= 0; i-- {
+		item := items[i]
+		completed := ""
+		if item.Completed != nil {
+			completed = item.Completed.Local().Format("2006-01-02")
+		}
+		line := fmt.Sprintf("%-10s  %s  %s", completed, item.ID, item.Text)
+		if item.Branch != "" {
+			line += " → " + item.Branch
+		}
+		fmt.Fprintln(w, line)
+	}
 	return nil
 }
 
@@ -44,7 +184,16 @@ func TodoList(_ context.Context, stdin io.Reader, stdout io.Writer) error {
 		return nil
 	}
 
+	cwd, _ := os.Getwd()
+	cfg := loadEffectiveConfig(cwd)
+	useTheme(cfg.Theme)
 	model := newTodoModel(items, storePath)
+	model.keys = newTodoKeyMap(cfg)
+	model.setAgent(agentDisplayName(cfg))
+	if statusPath, err := resolveAgentStatusStorePath(); err == nil {
+		model.statuses, _ = status.Load(statusPath)
+	}
+	model.loadPRState = func(prURL string) string { return pullRequestState(cwd, prURL) }
 	p := tea.NewProgram(model, tea.WithInput(stdin), tea.WithOutput(stdout))
 	finalModel, err := p.Run()
 	if err != nil {
@@ -58,14 +207,312 @@ func TodoList(_ context.Context, stdin io.Reader, stdout io.Writer) error {
 
 	switch m.result.Action {
 	case ActionGo:
-		return BrNew(context.Background(), stdout, m.result.BranchName, "", "")
+		return brNewFromTodo(stdout, storePath, m.selectedTodo, m.result.BranchName, "")
 	case ActionKickoff:
-		return BrNew(context.Background(), stdout, m.result.BranchName, "", m.result.Prompt)
+		// The prompt typed in the TUI stands in for the todo text; the
+		// issue link and notes still follow it.
+		item := m.selectedTodo
+		item.Text = m.result.Prompt
+		return brNewFromTodo(stdout, storePath, m.selectedTodo, m.result.BranchName, item.Prompt())
+	case ActionKickoffMarked:
+		return kickoffTodos(stdout, storePath, m.result.Items)
 	}
 
 	return nil
 }
 
+// brNewFromTodo links the todo to branch and then creates it. The link is
+// written first because `br new` without a prompt replaces this process with
+// the agent; it is cleared again if the worktree cannot be created.
+func brNewFromTodo(w io.Writer, storePath string, item TodoItem, branch, prompt string) error {
+	if err := LinkTodoBranch(storePath, item.ID, branch); err != nil {
+		return fmt.Errorf("link todo: %w", err)
+	}
+	if err := BrNew(context.Background(), w, branch, "", prompt); err != nil {
+		_ = LinkTodoBranch(storePath, item.ID, item.Branch)
+		return err
+	}
+	return nil
+}
+
+// TodoKickoff creates a worktree for each todo in ids and starts a
+// background agent in it with the todo text as the prompt.
+func TodoKickoff(_ context.Context, w io.Writer, ids []string) error {
+	storePath, err := resolveTodoStorePath()
+	if err != nil {
+		return err
+	}
+
+	all, err := LoadTodos(storePath)
+	if err != nil {
+		return fmt.Errorf("load todos: %w", err)
+	}
+
+	byID := make(map[string]TodoItem, len(all))
+	for _, item := range all {
+		byID[item.ID] = item
+	}
+	var items []TodoItem
+	for _, id := range ids {
+		item, ok := byID[id]
+		if !ok {
+			return fmt.Errorf("todo %q not found", id)
+		}
+		if item.Branch != "" {
+			return fmt.Errorf("todo %q is already linked to %s", id, item.Branch)
+		}
+		items = append(items, item)
+	}
+
+	return kickoffTodos(w, storePath, items)
+}
+
+// kickoffTodos creates one worktree per todo, named after its text, and
+// starts a background agent with the todo text as the prompt. Each todo is
+// linked to its branch as soon as the worktree exists; todos already linked
+// to a branch are skipped.
+func kickoffTodos(w io.Writer, storePath string, items []TodoItem) error {
+	var unlinked []TodoItem
+	for _, item := range items {
+		if item.Branch != "" {
+			fmt.Fprintf(w, "skipped: %q (already linked to %s)\n", item.Text, item.Branch)
+			continue
+		}
+		unlinked = append(unlinked, item)
+	}
+	items = unlinked
+	if len(items) == 0 {
+		return nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+
+	cfg := loadEffectiveConfig(cwd)
+	driver, err := resolveAgentDriver(cfg)
+	if err != nil {
+		return err
+	}
+	agentPath, err := lookPath(driver.Binary())
+	if err != nil {
+		return fmt.Errorf("%s not found in PATH", driver.Binary())
+	}
+
+	git := worktree.ShellGit{}
+	mgr := &worktree.Manager{Git: git}
+
+	_, _ = git.Run(cwd, "fetch", "origin")
+	base := "origin/" + detectDefaultBranch(git, cwd)
+
+	taken := make(map[string]bool)
+	if out, err := git.Run(cwd, "for-each-ref", "--format=%(refname:short)", "refs/heads"); err == nil {
+		for _, branch := range strings.Fields(out) {
+			taken[branch] = true
+		}
+	}
+
+	for _, item := range items {
+		name := todoBranchName(item.Text, taken)
+		taken[name] = true
+
+		path, err := mgr.Create(cwd, name, base)
+		if err != nil {
+			return fmt.Errorf("create worktree %s: %w", name, err)
+		}
+		recordRepo(cwd)
+		if err := LinkTodoBranch(storePath, item.ID, name); err != nil {
+			return fmt.Errorf("link todo: %w", err)
+		}
+		run, err := startAgentRun(runs.Run{
+			Branch: name,
+			Dir:    path,
+			Binary: agentPath,
+			Args:   driver.PromptArgs(cfg.Model, item.Prompt()),
+			Prompt: item.Prompt(),
+			Model:  cfg.Model,
+		})
+		if err != nil {
+			return fmt.Errorf("start %s in %s: %w", driver.Binary(), name, err)
+		}
+
+		state := "running"
+		if run.Queued {
+			state = "queued"
+		}
+		fmt.Fprintf(w, "worktree created: %s (%s) for %q\n", name, state, item.Text)
+	}
+
+	fmt.Fprintf(w, "%s is working on %d todos in the background\n", driver.Binary(), len(items))
+	return nil
+}
+
+// TodoImport adds open GitHub issues matching labels and assignee to the
+// todo list. Issues already in the list or the done archive are skipped, so
+// importing again only picks up new issues.
+func TodoImport(_ context.Context, w io.Writer, labels []string, assignee string) error {
+	storePath, err := resolveTodoStorePath()
+	if err != nil {
+		return err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+
+	args := []string{"issue", "list", "--state", "open", "--limit", "200", "--json", "number,title,body,url,labels"}
+	for _, label := range labels {
+		args = append(args, "--label", label)
+	}
+	if assignee != "" {
+		args = append(args, "--assignee", assignee)
+	}
+	out, err := runGh(cwd, args...)
+	if err != nil {
+		return fmt.Errorf("list issues: %w", err)
+	}
+	var issues []struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		Body   string `json:"body"`
+		URL    string `json:"url"`
+		Labels []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+	}
+	if err := json.Unmarshal([]byte(out), &issues); err != nil {
+		return fmt.Errorf("parse issues: %w", err)
+	}
+
+	var added []TodoItem
+	err = MutateTodos(storePath, func(items []TodoItem) ([]TodoItem, error) {
+		archived, err := LoadTodos(TodoArchivePath(storePath))
+		if err != nil {
+			return nil, fmt.Errorf("load done todos: %w", err)
+		}
+		known := make(map[int]bool)
+		for _, item := range append(slices.Clone(items), archived...) {
+			if item.Issue != 0 {
+				known[item.Issue] = true
+			}
+		}
+
+		for _, issue := range issues {
+			if known[issue.Number] {
+				continue
+			}
+			item := TodoItem{
+				ID:       shortID(),
+				Text:     strings.TrimSpace(issue.Title),
+				Created:  time.Now().UTC(),
+				Notes:    strings.TrimSpace(issue.Body),
+				Issue:    issue.Number,
+				IssueURL: issue.URL,
+			}
+			for _, label := range issue.Labels {
+				item.Tags = append(item.Tags, strings.ToLower(strings.ReplaceAll(label.Name, " ", "-")))
+			}
+			added = append(added, item)
+			known[issue.Number] = true
+		}
+		return append(items, added...), nil
+	})
+	if err != nil {
+		return fmt.Errorf("save todos: %w", err)
+	}
+
+	for _, item := range added {
+		fmt.Fprintf(w, "imported #%d: %s (%s)\n", item.Issue, item.Text, item.ID)
+	}
+	imported := len(added)
+	fmt.Fprintf(w, "imported %d issues (%d already in the todo list)\n", imported, len(issues)-imported)
+	return nil
+}
+
+// linkedIssue returns the GitHub issue number of the todo linked to branch,
+// or 0 if there is none.
+func linkedIssue(branch string) int {
+	storePath, err := resolveTodoStorePath()
+	if err != nil {
+		return 0
+	}
+	items, err := LoadTodos(storePath)
+	if err != nil {
+		return 0
+	}
+	for _, item := range items {
+		if item.Branch == branch && item.Issue != 0 {
+			return item.Issue
+		}
+	}
+	return 0
+}
+
+// completeMergedTodos marks todos linked to any of branches as done when the
+// branch's pull request has been merged, and returns them. It is
+// best-effort: removal has already happened, so lookup failures leave the
+// todos untouched.
+func completeMergedTodos(dir string, branches []string) []TodoItem {
+	todoPath, err := resolveTodoStorePath()
+	if err != nil {
+		return nil
+	}
+	items, err := LoadTodos(todoPath)
+	if err != nil {
+		return nil
+	}
+	linked := make(map[string]bool)
+	for _, item := range items {
+		if item.Branch != "" && item.Status() != TodoDone {
+			linked[item.Branch] = true
+		}
+	}
+
+	var statuses map[string]status.BranchStatus
+	var completed []TodoItem
+	for _, branch := range branches {
+		if !linked[branch] {
+			continue
+		}
+		if statuses == nil {
+			statusPath, err := resolveAgentStatusStorePath()
+			if err != nil {
+				return completed
+			}
+			if statuses, err = status.Load(statusPath); err != nil {
+				return completed
+			}
+		}
+		prURL := statuses[branch].PRURL
+		if prURL == "" || !pullRequestMerged(dir, prURL) {
+			continue
+		}
+		done, err := CompleteBranchTodos(todoPath, branch)
+		if err != nil {
+			continue
+		}
+		completed = append(completed, done...)
+	}
+	return completed
+}
+
+// pullRequestMerged reports whether gh says the pull request at prURL has
+// been merged.
+func pullRequestMerged(dir, prURL string) bool {
+	out, err := runGh(dir, "pr", "view", prURL, "--json", "state")
+	if err != nil {
+		return false
+	}
+	var pr struct {
+		State string `json:"state"`
+	}
+	if err := json.Unmarshal([]byte(out), &pr); err != nil {
+		return false
+	}
+	return pr.State == "MERGED"
+}
+
 func resolveTodoPath() (string, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
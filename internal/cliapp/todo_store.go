@@ -8,13 +8,114 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
 	"time"
+
+	"fitz/internal/fileutil"
+)
+
+// TodoState tracks a todo's progress from the list to a merged branch.
+type TodoState string
+
+const (
+	TodoOpen       TodoState = "open"
+	TodoInProgress TodoState = "in-progress"
+	TodoDone       TodoState = "done"
 )
 
 type TodoItem struct {
 	ID      string    `json:"id"`
 	Text    string    `json:"text"`
 	Created time.Time `json:"created"`
+	// Branch is the worktree branch spawned from this todo, if any.
+	Branch string    `json:"branch,omitempty"`
+	State  TodoState `json:"state,omitempty"`
+	// Priority is p0 (most urgent) to p3; empty sorts after p3.
+	Priority string   `json:"priority,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	// Notes is free-form detail appended to the kickoff prompt.
+	Notes     string     `json:"notes,omitempty"`
+	Completed *time.Time `json:"completed,omitempty"`
+	// Issue and IssueURL identify the GitHub issue the todo was imported
+	// from; the issue body is kept in Notes.
+	Issue    int    `json:"issue,omitempty"`
+	IssueURL string `json:"issue_url,omitempty"`
+}
+
+// Prompt returns the agent prompt for the todo: its text followed by the
+// issue it came from and any notes.
+func (t TodoItem) Prompt() string {
+	prompt := t.Text
+	if t.Issue != 0 {
+		prompt += fmt.Sprintf("\n\nGitHub issue #%d: %s", t.Issue, t.IssueURL)
+	}
+	if t.Notes != "" {
+		prompt += "\n\n" + t.Notes
+	}
+	return prompt
+}
+
+// priorityRank orders todos by priority, with unprioritised todos last.
+func (t TodoItem) priorityRank() int {
+	if len(t.Priority) == 2 && t.Priority[0] == 'p' && t.Priority[1] >= '0' && t.Priority[1] <= '3' {
+		return int(t.Priority[1] - '0')
+	}
+	return 4
+}
+
+// sortTodos orders items by priority, keeping creation order within a
+// priority.
+func sortTodos(items []TodoItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].priorityRank() < items[j].priorityRank()
+	})
+}
+
+var todoTagPattern = regexp.MustCompile(`^#[A-Za-z][\w-]*$`)
+
+// parseTodoText pulls a p0–p3 priority and #tags out of text and returns
+// the remaining words. Text made up only of markers is kept as is.
+func parseTodoText(text string) (string, string, []string) {
+	var words, tags []string
+	priority := ""
+	for _, word := range strings.Fields(text) {
+		lower := strings.ToLower(word)
+		switch {
+		case priority == "" && len(lower) == 2 && lower[0] == 'p' && lower[1] >= '0' && lower[1] <= '3':
+			priority = lower
+		case todoTagPattern.MatchString(word):
+			tag := strings.ToLower(word[1:])
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		default:
+			words = append(words, word)
+		}
+	}
+	if len(words) == 0 {
+		return strings.TrimSpace(text), "", nil
+	}
+	return strings.Join(words, " "), priority, tags
+}
+
+// setText replaces the todo's text, priority and tags from raw input.
+func (t *TodoItem) setText(raw string) {
+	t.Text, t.Priority, t.Tags = parseTodoText(raw)
+}
+
+// Status returns the todo's state, treating todos saved without one as open
+// or, when already linked to a branch, in progress.
+func (t TodoItem) Status() TodoState {
+	if t.State != "" {
+		return t.State
+	}
+	if t.Branch != "" {
+		return TodoInProgress
+	}
+	return TodoOpen
 }
 
 func TodoStorePath(homeDir, owner, repo string) (string, error) {
@@ -56,50 +157,183 @@ func SaveTodos(path string, items []TodoItem) error {
 	}
 	data = append(data, '\n')
 
-	if err := os.WriteFile(path, data, 0o644); err != nil {
+	if err := fileutil.WriteFile(path, data, 0o644); err != nil {
 		return fmt.Errorf("write todos: %w", err)
 	}
 	return nil
 }
 
-func AddTodoItem(path, text string) (TodoItem, error) {
+// MutateTodos applies fn to the todos at path while holding an exclusive
+// lock, and saves the todos it returns. The lock also covers the archive
+// next to path. Use it for any read-modify-write so that concurrent fitz
+// processes don't lose updates.
+func MutateTodos(path string, fn func([]TodoItem) ([]TodoItem, error)) error {
+	unlock, err := fileutil.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	items, err := LoadTodos(path)
 	if err != nil {
-		return TodoItem{}, err
+		return err
 	}
+	items, err = fn(items)
+	if err != nil {
+		return err
+	}
+	return SaveTodos(path, items)
+}
 
+func AddTodoItem(path, text string) (TodoItem, error) {
 	item := TodoItem{
 		ID:      shortID(),
-		Text:    text,
 		Created: time.Now().UTC(),
 	}
-	items = append(items, item)
+	item.setText(text)
 
-	if err := SaveTodos(path, items); err != nil {
+	err := MutateTodos(path, func(items []TodoItem) ([]TodoItem, error) {
+		return append(items, item), nil
+	})
+	if err != nil {
 		return TodoItem{}, err
 	}
 	return item, nil
 }
 
 func RemoveTodoItem(path, id string) error {
-	items, err := LoadTodos(path)
+	return MutateTodos(path, func(items []TodoItem) ([]TodoItem, error) {
+		idx := slices.IndexFunc(items, func(item TodoItem) bool { return item.ID == id })
+		if idx < 0 {
+			return nil, fmt.Errorf("todo %q not found", id)
+		}
+		return append(items[:idx], items[idx+1:]...), nil
+	})
+}
+
+// UpdateTodoItem applies fn to the todo with the given id and saves it.
+func UpdateTodoItem(path, id string, fn func(*TodoItem)) (TodoItem, error) {
+	var updated TodoItem
+	err := MutateTodos(path, func(items []TodoItem) ([]TodoItem, error) {
+		for i := range items {
+			if items[i].ID == id {
+				fn(&items[i])
+				updated = items[i]
+				return items, nil
+			}
+		}
+		return nil, fmt.Errorf("todo %q not found", id)
+	})
 	if err != nil {
-		return err
+		return TodoItem{}, err
 	}
+	return updated, nil
+}
 
-	idx := -1
-	for i, item := range items {
-		if item.ID == id {
-			idx = i
+// LinkTodoBranch records branch as the worktree spawned from the todo with
+// the given id and marks it in progress. An empty branch clears the link and
+// reopens the todo.
+func LinkTodoBranch(path, id, branch string) error {
+	_, err := UpdateTodoItem(path, id, func(item *TodoItem) {
+		item.Branch = branch
+		item.State = TodoInProgress
+		if branch == "" {
+			item.State = TodoOpen
+		}
+	})
+	return err
+}
+
+// TodoArchivePath returns the archive of done todos kept next to the todo
+// store at path.
+func TodoArchivePath(path string) string {
+	return filepath.Join(filepath.Dir(path), "todos-done.json")
+}
+
+// ArchiveTodoItem marks the todo with the given id done and moves it from
+// the store to the archive.
+func ArchiveTodoItem(path, id string) (TodoItem, error) {
+	done, err := archiveTodos(path, func(item TodoItem) bool { return item.ID == id })
+	if err != nil {
+		return TodoItem{}, err
+	}
+	if len(done) == 0 {
+		return TodoItem{}, fmt.Errorf("todo %q not found", id)
+	}
+	return done[0], nil
+}
+
+// CompleteBranchTodos archives every todo linked to branch as done and
+// returns the todos it moved.
+func CompleteBranchTodos(path, branch string) ([]TodoItem, error) {
+	return archiveTodos(path, func(item TodoItem) bool { return item.Branch == branch })
+}
+
+// archiveTodos moves the todos matching done to the archive. The archive is
+// written first so a failure part way leaves a duplicate rather than losing
+// the todo.
+func archiveTodos(path string, done func(TodoItem) bool) ([]TodoItem, error) {
+	var moved []TodoItem
+	err := MutateTodos(path, func(items []TodoItem) ([]TodoItem, error) {
+		var keep []TodoItem
+		now := time.Now().UTC()
+		for _, item := range items {
+			if !done(item) {
+				keep = append(keep, item)
+				continue
+			}
+			item.State = TodoDone
+			item.Completed = &now
+			moved = append(moved, item)
+		}
+		if len(moved) == 0 {
+			return items, nil
+		}
+
+		archivePath := TodoArchivePath(path)
+		archive, err := LoadTodos(archivePath)
+		if err != nil {
+			return nil, err
+		}
+		if err := SaveTodos(archivePath, append(archive, moved...)); err != nil {
+			return nil, err
+		}
+		return keep, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return moved, nil
+}
+
+// todoBranchName derives a branch name from todo text: lowercase words
+// joined by dashes, cut at a word boundary. A numeric suffix is added when
+// the name is already in taken.
+func todoBranchName(text string, taken map[string]bool) string {
+	const maxLen = 40
+
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}) {
+		if n := len(strings.Join(words, "-")); len(words) > 0 && n+1+len(word) > maxLen {
 			break
 		}
+		words = append(words, word)
+	}
+	name := strings.Join(words, "-")
+	if len(name) > maxLen {
+		name = name[:maxLen]
 	}
-	if idx < 0 {
-		return fmt.Errorf("todo %q not found", id)
+	if name == "" {
+		name = "todo"
 	}
 
-	items = append(items[:idx], items[idx+1:]...)
-	return SaveTodos(path, items)
+	candidate := name
+	for i := 2; taken[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+	return candidate
 }
 
 func shortID() string {
@@ -1,8 +1,11 @@
 package cliapp
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -107,6 +110,160 @@ func TestRemoveTodoItemNotFound(t *testing.T) {
 	}
 }
 
+func TestLinkTodoBranch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.json")
+
+	item, _ := AddTodoItem(path, "fix login")
+	if err := LinkTodoBranch(path, item.ID, "fix-login"); err != nil {
+		t.Fatalf("link error: %v", err)
+	}
+
+	loaded, err := LoadTodos(path)
+	if err != nil {
+		t.Fatalf("load error: %v", err)
+	}
+	if loaded[0].Branch != "fix-login" || loaded[0].Status() != TodoInProgress {
+		t.Fatalf("linked todo = %+v, want fix-login in progress", loaded[0])
+	}
+
+	if err := LinkTodoBranch(path, "nonexistent", "x"); err == nil {
+		t.Fatal("expected error for nonexistent ID")
+	}
+}
+
+func TestTodoStatusDefaults(t *testing.T) {
+	if got := (TodoItem{}).Status(); got != TodoOpen {
+		t.Fatalf("unlinked status = %q, want open", got)
+	}
+	if got := (TodoItem{Branch: "feat"}).Status(); got != TodoInProgress {
+		t.Fatalf("linked status = %q, want in-progress", got)
+	}
+}
+
+func TestCompleteBranchTodos(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.json")
+
+	a, _ := AddTodoItem(path, "a")
+	b, _ := AddTodoItem(path, "b")
+	_ = LinkTodoBranch(path, a.ID, "feat")
+	_ = LinkTodoBranch(path, b.ID, "other")
+
+	done, err := CompleteBranchTodos(path, "feat")
+	if err != nil {
+		t.Fatalf("complete error: %v", err)
+	}
+	if len(done) != 1 || done[0].ID != a.ID {
+		t.Fatalf("done = %+v, want only %s", done, a.ID)
+	}
+
+	loaded, _ := LoadTodos(path)
+	if len(loaded) != 1 || loaded[0].ID != b.ID {
+		t.Fatalf("remaining = %+v, want only %s", loaded, b.ID)
+	}
+	archived, _ := LoadTodos(TodoArchivePath(path))
+	if len(archived) != 1 || archived[0].Status() != TodoDone || archived[0].Completed == nil {
+		t.Fatalf("archive = %+v, want %s done", archived, a.ID)
+	}
+
+	if done, _ := CompleteBranchTodos(path, "feat"); len(done) != 0 {
+		t.Fatalf("second complete = %+v, want nothing", done)
+	}
+}
+
+func TestParseTodoText(t *testing.T) {
+	tests := []struct {
+		in       string
+		text     string
+		priority string
+		tags     string
+	}{
+		{"fix login", "fix login", "", ""},
+		{"P1 fix login #Auth #backend", "fix login", "p1", "auth,backend"},
+		{"fix #123 and p4 #ui #ui", "fix #123 and p4", "", "ui"},
+		{"p0 p2 ship it", "p2 ship it", "p0", ""},
+		{"#only", "#only", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			text, priority, tags := parseTodoText(tt.in)
+			if text != tt.text || priority != tt.priority || strings.Join(tags, ",") != tt.tags {
+				t.Fatalf("parseTodoText(%q) = %q, %q, %v; want %q, %q, %s", tt.in, text, priority, tags, tt.text, tt.priority, tt.tags)
+			}
+		})
+	}
+}
+
+func TestAddTodoItemParsesPriorityAndTags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.json")
+	item, err := AddTodoItem(path, "p2 tidy docs #docs")
+	if err != nil {
+		t.Fatalf("add error: %v", err)
+	}
+	if item.Text != "tidy docs" || item.Priority != "p2" || len(item.Tags) != 1 || item.Tags[0] != "docs" {
+		t.Fatalf("item = %+v", item)
+	}
+}
+
+func TestSortTodosByPriority(t *testing.T) {
+	items := []TodoItem{
+		{ID: "none"},
+		{ID: "p2a", Priority: "p2"},
+		{ID: "p0", Priority: "p0"},
+		{ID: "p2b", Priority: "p2"},
+	}
+	sortTodos(items)
+	var order []string
+	for _, item := range items {
+		order = append(order, item.ID)
+	}
+	if got, want := strings.Join(order, ","), "p0,p2a,p2b,none"; got != want {
+		t.Fatalf("order = %s, want %s", got, want)
+	}
+}
+
+func TestArchiveTodoItem(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.json")
+	item, _ := AddTodoItem(path, "ship it")
+
+	archived, err := ArchiveTodoItem(path, item.ID)
+	if err != nil {
+		t.Fatalf("archive error: %v", err)
+	}
+	if archived.Status() != TodoDone || archived.Completed == nil {
+		t.Fatalf("archived = %+v, want done with completion time", archived)
+	}
+	if loaded, _ := LoadTodos(path); len(loaded) != 0 {
+		t.Fatalf("store still has %d todos", len(loaded))
+	}
+	if done, _ := LoadTodos(TodoArchivePath(path)); len(done) != 1 || done[0].ID != item.ID {
+		t.Fatalf("archive = %+v", done)
+	}
+	if _, err := ArchiveTodoItem(path, item.ID); err == nil {
+		t.Fatal("expected error archiving a missing todo")
+	}
+}
+
+func TestTodoBranchName(t *testing.T) {
+	tests := []struct {
+		text  string
+		taken map[string]bool
+		want  string
+	}{
+		{"Fix the login bug", nil, "fix-the-login-bug"},
+		{"  Update README.md (docs)!  ", nil, "update-readme-md-docs"},
+		{"refactor the worktree manager so that removal handles locked worktrees", nil, "refactor-the-worktree-manager-so-that"},
+		{"!!!", nil, "todo"},
+		{"fix login", map[string]bool{"fix-login": true, "fix-login-2": true}, "fix-login-3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := todoBranchName(tt.text, tt.taken); got != tt.want {
+				t.Fatalf("todoBranchName(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestSaveTodosCreatesDir(t *testing.T) {
 	dir := t.TempDir()
 	nested := filepath.Join(dir, "a", "b", "todos.json")
@@ -131,3 +288,43 @@ func TestTodoStorePath(t *testing.T) {
 		t.Fatalf("path = %q, want %q", path, want)
 	}
 }
+
+func TestConcurrentTodoWritersLoseNoUpdates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.json")
+	const writers, adds = 8, 10
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < adds; i++ {
+				item, err := AddTodoItem(path, fmt.Sprintf("todo %d-%d", w, i))
+				if err != nil {
+					t.Errorf("add: %v", err)
+					return
+				}
+				// Archive every other todo, racing the other writers' adds.
+				if i%2 == 1 {
+					if _, err := ArchiveTodoItem(path, item.ID); err != nil {
+						t.Errorf("archive: %v", err)
+						return
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	items, err := LoadTodos(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	archived, err := LoadTodos(TodoArchivePath(path))
+	if err != nil {
+		t.Fatalf("load archive: %v", err)
+	}
+	if len(items) != writers*adds/2 || len(archived) != writers*adds/2 {
+		t.Fatalf("got %d todos and %d archived, want %d each (updates were lost)", len(items), len(archived), writers*adds/2)
+	}
+}
@@ -4,8 +4,13 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"fitz/internal/status"
 )
 
 func TestTodoAddWritesToStdout(t *testing.T) {
@@ -16,11 +21,12 @@ func TestTodoAddWritesToStdout(t *testing.T) {
 	storePath := dir + "/todos.json"
 	resolveTodoStorePath = func() (string, error) { return storePath, nil }
 
-	var out bytes.Buffer
-	err := TodoAdd(context.Background(), &out, "test todo")
+	result, err := TodoAdd(context.Background(), "test todo")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	var out bytes.Buffer
+	result.WriteText(&out)
 	if !strings.Contains(out.String(), "added:") {
 		t.Fatalf("stdout = %q, want 'added:'", out.String())
 	}
@@ -37,8 +43,7 @@ func TestTodoAddError(t *testing.T) {
 		return "", fmt.Errorf("identify repository: no git repo")
 	}
 
-	var out bytes.Buffer
-	err := TodoAdd(context.Background(), &out, "test todo")
+	_, err := TodoAdd(context.Background(), "test todo")
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -46,3 +51,275 @@ func TestTodoAddError(t *testing.T) {
 		t.Fatalf("error = %q, want it to mention 'repository'", err.Error())
 	}
 }
+
+func TestCompleteMergedTodos(t *testing.T) {
+	origTodo, origStatus, origGh := resolveTodoStorePath, resolveAgentStatusStorePath, runGh
+	t.Cleanup(func() {
+		resolveTodoStorePath, resolveAgentStatusStorePath, runGh = origTodo, origStatus, origGh
+	})
+
+	dir := t.TempDir()
+	todoPath := filepath.Join(dir, "todos.json")
+	statusPath := filepath.Join(dir, "status.json")
+	resolveTodoStorePath = func() (string, error) { return todoPath, nil }
+	resolveAgentStatusStorePath = func() (string, error) { return statusPath, nil }
+
+	merged, _ := AddTodoItem(todoPath, "merged work")
+	open, _ := AddTodoItem(todoPath, "open work")
+	_ = LinkTodoBranch(todoPath, merged.ID, "feat-merged")
+	_ = LinkTodoBranch(todoPath, open.ID, "feat-open")
+	_ = status.Save(statusPath, map[string]status.BranchStatus{
+		"feat-merged": {PRURL: "https://github.com/acme/repo/pull/7"},
+		"feat-open":   {PRURL: "https://github.com/acme/repo/pull/8"},
+	})
+
+	var ghCalls []string
+	runGh = func(dir string, args ...string) (string, error) {
+		ghCalls = append(ghCalls, strings.Join(args, " "))
+		if strings.Contains(strings.Join(args, " "), "/pull/7") {
+			return `{"state":"MERGED"}`, nil
+		}
+		return `{"state":"OPEN"}`, nil
+	}
+
+	done := completeMergedTodos(dir, []string{"feat-merged", "feat-open", "unlinked"})
+
+	if len(ghCalls) != 2 {
+		t.Fatalf("gh calls = %v, want one per linked branch", ghCalls)
+	}
+	if len(done) != 1 || done[0].ID != merged.ID || done[0].Branch != "feat-merged" {
+		t.Fatalf("completed = %+v, want the merged todo", done)
+	}
+
+	items, err := LoadTodos(todoPath)
+	if err != nil {
+		t.Fatalf("load error: %v", err)
+	}
+	if len(items) != 1 || items[0].Status() != TodoInProgress {
+		t.Fatalf("remaining todos = %+v, want only the open one in progress", items)
+	}
+	archived, _ := LoadTodos(TodoArchivePath(todoPath))
+	if len(archived) != 1 || archived[0].ID != merged.ID {
+		t.Fatalf("archive = %+v, want merged todo", archived)
+	}
+}
+
+func TestCleanupRemovedCompletesTodosBeforeForgetting(t *testing.T) {
+	origTodo, origStatus, origGh := resolveTodoStorePath, resolveAgentStatusStorePath, runGh
+	t.Cleanup(func() {
+		resolveTodoStorePath, resolveAgentStatusStorePath, runGh = origTodo, origStatus, origGh
+	})
+
+	dir := t.TempDir()
+	todoPath := filepath.Join(dir, "todos.json")
+	statusPath := filepath.Join(dir, "status.json")
+	resolveTodoStorePath = func() (string, error) { return todoPath, nil }
+	resolveAgentStatusStorePath = func() (string, error) { return statusPath, nil }
+
+	merged, _ := AddTodoItem(todoPath, "merged work")
+	_ = LinkTodoBranch(todoPath, merged.ID, "feat-merged")
+	_ = status.Save(statusPath, map[string]status.BranchStatus{
+		"feat-merged": {PRURL: "https://github.com/acme/repo/pull/7"},
+		"feat-other":  {Message: "still here"},
+	})
+	runGh = func(string, ...string) (string, error) { return `{"state":"MERGED"}`, nil }
+
+	done := cleanupRemoved(dir, []string{"feat-merged"})
+	if len(done) != 1 || done[0].ID != merged.ID {
+		t.Fatalf("completed = %+v, want the merged todo", done)
+	}
+	statuses, err := status.Load(statusPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := statuses["feat-merged"]; ok || len(statuses) != 1 {
+		t.Fatalf("statuses = %+v, want only feat-other left", statuses)
+	}
+}
+
+func TestTodoEditWithText(t *testing.T) {
+	orig := resolveTodoStorePath
+	t.Cleanup(func() { resolveTodoStorePath = orig })
+
+	storePath := filepath.Join(t.TempDir(), "todos.json")
+	resolveTodoStorePath = func() (string, error) { return storePath, nil }
+	item, _ := AddTodoItem(storePath, "old text")
+	_, _ = UpdateTodoItem(storePath, item.ID, func(i *TodoItem) { i.Notes = "keep me" })
+
+	var out bytes.Buffer
+	if err := TodoEdit(context.Background(), &out, item.ID, "p1 new text #api"); err != nil {
+		t.Fatalf("edit error: %v", err)
+	}
+
+	items, _ := LoadTodos(storePath)
+	got := items[0]
+	if got.Text != "new text" || got.Priority != "p1" || len(got.Tags) != 1 || got.Notes != "keep me" {
+		t.Fatalf("edited todo = %+v", got)
+	}
+	if !strings.Contains(out.String(), "updated: new text") {
+		t.Fatalf("stdout = %q", out.String())
+	}
+
+	if err := TodoEdit(context.Background(), &out, "missing", "x"); err == nil {
+		t.Fatal("expected error for missing todo")
+	}
+}
+
+func TestTodoEditInEditor(t *testing.T) {
+	origPath, origEditor := resolveTodoStorePath, runEditor
+	t.Cleanup(func() { resolveTodoStorePath, runEditor = origPath, origEditor })
+
+	storePath := filepath.Join(t.TempDir(), "todos.json")
+	resolveTodoStorePath = func() (string, error) { return storePath, nil }
+	item, _ := AddTodoItem(storePath, "p2 fix login #auth")
+
+	var seen string
+	runEditor = func(path string) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		seen = string(data)
+		return os.WriteFile(path, []byte("p0 fix login #auth\n\nSession cookies expire early.\nSee the auth middleware.\n"), 0o644)
+	}
+
+	if err := TodoEdit(context.Background(), io.Discard, item.ID, ""); err != nil {
+		t.Fatalf("edit error: %v", err)
+	}
+	if seen != "p2 fix login #auth\n\n" {
+		t.Fatalf("editor content = %q", seen)
+	}
+
+	items, _ := LoadTodos(storePath)
+	got := items[0]
+	if got.Priority != "p0" || got.Notes != "Session cookies expire early.\nSee the auth middleware." {
+		t.Fatalf("edited todo = %+v", got)
+	}
+	if want := "fix login\n\nSession cookies expire early.\nSee the auth middleware."; got.Prompt() != want {
+		t.Fatalf("prompt = %q, want %q", got.Prompt(), want)
+	}
+
+	runEditor = func(path string) error { return os.WriteFile(path, nil, 0o644) }
+	if err := TodoEdit(context.Background(), io.Discard, item.ID, ""); err == nil {
+		t.Fatal("expected error when the editor empties the todo")
+	}
+}
+
+func TestTodoListDone(t *testing.T) {
+	orig := resolveTodoStorePath
+	t.Cleanup(func() { resolveTodoStorePath = orig })
+
+	storePath := filepath.Join(t.TempDir(), "todos.json")
+	resolveTodoStorePath = func() (string, error) { return storePath, nil }
+
+	var out bytes.Buffer
+	if err := TodoListDone(context.Background(), &out); err != nil {
+		t.Fatalf("list error: %v", err)
+	}
+	if !strings.Contains(out.String(), "No done todos.") {
+		t.Fatalf("stdout = %q", out.String())
+	}
+
+	first, _ := AddTodoItem(storePath, "first")
+	second, _ := AddTodoItem(storePath, "second")
+	_, _ = ArchiveTodoItem(storePath, first.ID)
+	_, _ = ArchiveTodoItem(storePath, second.ID)
+
+	out.Reset()
+	if err := TodoListDone(context.Background(), &out); err != nil {
+		t.Fatalf("list error: %v", err)
+	}
+	if strings.Index(out.String(), "second") > strings.Index(out.String(), "first") {
+		t.Fatalf("stdout = %q, want most recent first", out.String())
+	}
+}
+
+func TestKickoffTodosSkipsLinkedTodos(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "todos.json")
+	items := []TodoItem{
+		{ID: "a", Text: "fix the bug", Branch: "fix-the-bug"},
+		{ID: "b", Text: "write docs", Branch: "docs"},
+	}
+
+	var out bytes.Buffer
+	if err := kickoffTodos(&out, storePath, items); err != nil {
+		t.Fatalf("kickoff error: %v", err)
+	}
+	for _, want := range []string{`skipped: "fix the bug" (already linked to fix-the-bug)`, `skipped: "write docs" (already linked to docs)`} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("stdout = %q, want %q", out.String(), want)
+		}
+	}
+	if strings.Contains(out.String(), "worktree created") {
+		t.Fatalf("linked todos should not get a worktree: %q", out.String())
+	}
+}
+
+func TestTodoImportDeduplicates(t *testing.T) {
+	origPath, origGh := resolveTodoStorePath, runGh
+	t.Cleanup(func() { resolveTodoStorePath, runGh = origPath, origGh })
+
+	storePath := filepath.Join(t.TempDir(), "todos.json")
+	resolveTodoStorePath = func() (string, error) { return storePath, nil }
+
+	var ghArgs []string
+	runGh = func(dir string, args ...string) (string, error) {
+		ghArgs = args
+		return `[
+			{"number": 12, "title": "Fix login", "body": "Cookies expire early.", "url": "https://github.com/acme/repo/issues/12", "labels": [{"name": "Good First Issue"}]},
+			{"number": 15, "title": "Add dark mode", "body": "", "url": "https://github.com/acme/repo/issues/15", "labels": []}
+		]`, nil
+	}
+
+	var out bytes.Buffer
+	if err := TodoImport(context.Background(), &out, []string{"bug"}, "@me"); err != nil {
+		t.Fatalf("import error: %v", err)
+	}
+	if got := strings.Join(ghArgs, " "); !strings.Contains(got, "--label bug") || !strings.Contains(got, "--assignee @me") {
+		t.Fatalf("gh args = %q, want label and assignee filters", got)
+	}
+	if !strings.Contains(out.String(), "imported 2 issues (0 already in the todo list)") {
+		t.Fatalf("stdout = %q", out.String())
+	}
+
+	items, _ := LoadTodos(storePath)
+	if len(items) != 2 {
+		t.Fatalf("got %d todos, want 2", len(items))
+	}
+	first := items[0]
+	if first.Issue != 12 || first.Text != "Fix login" || first.Tags[0] != "good-first-issue" {
+		t.Fatalf("imported todo = %+v", first)
+	}
+	if want := "Fix login\n\nGitHub issue #12: https://github.com/acme/repo/issues/12\n\nCookies expire early."; first.Prompt() != want {
+		t.Fatalf("prompt = %q, want %q", first.Prompt(), want)
+	}
+
+	// Issue 12 is done and archived; re-importing must not bring it back.
+	_, _ = ArchiveTodoItem(storePath, first.ID)
+	out.Reset()
+	if err := TodoImport(context.Background(), &out, nil, ""); err != nil {
+		t.Fatalf("re-import error: %v", err)
+	}
+	if !strings.Contains(out.String(), "imported 0 issues (2 already in the todo list)") {
+		t.Fatalf("stdout = %q", out.String())
+	}
+	if items, _ := LoadTodos(storePath); len(items) != 1 {
+		t.Fatalf("got %d todos after re-import, want 1", len(items))
+	}
+}
+
+func TestPublishPromptClosesLinkedIssue(t *testing.T) {
+	orig := resolveTodoStorePath
+	t.Cleanup(func() { resolveTodoStorePath = orig })
+
+	storePath := filepath.Join(t.TempDir(), "todos.json")
+	resolveTodoStorePath = func() (string, error) { return storePath, nil }
+	_ = SaveTodos(storePath, []TodoItem{{ID: "a", Text: "Fix login", Issue: 12, Branch: "fix-login"}})
+
+	if got := publishPrompt("fix-login"); !strings.Contains(got, `"Closes #12"`) {
+		t.Fatalf("prompt = %q, want Closes #12", got)
+	}
+	if got := publishPrompt("other"); got != "Create a PR for this branch" {
+		t.Fatalf("prompt = %q, want plain prompt", got)
+	}
+}
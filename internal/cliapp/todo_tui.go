@@ -3,17 +3,16 @@ package cliapp
 import (
 	"fmt"
 	"math/rand"
+	"slices"
+	"sort"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
-)
 
-var (
-	selectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("212")).Bold(true)
-	dimStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
-	promptStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("229"))
+	"fitz/internal/config"
+	"fitz/internal/status"
 )
 
 const (
@@ -27,9 +26,10 @@ const (
 type TodoAction int
 
 const (
-	ActionNone    TodoAction = iota
-	ActionGo                 // create worktree + interactive copilot
-	ActionKickoff            // create worktree + background copilot with prompt
+	ActionNone          TodoAction = iota
+	ActionGo                       // create worktree + interactive agent
+	ActionKickoff                  // create worktree + background agent with prompt
+	ActionKickoffMarked            // create a worktree + background agent per marked todo
 )
 
 // TodoResult carries the user's selection out of the TUI.
@@ -37,15 +37,37 @@ type TodoResult struct {
 	Action     TodoAction
 	BranchName string
 	Prompt     string
+	Items      []TodoItem // marked todos for ActionKickoffMarked
 }
 
 type todoModel struct {
-	items    []TodoItem
+	all      []TodoItem // every todo, sorted by priority
+	items    []TodoItem // the todos shown, after the tag filter
 	cursor   int
 	path     string
 	removed  []string
 	quitting bool
 	state    int
+	marked   map[string]bool // todo IDs marked for batch kickoff
+	notice   string          // banner above the list, cleared by the next key
+
+	// tag filter; empty shows every todo
+	tagFilter string
+
+	// branch status for linked todos, keyed by branch name
+	statuses map[string]status.BranchStatus
+
+	// state of the linked PRs (open, merged, …), keyed by URL and looked up
+	// in the background when the list opens
+	prStates    map[string]string
+	loadPRState func(prURL string) string
+
+	// key bindings of the list, and whether its help footer lists them all
+	keys     todoKeyMap
+	showHelp bool
+
+	// display name of the configured agent, shown in prompts
+	agent string
 
 	// branch input state
 	selectedTodo TodoItem
@@ -76,13 +98,89 @@ func newTodoModel(items []TodoItem, path string) todoModel {
 	ai := textinput.New()
 	ai.Placeholder = "new todo text"
 	pi := textinput.New()
-	pi.Placeholder = "prompt for copilot"
-	return todoModel{items: items, path: path, branchInput: bi, addInput: ai, promptInput: pi, dissolving: -1}
+	m := todoModel{path: path, branchInput: bi, addInput: ai, promptInput: pi, marked: map[string]bool{}, prStates: map[string]string{}, keys: newTodoKeyMap(config.Config{}), dissolving: -1}
+	m.setAgent(agentDisplayName(config.Config{}))
+	m.setItems(items)
+	return m
+}
+
+// setAgent names the agent that worktrees are opened with.
+func (m *todoModel) setAgent(name string) {
+	m.agent = name
+	m.promptInput.Placeholder = "prompt for " + name
+}
+
+// setItems replaces the todo list, sorting it by priority and reapplying the
+// tag filter.
+func (m *todoModel) setItems(items []TodoItem) {
+	m.all = slices.Clone(items)
+	sortTodos(m.all)
+	m.items = nil
+	for _, item := range m.all {
+		if m.tagFilter == "" || slices.Contains(item.Tags, m.tagFilter) {
+			m.items = append(m.items, item)
+		}
+	}
+	if m.cursor > len(m.items) {
+		m.cursor = len(m.items)
+	}
+}
+
+// nextTagFilter cycles through the tags in use, then back to no filter.
+func (m todoModel) nextTagFilter() string {
+	var tags []string
+	for _, item := range m.all {
+		for _, tag := range item.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	i := slices.Index(tags, m.tagFilter)
+	if i+1 < len(tags) {
+		return tags[i+1]
+	}
+	return ""
 }
 
-func (m todoModel) Init() tea.Cmd { return nil }
+func (m todoModel) Init() tea.Cmd { return m.loadPRStatesCmd() }
+
+// todoPRStateMsg carries the state of a linked PR.
+type todoPRStateMsg struct {
+	url   string
+	state string
+}
+
+// loadPRStatesCmd looks up the state of the PR of every linked todo, one gh
+// call per PR, concurrently.
+func (m todoModel) loadPRStatesCmd() tea.Cmd {
+	if m.loadPRState == nil {
+		return nil
+	}
+	var cmds []tea.Cmd
+	for _, item := range m.all {
+		url := m.statuses[item.Branch].PRURL
+		if item.Branch == "" || url == "" {
+			continue
+		}
+		if _, seen := m.prStates[url]; seen {
+			continue
+		}
+		m.prStates[url] = "" // pending
+		load := m.loadPRState
+		cmds = append(cmds, func() tea.Msg {
+			return todoPRStateMsg{url: url, state: load(url)}
+		})
+	}
+	return tea.Batch(cmds...)
+}
 
 func (m todoModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(todoPRStateMsg); ok {
+		m.prStates[msg.url] = msg.state
+		return m, nil
+	}
 	switch m.state {
 	case stateList:
 		return m.updateList(msg)
@@ -103,16 +201,20 @@ func (m todoModel) updateList(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.dissolveFrame++
 			if m.dissolveFrame > dissolveFrames {
 				removed := m.items[m.dissolving]
+				m.dissolving = -1
+				m.dissolveFrame = 0
+				m.dissolveRng = nil
+				if _, err := ArchiveTodoItem(m.path, removed.ID); err != nil {
+					m.notice = fmt.Sprintf("could not mark %q done: %v", removed.Text, err)
+					return m, nil
+				}
+				delete(m.marked, removed.ID)
 				m.removed = append(m.removed, removed.Text)
-				m.items = append(m.items[:m.dissolving], m.items[m.dissolving+1:]...)
-				_ = RemoveTodoItem(m.path, removed.ID)
+				m.setItems(slices.DeleteFunc(m.all, func(item TodoItem) bool { return item.ID == removed.ID }))
 				if m.cursor >= len(m.items) && m.cursor > 0 {
 					m.cursor--
 				}
-				m.dissolving = -1
-				m.dissolveFrame = 0
-				m.dissolveRng = nil
-				if len(m.items) == 0 {
+				if len(m.all) == 0 {
 					m.quitting = true
 					return m, tea.Quit
 				}
@@ -127,12 +229,12 @@ func (m todoModel) updateList(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if m.adding {
 		switch msg := msg.(type) {
 		case tea.KeyMsg:
-			switch msg.String() {
-			case "esc":
+			switch {
+			case key.Matches(msg, m.keys.Back):
 				m.adding = false
 				m.addInput.Blur()
 				return m, nil
-			case "enter":
+			case key.Matches(msg, m.keys.Select):
 				text := strings.TrimSpace(m.addInput.Value())
 				if text == "" {
 					m.adding = false
@@ -142,7 +244,7 @@ func (m todoModel) updateList(msg tea.Msg) (tea.Model, tea.Cmd) {
 				_, _ = AddTodoItem(m.path, text)
 				items, err := LoadTodos(m.path)
 				if err == nil {
-					m.items = items
+					m.setItems(items)
 				}
 				m.adding = false
 				m.addInput.Blur()
@@ -158,26 +260,58 @@ func (m todoModel) updateList(msg tea.Msg) (tea.Model, tea.Cmd) {
 	totalRows := len(m.items) + 1 // +1 for "add new" virtual row
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "q", "esc", "ctrl+c":
+		m.notice = ""
+		switch {
+		case msg.String() == "ctrl+c", key.Matches(msg, m.keys.Quit):
 			m.quitting = true
 			return m, tea.Quit
-		case "up", "k":
+		case key.Matches(msg, m.keys.Help):
+			m.showHelp = !m.showHelp
+		case key.Matches(msg, m.keys.Up):
 			if m.cursor > 0 {
 				m.cursor--
 			}
-		case "down", "j":
+		case key.Matches(msg, m.keys.Down):
 			if m.cursor < totalRows-1 {
 				m.cursor++
 			}
-		case "d":
+		case key.Matches(msg, m.keys.Mark):
+			if m.cursor < len(m.items) {
+				id := m.items[m.cursor].ID
+				if m.marked[id] {
+					delete(m.marked, id)
+				} else {
+					m.marked[id] = true
+				}
+				if m.cursor < totalRows-1 {
+					m.cursor++
+				}
+			}
+		case key.Matches(msg, m.keys.Tag):
+			m.tagFilter = m.nextTagFilter()
+			m.cursor = 0
+			m.setItems(m.all)
+		case key.Matches(msg, m.keys.Kickoff):
+			var items []TodoItem
+			for _, item := range m.all {
+				if m.marked[item.ID] {
+					items = append(items, item)
+				}
+			}
+			if len(items) > 0 {
+				m.result.Action = ActionKickoffMarked
+				m.result.Items = items
+				m.quitting = true
+				return m, tea.Quit
+			}
+		case key.Matches(msg, m.keys.Done):
 			if m.cursor < len(m.items) && len(m.items) > 0 {
 				m.dissolving = m.cursor
 				m.dissolveFrame = 1
 				m.dissolveRng = rand.New(rand.NewSource(int64(len(m.items[m.cursor].Text))))
 				return m, dissolveTickCmd()
 			}
-		case "enter":
+		case key.Matches(msg, m.keys.Go):
 			if m.cursor == len(m.items) {
 				// activate inline add input
 				m.addInput.SetValue("")
@@ -202,12 +336,12 @@ func (m todoModel) updateList(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m todoModel) updateBranchInput(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "esc":
+		switch {
+		case key.Matches(msg, m.keys.Back):
 			m.state = stateList
 			m.branchInput.Blur()
 			return m, nil
-		case "enter":
+		case key.Matches(msg, m.keys.Select):
 			name := strings.TrimSpace(m.branchInput.Value())
 			if name == "" {
 				return m, nil
@@ -227,20 +361,20 @@ func (m todoModel) updateBranchInput(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m todoModel) updateActionChoice(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "esc":
+		switch {
+		case key.Matches(msg, m.keys.Back):
 			m.branchInput.Focus()
 			m.state = stateBranchInput
 			return m, m.branchInput.Cursor.BlinkCmd()
-		case "up", "k":
+		case key.Matches(msg, m.keys.Up):
 			if m.actionCursor > 0 {
 				m.actionCursor--
 			}
-		case "down", "j":
+		case key.Matches(msg, m.keys.Down):
 			if m.actionCursor < 1 {
 				m.actionCursor++
 			}
-		case "enter":
+		case key.Matches(msg, m.keys.Select):
 			if m.actionCursor == 0 {
 				m.result.Action = ActionGo
 				m.quitting = true
@@ -251,7 +385,7 @@ func (m todoModel) updateActionChoice(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.promptInput.Focus()
 			m.state = statePromptInput
 			return m, m.promptInput.Cursor.BlinkCmd()
-		case "q", "ctrl+c":
+		case msg.String() == "ctrl+c", key.Matches(msg, m.keys.Quit):
 			m.quitting = true
 			return m, tea.Quit
 		}
@@ -283,12 +417,21 @@ func (m todoModel) View() string {
 }
 
 func (m todoModel) viewList() string {
-	if len(m.items) == 0 {
+	if len(m.all) == 0 {
 		return "No todos.\n"
 	}
 
 	var b strings.Builder
-	b.WriteString("Todos (↑/↓ navigate, enter create worktree, d done, q quit)\n\n")
+	b.WriteString("Todos\n")
+	if m.tagFilter != "" {
+		b.WriteString(promptStyle.Render(fmt.Sprintf("Showing #%s (%d of %d)", m.tagFilter, len(m.items), len(m.all))))
+		b.WriteString("\n")
+	}
+	if m.notice != "" {
+		b.WriteString(errorStyle.Render(m.notice))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
 
 	for i, item := range m.items {
 		cursor := "  "
@@ -304,7 +447,34 @@ func (m todoModel) viewList() string {
 			rng := rand.New(rand.NewSource(m.dissolveRng.Int63()))
 			displayText = dissolveText(item.Text, m.dissolveFrame, dissolveFrames, rng)
 		}
-		b.WriteString(style.Render(fmt.Sprintf("%s%s", cursor, displayText)))
+		mark := "  "
+		if m.marked[item.ID] {
+			mark = "✓ "
+		}
+		if item.Priority != "" {
+			displayText = item.Priority + " " + displayText
+		}
+		b.WriteString(style.Render(fmt.Sprintf("%s%s%s", cursor, mark, displayText)))
+		for _, tag := range item.Tags {
+			b.WriteString(dimStyle.Render(" #" + tag))
+		}
+		b.WriteString(dimStyle.Render(" " + item.ID))
+		if item.Issue != 0 {
+			b.WriteString(dimStyle.Render(fmt.Sprintf(" (issue #%d)", item.Issue)))
+		}
+		if item.Branch != "" {
+			link := " → " + item.Branch
+			if url := m.statuses[item.Branch].PRURL; url != "" {
+				link += " · " + formatPRLabel(url)
+				if state := m.prStates[url]; state != "" {
+					link += " " + state
+				}
+			}
+			b.WriteString(dimStyle.Render(link))
+		}
+		if state := item.Status(); state != TodoOpen {
+			b.WriteString(dimStyle.Render(fmt.Sprintf(" [%s]", state)))
+		}
 		b.WriteString("\n")
 	}
 
@@ -316,11 +486,13 @@ func (m todoModel) viewList() string {
 		addStyle = selectedStyle
 	}
 	if m.adding {
-		b.WriteString(addStyle.Render(fmt.Sprintf("%s+ ", addCursor)))
+		b.WriteString(addStyle.Render(fmt.Sprintf("%s  + ", addCursor)))
 		b.WriteString(m.addInput.View())
 	} else {
-		b.WriteString(addStyle.Render(fmt.Sprintf("%s+ Add new todo...", addCursor)))
+		b.WriteString(addStyle.Render(fmt.Sprintf("%s  + Add new todo...", addCursor)))
 	}
+	b.WriteString("\n\n")
+	b.WriteString(helpView(m.keys, 0, m.showHelp))
 	b.WriteString("\n")
 
 	return b.String()
@@ -332,7 +504,7 @@ func (m todoModel) viewBranchInput() string {
 	b.WriteString("\n\n")
 	b.WriteString(fmt.Sprintf("Branch name: %s", m.branchInput.View()))
 	b.WriteString("\n\n")
-	b.WriteString(dimStyle.Render("(enter confirm, esc back)"))
+	b.WriteString(dimStyle.Render(keyHints(keyHint(m.keys.Select, "confirm"), keyHint(m.keys.Back, "back"))))
 	b.WriteString("\n")
 	return b.String()
 }
@@ -346,8 +518,8 @@ func (m todoModel) viewActionChoice() string {
 		label string
 		desc  string
 	}{
-		{"Create and go", "(open Copilot interactively)"},
-		{"Create and kickoff", "(run Copilot in background)"},
+		{"Create and go", fmt.Sprintf("(open %s interactively)", m.agent)},
+		{"Create and kickoff", fmt.Sprintf("(run %s in background)", m.agent)},
 	}
 	for i, opt := range options {
 		cursor := "  "
@@ -360,7 +532,7 @@ func (m todoModel) viewActionChoice() string {
 		b.WriteString("\n")
 	}
 	b.WriteString("\n")
-	b.WriteString(dimStyle.Render("(enter select, esc back)"))
+	b.WriteString(dimStyle.Render(keyHints(keyHint(m.keys.Select, "select"), keyHint(m.keys.Back, "back"))))
 	b.WriteString("\n")
 	return b.String()
 }
@@ -371,7 +543,7 @@ func (m todoModel) viewPromptInput() string {
 	b.WriteString("\n\n")
 	b.WriteString(fmt.Sprintf("Prompt: %s", m.promptInput.View()))
 	b.WriteString("\n\n")
-	b.WriteString(dimStyle.Render("(enter confirm, esc back)"))
+	b.WriteString(dimStyle.Render(keyHints(keyHint(m.keys.Select, "confirm"), keyHint(m.keys.Back, "back"))))
 	b.WriteString("\n")
 	return b.String()
 }
@@ -379,12 +551,12 @@ func (m todoModel) viewPromptInput() string {
 func (m todoModel) updatePromptInput(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "esc":
+		switch {
+		case key.Matches(msg, m.keys.Back):
 			m.state = stateActionChoice
 			m.promptInput.Blur()
 			return m, nil
-		case "enter":
+		case key.Matches(msg, m.keys.Select):
 			prompt := strings.TrimSpace(m.promptInput.Value())
 			if prompt == "" {
 				return m, nil
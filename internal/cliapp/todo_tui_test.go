@@ -1,10 +1,14 @@
 package cliapp
 
 import (
+	"strings"
 	"testing"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+
+	"fitz/internal/config"
+	"fitz/internal/status"
 )
 
 func TestDissolveDKeyStartsAnimation(t *testing.T) {
@@ -65,6 +69,74 @@ func TestDissolveAnimationCompletesRemoval(t *testing.T) {
 	}
 }
 
+func TestTodoListShowsPRState(t *testing.T) {
+	items := []TodoItem{
+		{ID: "a", Text: "fix login", Branch: "fix-login"},
+		{ID: "b", Text: "add auth", Branch: "feat/auth"},
+		{ID: "c", Text: "write docs"},
+	}
+	m := newTodoModel(items, t.TempDir()+"/todos.json")
+	m.statuses = map[string]status.BranchStatus{
+		"fix-login": {PRURL: "https://github.com/acme/api/pull/7"},
+		"feat/auth": {PRURL: "https://github.com/acme/api/pull/9"},
+	}
+	var looked []string
+	m.loadPRState = func(url string) string {
+		looked = append(looked, url)
+		if strings.HasSuffix(url, "/7") {
+			return "merged"
+		}
+		return "open"
+	}
+
+	cmd := m.Init()
+	if cmd == nil {
+		t.Fatal("expected the list to look up PR states")
+	}
+	var updated tea.Model = m
+	for _, msg := range cmd().(tea.BatchMsg) {
+		updated, _ = updated.Update(msg())
+	}
+	if len(looked) != 2 {
+		t.Fatalf("looked up %v", looked)
+	}
+	view := updated.View()
+	if !strings.Contains(view, "PR #7 merged") || !strings.Contains(view, "PR #9 open") {
+		t.Fatalf("view missing PR states:\n%s", view)
+	}
+}
+
+func TestDissolveKeepsTodoWhenArchiveFails(t *testing.T) {
+	items := []TodoItem{
+		{ID: "a", Text: "first", Created: time.Now()},
+		{ID: "b", Text: "second", Created: time.Now()},
+	}
+	// The store does not hold the todos, so archiving them fails.
+	m := newTodoModel(items, t.TempDir()+"/todos.json")
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	model := updated.(todoModel)
+	for i := 0; i < dissolveFrames; i++ {
+		updated, _ = model.Update(dissolveTickMsg{})
+		model = updated.(todoModel)
+	}
+
+	if model.dissolving != -1 {
+		t.Fatalf("dissolving = %d, want -1 after animation", model.dissolving)
+	}
+	if len(model.items) != 2 || len(model.removed) != 0 {
+		t.Fatalf("items = %d, removed = %v; want the todo kept", len(model.items), model.removed)
+	}
+	if view := model.View(); !strings.Contains(view, `could not mark "first" done`) {
+		t.Fatalf("view should show the error:\n%s", view)
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if strings.Contains(updated.View(), "could not mark") {
+		t.Fatal("the next key should clear the error")
+	}
+}
+
 func TestDissolveIgnoresKeypressesDuringAnimation(t *testing.T) {
 	items := []TodoItem{
 		{ID: "a", Text: "first", Created: time.Now()},
@@ -216,3 +288,181 @@ func TestDissolveLastItemQuitsAfterAnimation(t *testing.T) {
 		t.Fatal("expected quit cmd")
 	}
 }
+
+func TestSpaceMarksAndKKicksOffMarked(t *testing.T) {
+	items := []TodoItem{
+		{ID: "a", Text: "first", Created: time.Now()},
+		{ID: "b", Text: "second", Created: time.Now()},
+		{ID: "c", Text: "third", Created: time.Now()},
+	}
+	m := newTodoModel(items, t.TempDir()+"/todos.json")
+
+	// K with nothing marked does nothing.
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'K'}})
+	model := updated.(todoModel)
+	if model.quitting {
+		t.Fatal("K with no marked todos should not quit")
+	}
+
+	// Mark "a" (cursor advances), skip "b", mark "c".
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	model = updated.(todoModel)
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	model = updated.(todoModel)
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	model = updated.(todoModel)
+	if !model.marked["a"] || model.marked["b"] || !model.marked["c"] {
+		t.Fatalf("marked = %v, want a and c", model.marked)
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'K'}})
+	model = updated.(todoModel)
+	if !model.quitting || model.result.Action != ActionKickoffMarked {
+		t.Fatalf("action = %d, quitting = %v; want ActionKickoffMarked", model.result.Action, model.quitting)
+	}
+	if len(model.result.Items) != 2 || model.result.Items[0].ID != "a" || model.result.Items[1].ID != "c" {
+		t.Fatalf("items = %+v, want a and c", model.result.Items)
+	}
+}
+
+func TestSpaceUnmarks(t *testing.T) {
+	items := []TodoItem{{ID: "a", Text: "first", Created: time.Now()}}
+	m := newTodoModel(items, t.TempDir()+"/todos.json")
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	model := updated.(todoModel)
+	model.cursor = 0
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	model = updated.(todoModel)
+	if model.marked["a"] {
+		t.Fatal("expected second space to unmark the todo")
+	}
+}
+
+func TestListShowsLinkedBranch(t *testing.T) {
+	items := []TodoItem{{ID: "a", Text: "first", Created: time.Now(), Branch: "first-branch", State: TodoDone}}
+	m := newTodoModel(items, t.TempDir()+"/todos.json")
+	m.statuses = map[string]status.BranchStatus{
+		"first-branch": {PRURL: "https://github.com/acme/repo/pull/12"},
+	}
+	view := m.View()
+	for _, want := range []string{"first-branch", "PR #12", "[done]"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("view = %q, want %q", view, want)
+		}
+	}
+}
+
+func TestTodoListSortsAndFiltersByTag(t *testing.T) {
+	items := []TodoItem{
+		{ID: "a", Text: "later", Tags: []string{"ui"}},
+		{ID: "b", Text: "urgent", Priority: "p0", Tags: []string{"api"}},
+		{ID: "c", Text: "soon", Priority: "p1", Tags: []string{"ui"}},
+	}
+	m := newTodoModel(items, t.TempDir()+"/todos.json")
+	if m.items[0].ID != "b" || m.items[1].ID != "c" || m.items[2].ID != "a" {
+		t.Fatalf("items not sorted by priority: %+v", m.items)
+	}
+
+	// t cycles api -> ui -> no filter.
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	model := updated.(todoModel)
+	if model.tagFilter != "api" || len(model.items) != 1 || model.items[0].ID != "b" {
+		t.Fatalf("filter %q items %+v, want only b", model.tagFilter, model.items)
+	}
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	model = updated.(todoModel)
+	if model.tagFilter != "ui" || len(model.items) != 2 {
+		t.Fatalf("filter %q items %+v, want c and a", model.tagFilter, model.items)
+	}
+	if view := model.View(); !strings.Contains(view, "Showing #ui (2 of 3)") {
+		t.Fatalf("view = %q, want filter line", view)
+	}
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	model = updated.(todoModel)
+	if model.tagFilter != "" || len(model.items) != 3 {
+		t.Fatalf("filter %q items %d, want everything", model.tagFilter, len(model.items))
+	}
+}
+
+func TestDissolveArchivesTodo(t *testing.T) {
+	items := []TodoItem{
+		{ID: "a", Text: "first", Created: time.Now()},
+		{ID: "b", Text: "second", Created: time.Now()},
+	}
+	path := t.TempDir() + "/todos.json"
+	_ = SaveTodos(path, items)
+	m := newTodoModel(items, path)
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	model := updated.(todoModel)
+	for i := 0; i < dissolveFrames; i++ {
+		updated, _ = model.Update(dissolveTickMsg{})
+		model = updated.(todoModel)
+	}
+
+	archived, err := LoadTodos(TodoArchivePath(path))
+	if err != nil {
+		t.Fatalf("load archive: %v", err)
+	}
+	if len(archived) != 1 || archived[0].ID != "a" || archived[0].Status() != TodoDone {
+		t.Fatalf("archive = %+v, want a done", archived)
+	}
+}
+
+func TestTodoCustomKeyBindings(t *testing.T) {
+	items := []TodoItem{{ID: "a", Text: "first"}, {ID: "b", Text: "second"}}
+	m := newTodoModel(items, t.TempDir()+"/todos.json")
+	m.keys = newTodoKeyMap(config.Config{KeyBindings: map[string]string{"todo.done": "x", "todo.quit": "Q"}})
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	if got := updated.(todoModel); got.dissolving != -1 {
+		t.Fatal("d should do nothing once done is rebound")
+	}
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
+	if got := updated.(todoModel); got.quitting {
+		t.Fatal("q should not quit once quit is rebound")
+	}
+	if view := updated.View(); !strings.Contains(view, "x done") || !strings.Contains(view, "Q quit") {
+		t.Fatalf("help footer should show the rebound keys:\n%s", view)
+	}
+
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	if got := updated.(todoModel); got.dissolving != 0 {
+		t.Fatalf("x should start the done animation, dissolving = %d", got.dissolving)
+	}
+}
+
+func TestTodoCustomPromptKeyBindings(t *testing.T) {
+	items := []TodoItem{{ID: "a", Text: "fix the bug"}}
+	m := newTodoModel(items, t.TempDir()+"/todos.json")
+	m.keys = newTodoKeyMap(config.Config{KeyBindings: map[string]string{"todo.select": "tab", "todo.back": "ctrl+b", "todo.down": "s"}})
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model := updated.(todoModel)
+	model.branchInput.SetValue("fix-the-bug")
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := updated.(todoModel).state; got != stateBranchInput {
+		t.Fatalf("enter should not confirm once select is rebound: state = %d", got)
+	}
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if got := updated.(todoModel).state; got != stateActionChoice {
+		t.Fatalf("tab: state = %d, want action choice", got)
+	}
+	if view := updated.View(); !strings.Contains(view, "(tab select, ctrl+b back)") {
+		t.Fatalf("action choice should show the rebound keys:\n%s", view)
+	}
+
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	if got := updated.(todoModel).actionCursor; got != 0 {
+		t.Fatalf("j should not move once down is rebound, cursor = %d", got)
+	}
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	if got := updated.(todoModel).actionCursor; got != 1 {
+		t.Fatalf("s: cursor = %d, want 1", got)
+	}
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyCtrlB})
+	if got := updated.(todoModel).state; got != stateBranchInput {
+		t.Fatalf("ctrl+b: state = %d, want branch input", got)
+	}
+}
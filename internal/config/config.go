@@ -6,6 +6,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"fitz/internal/fileutil"
 )
 
 // Config holds fitz user configuration. Zero values mean "not set".
@@ -14,12 +19,106 @@ type Config struct {
 	Agent              string `json:"agent,omitempty"`
 	BranchOpenMode     string `json:"branch_open_mode,omitempty"`
 	BranchZellijLayout string `json:"branch_zellij_layout,omitempty"`
+
+	// Command-template agent settings, used when Agent is "command".
+	AgentCommand    string `json:"agent_command,omitempty"`
+	AgentModelArgs  string `json:"agent_model_args,omitempty"`
+	AgentPromptArgs string `json:"agent_prompt_args,omitempty"`
+
+	// MaxConcurrentAgents caps how many background agents run at once per
+	// repo; further kickoffs are queued. Empty or "0" means no limit.
+	MaxConcurrentAgents string `json:"max_concurrent_agents,omitempty"`
+
+	// BrActiveHours is how recent agent activity must be for a worktree to
+	// count as active in the br TUI's active-only view. Empty means
+	// DefaultActiveHours.
+	BrActiveHours string `json:"br_active_hours,omitempty"`
+
+	// Theme is the TUI color theme, one of Themes. Empty means "auto".
+	Theme string `json:"theme,omitempty"`
+
+	// Notify is how `agent notify` reaches you, one of NotifySinks. Empty
+	// means "bell".
+	Notify string `json:"notify,omitempty"`
+
+	// KeyBindings overrides TUI key bindings, keyed by action as in
+	// DefaultKeyBindings. Repo bindings override global ones per action.
+	KeyBindings map[string]string `json:"keys,omitempty"`
+}
+
+// Agents lists the agent CLIs fitz can drive, one driver each.
+var Agents = []string{"copilot-cli", "claude", "codex", "command"}
+
+// Themes lists the built-in TUI color themes. "auto" picks light or dark
+// colors from the terminal background.
+var Themes = []string{"auto", "dark", "light", "high-contrast"}
+
+// NotifySinks lists the ways `agent notify` can notify: the terminal bell
+// (only outside Zellij, whose tab is renamed instead), the OS's desktop
+// notifications ("auto" picks "osascript" on macOS and "desktop" on other
+// Unix systems), terminal OSC 9 or OSC 777 escape sequences, or nothing.
+var NotifySinks = []string{"bell", "auto", "desktop", "osascript", "osc9", "osc777", "none"}
+
+// DefaultKeyBindings maps each TUI action, named "<tui>.<action>", to the
+// comma-separated keys that trigger it. "space" is the space bar.
+var DefaultKeyBindings = map[string]string{
+	"br.up":      "up,k",
+	"br.down":    "down,j",
+	"br.go":      "enter",
+	"br.delete":  "d",
+	"br.new":     "n",
+	"br.publish": "p",
+	"br.logs":    "l",
+	"br.details": "i",
+	"br.mark":    "space",
+	"br.stop":    "x",
+	"br.refresh": "r",
+	"br.filter":  "/",
+	"br.sort":    "s",
+	"br.active":  "a",
+	"br.help":    "?",
+	"br.quit":    "q,esc",
+	"br.select":  "enter",
+	"br.back":    "esc",
+	"br.yes":     "y",
+	"br.no":      "n,esc",
+	"br.force":   "f",
+
+	"todo.up":      "up,k",
+	"todo.down":    "down,j",
+	"todo.go":      "enter",
+	"todo.mark":    "space",
+	"todo.kickoff": "K",
+	"todo.tag":     "t",
+	"todo.done":    "d",
+	"todo.help":    "?",
+	"todo.quit":    "q,esc",
+	"todo.select":  "enter",
+	"todo.back":    "esc",
+
+	"ls.up":   "up,k",
+	"ls.down": "down,j",
+	"ls.go":   "enter",
+	"ls.quit": "q,esc",
+}
+
+// KeyBindingNames returns the TUI actions that can be rebound, sorted.
+func KeyBindingNames() []string {
+	names := make([]string, 0, len(DefaultKeyBindings))
+	for name := range DefaultKeyBindings {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
 }
 
-// DefaultConfig returns the hardcoded default configuration.
+// DefaultActiveHours is the br-active-hours value used when it is unset.
+const DefaultActiveHours = 4
+
+// DefaultConfig returns the hardcoded default configuration. Model is left
+// unset, so each agent starts with its own default model.
 func DefaultConfig() Config {
 	return Config{
-		Model:              "claude-opus-4.6",
 		Agent:              "copilot-cli",
 		BranchOpenMode:     "zellij",
 		BranchZellijLayout: "vertical",
@@ -78,12 +177,32 @@ func Save(path string, cfg Config) error {
 		return fmt.Errorf("marshal config: %w", err)
 	}
 
-	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+	if err := fileutil.WriteFile(path, append(data, '\n'), 0o644); err != nil {
 		return fmt.Errorf("write config %s: %w", path, err)
 	}
 	return nil
 }
 
+// Update applies fn to the config at path while holding an exclusive lock,
+// and saves the config it returns.
+func Update(path string, fn func(Config) (Config, error)) error {
+	unlock, err := fileutil.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cfg, err := Load(path)
+	if err != nil {
+		return err
+	}
+	cfg, err = fn(cfg)
+	if err != nil {
+		return err
+	}
+	return Save(path, cfg)
+}
+
 // merge overlays non-empty fields from src onto dst.
 func merge(dst, src Config) Config {
 	if src.Model != "" {
@@ -98,6 +217,37 @@ func merge(dst, src Config) Config {
 	if src.BranchZellijLayout != "" {
 		dst.BranchZellijLayout = src.BranchZellijLayout
 	}
+	if src.AgentCommand != "" {
+		dst.AgentCommand = src.AgentCommand
+	}
+	if src.AgentModelArgs != "" {
+		dst.AgentModelArgs = src.AgentModelArgs
+	}
+	if src.AgentPromptArgs != "" {
+		dst.AgentPromptArgs = src.AgentPromptArgs
+	}
+	if src.MaxConcurrentAgents != "" {
+		dst.MaxConcurrentAgents = src.MaxConcurrentAgents
+	}
+	if src.BrActiveHours != "" {
+		dst.BrActiveHours = src.BrActiveHours
+	}
+	if src.Theme != "" {
+		dst.Theme = src.Theme
+	}
+	if src.Notify != "" {
+		dst.Notify = src.Notify
+	}
+	if len(src.KeyBindings) > 0 {
+		bindings := make(map[string]string, len(dst.KeyBindings)+len(src.KeyBindings))
+		for action, keys := range dst.KeyBindings {
+			bindings[action] = keys
+		}
+		for action, keys := range src.KeyBindings {
+			bindings[action] = keys
+		}
+		dst.KeyBindings = bindings
+	}
 	return dst
 }
 
@@ -131,8 +281,16 @@ func LoadEffective(homeDir, owner, repo string) (Config, error) {
 	return cfg, nil
 }
 
+// keyBindingPrefix starts the config keys of key bindings, as in
+// "keys.br.delete".
+const keyBindingPrefix = "keys."
+
 // Get returns the value of the named field, and whether the key is valid.
 func Get(cfg Config, key string) (string, bool) {
+	if action, ok := strings.CutPrefix(key, keyBindingPrefix); ok {
+		_, valid := DefaultKeyBindings[action]
+		return cfg.KeyBindings[action], valid
+	}
 	switch key {
 	case "model":
 		return cfg.Model, true
@@ -142,6 +300,20 @@ func Get(cfg Config, key string) (string, bool) {
 		return cfg.BranchOpenMode, true
 	case "branch-zellij-layout":
 		return cfg.BranchZellijLayout, true
+	case "agent-command":
+		return cfg.AgentCommand, true
+	case "agent-model-args":
+		return cfg.AgentModelArgs, true
+	case "agent-prompt-args":
+		return cfg.AgentPromptArgs, true
+	case "max-concurrent-agents":
+		return cfg.MaxConcurrentAgents, true
+	case "br-active-hours":
+		return cfg.BrActiveHours, true
+	case "theme":
+		return cfg.Theme, true
+	case "notify":
+		return cfg.Notify, true
 	default:
 		return "", false
 	}
@@ -150,10 +322,28 @@ func Get(cfg Config, key string) (string, bool) {
 // Set returns a new Config with the named field set to value.
 // Returns an error if the key is unknown.
 func Set(cfg Config, key, value string) (Config, error) {
+	if action, ok := strings.CutPrefix(key, keyBindingPrefix); ok {
+		if _, valid := DefaultKeyBindings[action]; !valid {
+			return cfg, unknownKeyError(key)
+		}
+		if len(ParseKeys(value)) == 0 {
+			return cfg, fmt.Errorf("invalid %s: %q (must list one or more keys, comma-separated)", key, value)
+		}
+		bindings := make(map[string]string, len(cfg.KeyBindings)+1)
+		for a, k := range cfg.KeyBindings {
+			bindings[a] = k
+		}
+		bindings[action] = value
+		cfg.KeyBindings = bindings
+		return cfg, nil
+	}
 	switch key {
 	case "model":
 		cfg.Model = value
 	case "agent":
+		if !slices.Contains(Agents, value) {
+			return cfg, fmt.Errorf("invalid agent: %s (valid values: %s)", value, strings.Join(Agents, ", "))
+		}
 		cfg.Agent = value
 	case "branch-open-mode":
 		if value != "zellij" && value != "standard" {
@@ -165,8 +355,34 @@ func Set(cfg Config, key, value string) (Config, error) {
 			return cfg, fmt.Errorf("invalid branch-zellij-layout: %s (valid values: vertical, horizontal)", value)
 		}
 		cfg.BranchZellijLayout = value
+	case "agent-command":
+		cfg.AgentCommand = value
+	case "agent-model-args":
+		cfg.AgentModelArgs = value
+	case "agent-prompt-args":
+		cfg.AgentPromptArgs = value
+	case "max-concurrent-agents":
+		if n, err := strconv.Atoi(value); err != nil || n < 0 {
+			return cfg, fmt.Errorf("invalid max-concurrent-agents: %s (must be a non-negative integer)", value)
+		}
+		cfg.MaxConcurrentAgents = value
+	case "br-active-hours":
+		if n, err := strconv.Atoi(value); err != nil || n <= 0 {
+			return cfg, fmt.Errorf("invalid br-active-hours: %s (must be a positive integer)", value)
+		}
+		cfg.BrActiveHours = value
+	case "theme":
+		if !slices.Contains(Themes, value) {
+			return cfg, fmt.Errorf("invalid theme: %s (valid values: %s)", value, strings.Join(Themes, ", "))
+		}
+		cfg.Theme = value
+	case "notify":
+		if !slices.Contains(NotifySinks, value) {
+			return cfg, fmt.Errorf("invalid notify: %s (valid values: %s)", value, strings.Join(NotifySinks, ", "))
+		}
+		cfg.Notify = value
 	default:
-		return cfg, fmt.Errorf("unknown config key: %s (valid keys: model, agent, branch-open-mode, branch-zellij-layout)", key)
+		return cfg, unknownKeyError(key)
 	}
 	return cfg, nil
 }
@@ -174,6 +390,22 @@ func Set(cfg Config, key, value string) (Config, error) {
 // Unset returns a new Config with the named field cleared.
 // Returns an error if the key is unknown.
 func Unset(cfg Config, key string) (Config, error) {
+	if action, ok := strings.CutPrefix(key, keyBindingPrefix); ok {
+		if _, valid := DefaultKeyBindings[action]; !valid {
+			return cfg, unknownKeyError(key)
+		}
+		bindings := make(map[string]string, len(cfg.KeyBindings))
+		for a, k := range cfg.KeyBindings {
+			if a != action {
+				bindings[a] = k
+			}
+		}
+		cfg.KeyBindings = bindings
+		if len(bindings) == 0 {
+			cfg.KeyBindings = nil
+		}
+		return cfg, nil
+	}
 	switch key {
 	case "model":
 		cfg.Model = ""
@@ -183,11 +415,81 @@ func Unset(cfg Config, key string) (Config, error) {
 		cfg.BranchOpenMode = ""
 	case "branch-zellij-layout":
 		cfg.BranchZellijLayout = ""
+	case "agent-command":
+		cfg.AgentCommand = ""
+	case "agent-model-args":
+		cfg.AgentModelArgs = ""
+	case "agent-prompt-args":
+		cfg.AgentPromptArgs = ""
+	case "max-concurrent-agents":
+		cfg.MaxConcurrentAgents = ""
+	case "br-active-hours":
+		cfg.BrActiveHours = ""
+	case "theme":
+		cfg.Theme = ""
+	case "notify":
+		cfg.Notify = ""
 	default:
-		return cfg, fmt.Errorf("unknown config key: %s (valid keys: model, agent, branch-open-mode, branch-zellij-layout)", key)
+		return cfg, unknownKeyError(key)
 	}
 	return cfg, nil
 }
 
 // Keys returns the list of all valid config keys.
-var Keys = []string{"model", "agent", "branch-open-mode", "branch-zellij-layout"}
+var Keys = []string{
+	"model",
+	"agent",
+	"branch-open-mode",
+	"branch-zellij-layout",
+	"agent-command",
+	"agent-model-args",
+	"agent-prompt-args",
+	"max-concurrent-agents",
+	"br-active-hours",
+	"theme",
+	"notify",
+}
+
+// AgentLimit returns the parsed max-concurrent-agents value, or 0 (no limit)
+// when it is unset or invalid.
+func (c Config) AgentLimit() int {
+	n, err := strconv.Atoi(strings.TrimSpace(c.MaxConcurrentAgents))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// ActiveHours returns the parsed br-active-hours value, or
+// DefaultActiveHours when it is unset or invalid.
+func (c Config) ActiveHours() int {
+	n, err := strconv.Atoi(strings.TrimSpace(c.BrActiveHours))
+	if err != nil || n <= 0 {
+		return DefaultActiveHours
+	}
+	return n
+}
+
+// KeyBinding returns the keys bound to action, from KeyBindings or else
+// DefaultKeyBindings.
+func (c Config) KeyBinding(action string) []string {
+	if keys := ParseKeys(c.KeyBindings[action]); len(keys) > 0 {
+		return keys
+	}
+	return ParseKeys(DefaultKeyBindings[action])
+}
+
+// ParseKeys splits a comma-separated key list, dropping empty entries.
+func ParseKeys(value string) []string {
+	var keys []string
+	for _, k := range strings.Split(value, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func unknownKeyError(key string) error {
+	return fmt.Errorf("unknown config key: %s (valid keys: %s, keys.<action>)", key, strings.Join(Keys, ", "))
+}
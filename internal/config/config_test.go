@@ -3,6 +3,7 @@ package config_test
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"fitz/internal/config"
@@ -10,8 +11,8 @@ import (
 
 func TestDefaultConfig(t *testing.T) {
 	cfg := config.DefaultConfig()
-	if cfg.Model != "claude-opus-4.6" {
-		t.Errorf("default model = %q, want %q", cfg.Model, "claude-opus-4.6")
+	if cfg.Model != "" {
+		t.Errorf("default model = %q, want unset so the agent picks its own", cfg.Model)
 	}
 	if cfg.Agent != "copilot-cli" {
 		t.Errorf("default agent = %q, want %q", cfg.Agent, "copilot-cli")
@@ -101,7 +102,7 @@ func TestSaveAndLoad_RoundTrip(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if loaded != original {
+	if !reflect.DeepEqual(loaded, original) {
 		t.Errorf("round-trip: got %+v, want %+v", loaded, original)
 	}
 }
@@ -113,7 +114,7 @@ func TestLoadEffective_DefaultsOnly(t *testing.T) {
 		t.Fatal(err)
 	}
 	def := config.DefaultConfig()
-	if cfg != def {
+	if !reflect.DeepEqual(cfg, def) {
 		t.Errorf("effective (no files) = %+v, want defaults %+v", cfg, def)
 	}
 }
@@ -221,10 +222,13 @@ func TestSet(t *testing.T) {
 		t.Errorf("Set model: got %+v, err=%v", cfg2, err)
 	}
 
-	cfg3, err := config.Set(cfg, "agent", "new-agent")
-	if err != nil || cfg3.Agent != "new-agent" {
+	cfg3, err := config.Set(cfg, "agent", "claude")
+	if err != nil || cfg3.Agent != "claude" {
 		t.Errorf("Set agent: got %+v, err=%v", cfg3, err)
 	}
+	if _, err := config.Set(cfg, "agent", "claud"); err == nil {
+		t.Error("Set unknown agent should return error")
+	}
 
 	cfg4, err := config.Set(cfg, "branch-open-mode", "standard")
 	if err != nil || cfg4.BranchOpenMode != "standard" {
@@ -290,3 +294,157 @@ func TestKeys(t *testing.T) {
 		}
 	}
 }
+
+func TestAgentCommandKeysRoundTrip(t *testing.T) {
+	cfg := config.Config{}
+	values := map[string]string{
+		"agent-command":     "aider",
+		"agent-model-args":  "--model {model}",
+		"agent-prompt-args": "--yes --message {prompt}",
+	}
+	for key, value := range values {
+		var err error
+		cfg, err = config.Set(cfg, key, value)
+		if err != nil {
+			t.Fatalf("Set %s: %v", key, err)
+		}
+	}
+	for key, want := range values {
+		if got, ok := config.Get(cfg, key); !ok || got != want {
+			t.Errorf("Get %s = %q, %v; want %q, true", key, got, ok, want)
+		}
+	}
+
+	for key := range values {
+		var err error
+		if cfg, err = config.Unset(cfg, key); err != nil {
+			t.Fatalf("Unset %s: %v", key, err)
+		}
+	}
+	if !reflect.DeepEqual(cfg, config.Config{}) {
+		t.Errorf("after unset cfg = %+v, want empty", cfg)
+	}
+}
+
+func TestMaxConcurrentAgents(t *testing.T) {
+	cfg, err := config.Set(config.Config{}, "max-concurrent-agents", "3")
+	if err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got := cfg.AgentLimit(); got != 3 {
+		t.Fatalf("AgentLimit = %d, want 3", got)
+	}
+
+	for _, bad := range []string{"-1", "many", ""} {
+		if _, err := config.Set(config.Config{}, "max-concurrent-agents", bad); err == nil {
+			t.Errorf("Set max-concurrent-agents=%q: expected error", bad)
+		}
+	}
+
+	if got := (config.Config{}).AgentLimit(); got != 0 {
+		t.Fatalf("unset AgentLimit = %d, want 0 (no limit)", got)
+	}
+}
+
+func TestBrActiveHours(t *testing.T) {
+	cfg, err := config.Set(config.Config{}, "br-active-hours", "12")
+	if err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got := cfg.ActiveHours(); got != 12 {
+		t.Fatalf("ActiveHours = %d, want 12", got)
+	}
+
+	for _, bad := range []string{"0", "-1", "soon"} {
+		if _, err := config.Set(config.Config{}, "br-active-hours", bad); err == nil {
+			t.Errorf("Set br-active-hours=%q: expected error", bad)
+		}
+	}
+
+	if got := (config.Config{}).ActiveHours(); got != config.DefaultActiveHours {
+		t.Fatalf("unset ActiveHours = %d, want %d", got, config.DefaultActiveHours)
+	}
+}
+
+func TestTheme(t *testing.T) {
+	cfg, err := config.Set(config.Config{}, "theme", "light")
+	if err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got, ok := config.Get(cfg, "theme"); !ok || got != "light" {
+		t.Fatalf("Get theme = %q, %v; want light, true", got, ok)
+	}
+	if _, err := config.Set(config.Config{}, "theme", "solarized"); err == nil {
+		t.Error("Set theme=solarized: expected error")
+	}
+}
+
+func TestNotify(t *testing.T) {
+	for _, sink := range config.NotifySinks {
+		cfg, err := config.Set(config.Config{}, "notify", sink)
+		if err != nil {
+			t.Fatalf("Set notify=%s: %v", sink, err)
+		}
+		if got, ok := config.Get(cfg, "notify"); !ok || got != sink {
+			t.Fatalf("Get notify = %q, %v; want %s, true", got, ok, sink)
+		}
+	}
+	if _, err := config.Set(config.Config{}, "notify", "pager"); err == nil {
+		t.Error("Set notify=pager: expected error")
+	}
+}
+
+func TestKeyBindings(t *testing.T) {
+	cfg := config.Config{}
+	if got := cfg.KeyBinding("br.up"); !reflect.DeepEqual(got, []string{"up", "k"}) {
+		t.Fatalf("default br.up = %v, want [up k]", got)
+	}
+
+	cfg, err := config.Set(cfg, "keys.br.delete", "D, backspace")
+	if err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got := cfg.KeyBinding("br.delete"); !reflect.DeepEqual(got, []string{"D", "backspace"}) {
+		t.Fatalf("br.delete = %v, want [D backspace]", got)
+	}
+	if got, ok := config.Get(cfg, "keys.br.delete"); !ok || got != "D, backspace" {
+		t.Fatalf("Get keys.br.delete = %q, %v", got, ok)
+	}
+
+	for _, bad := range []struct{ key, value string }{
+		{"keys.br.nope", "z"},
+		{"keys.br.delete", " , "},
+	} {
+		if _, err := config.Set(cfg, bad.key, bad.value); err == nil {
+			t.Errorf("Set %s=%q: expected error", bad.key, bad.value)
+		}
+	}
+
+	if cfg, err = config.Unset(cfg, "keys.br.delete"); err != nil {
+		t.Fatalf("Unset: %v", err)
+	}
+	if cfg.KeyBindings != nil {
+		t.Fatalf("after unset KeyBindings = %v, want nil", cfg.KeyBindings)
+	}
+}
+
+func TestLoadEffective_KeyBindingsMergePerAction(t *testing.T) {
+	dir := t.TempDir()
+	globalPath, _ := config.GlobalConfigPath(dir)
+	repoPath, _ := config.RepoConfigPath(dir, "owner", "repo")
+	if err := config.Save(globalPath, config.Config{KeyBindings: map[string]string{"br.delete": "D", "br.new": "N"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Save(repoPath, config.Config{KeyBindings: map[string]string{"br.new": "c"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.LoadEffective(dir, "owner", "repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"br.delete": "D", "br.new": "c"}
+	if !reflect.DeepEqual(cfg.KeyBindings, want) {
+		t.Fatalf("KeyBindings = %v, want %v", cfg.KeyBindings, want)
+	}
+}
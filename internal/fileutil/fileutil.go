@@ -0,0 +1,80 @@
+package fileutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LockTimeout bounds how long Lock waits for another fitz process.
+var LockTimeout = 10 * time.Second
+
+// errLocked is returned by tryLock while another process holds the lock.
+var errLocked = errors.New("locked")
+
+// Lock takes an exclusive lock on the file at path, and returns a function
+// that releases it. The lock is an OS file lock (flock, or LockFileEx on
+// Windows) on a lock file next to path. The lock file is never removed, and
+// the OS releases the lock when its holder exits, so a crashed process
+// can't leave it held. The lock is advisory: it only excludes other callers
+// of Lock.
+func Lock(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create directory: %w", err)
+	}
+
+	lockPath := path + ".lock"
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("lock %s: %w", filepath.Base(path), err)
+	}
+	deadline := time.Now().Add(LockTimeout)
+	for {
+		err := tryLock(f)
+		if err == nil {
+			return func() {
+				_ = unlockFile(f)
+				f.Close()
+			}, nil
+		}
+		if !errors.Is(err, errLocked) {
+			f.Close()
+			return nil, fmt.Errorf("lock %s: %w", filepath.Base(path), err)
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("lock %s: timed out waiting for %s", filepath.Base(path), lockPath)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// WriteFile writes data to path atomically: it writes a temporary file in
+// the same directory and renames it over path, so readers see either the
+// old or the new contents and never a partial write.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp) // no-op once renamed
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,76 @@
+package fileutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteFileReplacesContents(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "store.json")
+
+	for _, content := range []string{"first\n", "second\n"} {
+		if err := WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write error: %v", err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read error: %v", err)
+		}
+		if string(got) != content {
+			t.Fatalf("contents = %q, want %q", got, content)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("dir has %d files, want only store.json (temp files left behind?)", len(entries))
+	}
+}
+
+func TestLockExcludesAndReleases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	origTimeout := LockTimeout
+	t.Cleanup(func() { LockTimeout = origTimeout })
+	LockTimeout = 50 * time.Millisecond
+
+	unlock, err := Lock(path)
+	if err != nil {
+		t.Fatalf("lock error: %v", err)
+	}
+	if _, err := Lock(path); err == nil {
+		t.Fatal("second lock should time out while the first is held")
+	}
+
+	unlock()
+	unlock, err = Lock(path)
+	if err != nil {
+		t.Fatalf("lock after release: %v", err)
+	}
+	unlock()
+}
+
+func TestLockIgnoresLeftoverLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	origTimeout := LockTimeout
+	t.Cleanup(func() { LockTimeout = origTimeout })
+	LockTimeout = 50 * time.Millisecond
+
+	// A crashed process leaves the lock file behind but not the lock.
+	if err := os.WriteFile(path+".lock", nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	unlock, err := Lock(path)
+	if err != nil {
+		t.Fatalf("lock should not wait on a leftover lock file: %v", err)
+	}
+	unlock()
+	if _, err := os.Stat(path + ".lock"); err != nil {
+		t.Fatalf("lock file removed on unlock: %v", err)
+	}
+}
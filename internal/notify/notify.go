@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// Notification is what `fitz agent notify` tells the user: which branch of
+// which repo is waiting, and the last status its agent reported.
+type Notification struct {
+	Repo    string // owner/repo, empty when unknown
+	Branch  string
+	Message string // last status message, empty when none
+}
+
+// Title is the notification's heading, e.g. "fitz: acme/api".
+func (n Notification) Title() string {
+	if n.Repo == "" {
+		return "fitz"
+	}
+	return "fitz: " + n.Repo
+}
+
+// Body is the notification's text, e.g. "feat/auth: Which database?".
+func (n Notification) Body() string {
+	if n.Message == "" {
+		return n.Branch + " is waiting for input"
+	}
+	return n.Branch + ": " + n.Message
+}
+
+// Sink delivers notifications.
+type Sink interface {
+	Notify(n Notification) error
+}
+
+// SinkFunc adapts a function to a Sink.
+type SinkFunc func(n Notification) error
+
+func (f SinkFunc) Notify(n Notification) error { return f(n) }
+
+// lookPath and run are replaced in tests.
+var lookPath = exec.LookPath
+
+var run = func(binary string, args ...string) error {
+	cmd := exec.Command(binary, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w: %s", filepath.Base(binary), err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return nil
+}
+
+var goos = runtime.GOOS
+
+// New returns the sink for name, one of config.NotifySinks. Terminal sinks
+// write their escape sequences to w. An empty name is "bell".
+func New(name string, w io.Writer) (Sink, error) {
+	switch name {
+	case "", "bell":
+		return bellSink{w}, nil
+	case "auto":
+		return autoSink(w), nil
+	case "desktop":
+		return SinkFunc(notifyDesktop), nil
+	case "osascript":
+		return SinkFunc(notifyOSAScript), nil
+	case "osc9":
+		return osc9Sink{w}, nil
+	case "osc777":
+		return osc777Sink{w}, nil
+	case "none":
+		return SinkFunc(func(Notification) error { return nil }), nil
+	default:
+		return nil, fmt.Errorf("unknown notify sink: %s", name)
+	}
+}
+
+// autoSink picks the native desktop notifications of the OS, falling back
+// to the terminal bell where there are none.
+func autoSink(w io.Writer) Sink {
+	switch goos {
+	case "darwin":
+		if _, err := lookPath("osascript"); err == nil {
+			return SinkFunc(notifyOSAScript)
+		}
+	case "linux", "freebsd", "openbsd", "netbsd":
+		if _, _, err := desktopCommand(); err == nil {
+			return SinkFunc(notifyDesktop)
+		}
+	}
+	return bellSink{w}
+}
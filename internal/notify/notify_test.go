@@ -0,0 +1,164 @@
+package notify
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testNotification = Notification{Repo: "acme/api", Branch: "feat/auth", Message: `Use "pg"; or sqlite?`}
+
+// stubCommands makes the binaries in path the only ones found, and records
+// the commands run instead of running them.
+func stubCommands(t *testing.T, path ...string) *[][]string {
+	t.Helper()
+	origLookPath, origRun := lookPath, run
+	t.Cleanup(func() { lookPath, run = origLookPath, origRun })
+
+	lookPath = func(name string) (string, error) {
+		for _, p := range path {
+			if p == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+	var ran [][]string
+	run = func(binary string, args ...string) error {
+		ran = append(ran, append([]string{binary}, args...))
+		return nil
+	}
+	return &ran
+}
+
+func TestNotificationText(t *testing.T) {
+	if got := testNotification.Title(); got != "fitz: acme/api" {
+		t.Fatalf("title = %q", got)
+	}
+	if got := testNotification.Body(); got != `feat/auth: Use "pg"; or sqlite?` {
+		t.Fatalf("body = %q", got)
+	}
+	n := Notification{Branch: "docs"}
+	if n.Title() != "fitz" || n.Body() != "docs is waiting for input" {
+		t.Fatalf("title, body = %q, %q", n.Title(), n.Body())
+	}
+}
+
+func TestTerminalSinks(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "", want: "\a"},
+		{name: "bell", want: "\a"},
+		{name: "osc9", want: "\x1b]9;fitz: acme/api: feat/auth: Use \"pg\"; or sqlite?\a"},
+		{name: "osc777", want: "\x1b]777;notify;fitz: acme/api;feat/auth: Use \"pg\"; or sqlite?\a"},
+		{name: "none", want: ""},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		sink, err := New(tt.name, &out)
+		if err != nil {
+			t.Fatalf("New(%q): %v", tt.name, err)
+		}
+		if err := sink.Notify(testNotification); err != nil {
+			t.Fatalf("%q: notify error: %v", tt.name, err)
+		}
+		if out.String() != tt.want {
+			t.Errorf("%q wrote %q, want %q", tt.name, out.String(), tt.want)
+		}
+	}
+}
+
+func TestOSCStripsControlCharacters(t *testing.T) {
+	var out bytes.Buffer
+	sink, _ := New("osc9", &out)
+	if err := sink.Notify(Notification{Branch: "x", Message: "a\x1b]0;b\ac"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); strings.Count(got, "\a") != 1 || strings.Count(got, "\x1b") != 1 {
+		t.Fatalf("wrote %q, want one escape sequence", got)
+	}
+}
+
+func TestDesktopSink(t *testing.T) {
+	ran := stubCommands(t, "notify-send", "gdbus")
+	sink, _ := New("desktop", nil)
+	if err := sink.Notify(testNotification); err != nil {
+		t.Fatalf("notify error: %v", err)
+	}
+	want := []string{"/usr/bin/notify-send", "--app-name=fitz", "--expire-time=10000", "fitz: acme/api", `feat/auth: Use "pg"; or sqlite?`}
+	if len(*ran) != 1 || !reflect.DeepEqual((*ran)[0], want) {
+		t.Fatalf("ran %q, want %q", *ran, want)
+	}
+}
+
+func TestDesktopSinkFallsBackToDBus(t *testing.T) {
+	ran := stubCommands(t, "gdbus")
+	sink, _ := New("desktop", nil)
+	if err := sink.Notify(Notification{Repo: "acme/api", Branch: "it's"}); err != nil {
+		t.Fatalf("notify error: %v", err)
+	}
+	if len(*ran) != 1 || (*ran)[0][0] != "/usr/bin/gdbus" || !strings.Contains(strings.Join((*ran)[0], " "), `org.freedesktop.Notifications.Notify 'fitz' 0 '' 'fitz: acme/api' 'it\'s is waiting for input'`) {
+		t.Fatalf("ran %q", *ran)
+	}
+
+	stubCommands(t)
+	if err := sink.Notify(testNotification); err == nil {
+		t.Fatal("expected an error without notify-send or gdbus")
+	}
+}
+
+func TestOSAScriptSink(t *testing.T) {
+	ran := stubCommands(t, "osascript")
+	sink, _ := New("osascript", nil)
+	if err := sink.Notify(testNotification); err != nil {
+		t.Fatalf("notify error: %v", err)
+	}
+	want := []string{"/usr/bin/osascript", "-e", `display notification "feat/auth: Use \"pg\"; or sqlite?" with title "fitz: acme/api"`}
+	if len(*ran) != 1 || !reflect.DeepEqual((*ran)[0], want) {
+		t.Fatalf("ran %q, want %q", *ran, want)
+	}
+}
+
+func TestAutoSink(t *testing.T) {
+	origGOOS := goos
+	t.Cleanup(func() { goos = origGOOS })
+
+	tests := []struct {
+		goos string
+		path []string
+		want string // binary run, or "" for the bell
+	}{
+		{goos: "darwin", path: []string{"osascript"}, want: "/usr/bin/osascript"},
+		{goos: "linux", path: []string{"notify-send"}, want: "/usr/bin/notify-send"},
+		{goos: "linux"},
+		{goos: "windows", path: []string{"notify-send"}},
+	}
+	for _, tt := range tests {
+		goos = tt.goos
+		ran := stubCommands(t, tt.path...)
+		var out bytes.Buffer
+		sink, err := New("auto", &out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sink.Notify(testNotification); err != nil {
+			t.Fatalf("%s: notify error: %v", tt.goos, err)
+		}
+		switch {
+		case tt.want == "" && (out.String() != "\a" || len(*ran) != 0):
+			t.Errorf("%s %v: wrote %q, ran %q; want the bell", tt.goos, tt.path, out.String(), *ran)
+		case tt.want != "" && (len(*ran) != 1 || (*ran)[0][0] != tt.want):
+			t.Errorf("%s %v: ran %q, want %s", tt.goos, tt.path, *ran, tt.want)
+		}
+	}
+}
+
+func TestNewRejectsUnknownSink(t *testing.T) {
+	if _, err := New("pager", nil); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,97 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// notifyTimeout is how long desktop notifications stay up, in milliseconds.
+const notifyTimeout = "10000"
+
+// bellSink rings the terminal bell.
+type bellSink struct{ w io.Writer }
+
+func (s bellSink) Notify(Notification) error {
+	_, err := fmt.Fprint(s.w, "\a")
+	return err
+}
+
+// osc9Sink sends the OSC 9 notification understood by iTerm2, Windows
+// Terminal, WezTerm, kitty and others.
+type osc9Sink struct{ w io.Writer }
+
+func (s osc9Sink) Notify(n Notification) error {
+	_, err := fmt.Fprintf(s.w, "\x1b]9;%s\a", oscText(n.Title()+": "+n.Body()))
+	return err
+}
+
+// osc777Sink sends the OSC 777 notification understood by rxvt-unicode,
+// foot, Ghostty and VTE-based terminals.
+type osc777Sink struct{ w io.Writer }
+
+func (s osc777Sink) Notify(n Notification) error {
+	title := strings.ReplaceAll(oscText(n.Title()), ";", ",")
+	_, err := fmt.Fprintf(s.w, "\x1b]777;notify;%s;%s\a", title, oscText(n.Body()))
+	return err
+}
+
+// oscText drops the control characters that would end an escape sequence
+// early.
+func oscText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// desktopCommand returns the command that sends freedesktop notifications:
+// notify-send, or else gdbus talking to the notification service over D-Bus.
+func desktopCommand() (binary string, dbus bool, err error) {
+	if path, err := lookPath("notify-send"); err == nil {
+		return path, false, nil
+	}
+	if path, err := lookPath("gdbus"); err == nil {
+		return path, true, nil
+	}
+	return "", false, errors.New("desktop notifications need notify-send or gdbus in PATH")
+}
+
+func notifyDesktop(n Notification) error {
+	binary, dbus, err := desktopCommand()
+	if err != nil {
+		return err
+	}
+	if dbus {
+		return run(binary, "call", "--session",
+			"--dest", "org.freedesktop.Notifications",
+			"--object-path", "/org/freedesktop/Notifications",
+			"--method", "org.freedesktop.Notifications.Notify",
+			gvariantString("fitz"), "0", gvariantString(""), gvariantString(n.Title()), gvariantString(n.Body()),
+			"@as []", "@a{sv} {}", notifyTimeout)
+	}
+	return run(binary, "--app-name=fitz", "--expire-time="+notifyTimeout, n.Title(), n.Body())
+}
+
+// gvariantString quotes s as a GVariant text string, as gdbus parses its
+// arguments.
+func gvariantString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+func notifyOSAScript(n Notification) error {
+	binary, err := lookPath("osascript")
+	if err != nil {
+		return errors.New("osascript not found in PATH")
+	}
+	script := fmt.Sprintf("display notification %s with title %s", appleScriptString(n.Body()), appleScriptString(n.Title()))
+	return run(binary, "-e", script)
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
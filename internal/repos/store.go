@@ -0,0 +1,112 @@
+package repos
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"fitz/internal/fileutil"
+)
+
+// Repo is a repository fitz has created worktrees for, keyed in the
+// registry by its ID.
+type Repo struct {
+	Owner     string    `json:"owner,omitempty"`
+	Name      string    `json:"name"`
+	Root      string    `json:"root"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ID returns "<owner>/<name>", or just the name for repos without an origin
+// on GitHub. It matches the repo's data directory under ~/.fitz.
+func (r Repo) ID() string {
+	if r.Owner == "" {
+		return r.Name
+	}
+	return r.Owner + "/" + r.Name
+}
+
+// StorePath returns the registry path (~/.fitz/repos.json).
+func StorePath(homeDir string) (string, error) {
+	if homeDir == "" {
+		var err error
+		homeDir, err = os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("get home dir: %w", err)
+		}
+	}
+	return filepath.Join(homeDir, ".fitz", "repos.json"), nil
+}
+
+func Load(path string) (map[string]Repo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return map[string]Repo{}, nil
+		}
+		return nil, fmt.Errorf("read repos: %w", err)
+	}
+
+	var entries map[string]Repo
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parse repos: %w", err)
+	}
+	if entries == nil {
+		entries = map[string]Repo{}
+	}
+	return entries, nil
+}
+
+func Save(path string, entries map[string]Repo) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode repos: %w", err)
+	}
+	data = append(data, '\n')
+
+	if err := fileutil.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("write repos: %w", err)
+	}
+	return nil
+}
+
+// Record adds repo to the registry, or updates its root if it is already
+// known.
+func Record(path string, repo Repo) (Repo, error) {
+	unlock, err := fileutil.Lock(path)
+	if err != nil {
+		return Repo{}, err
+	}
+	defer unlock()
+
+	entries, err := Load(path)
+	if err != nil {
+		return Repo{}, err
+	}
+
+	repo.UpdatedAt = time.Now().UTC()
+	entries[repo.ID()] = repo
+	if err := Save(path, entries); err != nil {
+		return Repo{}, err
+	}
+	return repo, nil
+}
+
+// Sorted returns the registered repos ordered by ID.
+func Sorted(entries map[string]Repo) []Repo {
+	list := make([]Repo, 0, len(entries))
+	for _, repo := range entries {
+		list = append(list, repo)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID() < list[j].ID() })
+	return list
+}
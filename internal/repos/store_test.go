@@ -0,0 +1,51 @@
+package repos
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "repos.json")
+	entries, err := Load(path)
+	if err != nil {
+		t.Fatalf("load error: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected empty map, got %d entries", len(entries))
+	}
+}
+
+func TestRecordAddsAndUpdates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "repos.json")
+
+	if _, err := Record(path, Repo{Owner: "acme", Name: "api", Root: "/src/api"}); err != nil {
+		t.Fatalf("record error: %v", err)
+	}
+	if _, err := Record(path, Repo{Name: "scratch", Root: "/src/scratch"}); err != nil {
+		t.Fatalf("record error: %v", err)
+	}
+	got, err := Record(path, Repo{Owner: "acme", Name: "api", Root: "/work/api"})
+	if err != nil {
+		t.Fatalf("record error: %v", err)
+	}
+	if got.UpdatedAt.IsZero() {
+		t.Fatal("expected UpdatedAt to be set")
+	}
+
+	entries, err := Load(path)
+	if err != nil {
+		t.Fatalf("load error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if entries["acme/api"].Root != "/work/api" {
+		t.Fatalf("acme/api root = %q, want /work/api", entries["acme/api"].Root)
+	}
+
+	sorted := Sorted(entries)
+	if sorted[0].ID() != "acme/api" || sorted[1].ID() != "scratch" {
+		t.Fatalf("sorted = %+v", sorted)
+	}
+}
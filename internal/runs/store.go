@@ -0,0 +1,312 @@
+package runs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"fitz/internal/fileutil"
+)
+
+const (
+	StateRunning  = "running"
+	StateFinished = "finished"
+	StateFailed   = "failed"
+	StateStopping = "stopping"
+	StateStopped  = "stopped"
+	StateQueued   = "queued"
+	// StateCancelled is a queued run that was stopped before it started.
+	StateCancelled = "cancelled"
+)
+
+// Run records one background agent kickoff for a branch.
+type Run struct {
+	ID     string   `json:"id"`
+	Branch string   `json:"branch"`
+	Dir    string   `json:"dir"`
+	PID    int      `json:"pid,omitempty"`
+	Binary string   `json:"binary"`
+	Args   []string `json:"args"`
+	Prompt string   `json:"prompt,omitempty"`
+	Model  string   `json:"model,omitempty"`
+	// Group names the fanout a run was started by, if any.
+	Group     string     `json:"group,omitempty"`
+	LogPath   string     `json:"log_path"`
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	ExitCode  *int       `json:"exit_code,omitempty"`
+	StoppedAt *time.Time `json:"stopped_at,omitempty"`
+	// Queued runs are waiting for a free agent slot; they have no PID yet.
+	Queued bool `json:"queued,omitempty"`
+}
+
+// State reports the run's lifecycle state. alive reports whether the
+// recorded process is still running; a run whose process vanished without
+// recording an exit code is treated as failed, unless it was stopped.
+func (r Run) State(alive bool) string {
+	if r.Queued {
+		return StateQueued
+	}
+	if r.StoppedAt != nil {
+		if r.PID == 0 && r.ExitCode == nil {
+			return StateCancelled
+		}
+		if r.ExitCode == nil && alive {
+			return StateStopping
+		}
+		return StateStopped
+	}
+	if r.ExitCode == nil {
+		if alive {
+			return StateRunning
+		}
+		return StateFailed
+	}
+	if *r.ExitCode != 0 {
+		return StateFailed
+	}
+	return StateFinished
+}
+
+// Label returns a short human-readable state such as "running",
+// "failed (exit 1)" or "finished".
+func (r Run) Label(alive bool) string {
+	state := r.State(alive)
+	if state == StateFailed && r.ExitCode != nil {
+		return state + " (exit " + strconv.Itoa(*r.ExitCode) + ")"
+	}
+	return state
+}
+
+func StorePath(homeDir, owner, repo string) (string, error) {
+	if homeDir == "" {
+		var err error
+		homeDir, err = os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("get home dir: %w", err)
+		}
+	}
+	return filepath.Join(homeDir, ".fitz", owner, repo, "runs.json"), nil
+}
+
+// LogPath returns the log file path for a run stored in the registry at
+// storePath. Logs live in a hidden directory so they can't collide with a
+// worktree directory of the same name.
+func LogPath(storePath, id string) string {
+	return filepath.Join(filepath.Dir(storePath), ".runs", id+".log")
+}
+
+func NewID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(b)
+}
+
+func Load(path string) ([]Run, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read runs: %w", err)
+	}
+
+	var runs []Run
+	if err := json.Unmarshal(data, &runs); err != nil {
+		return nil, fmt.Errorf("parse runs: %w", err)
+	}
+	return runs, nil
+}
+
+func Save(path string, runs []Run) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(runs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode runs: %w", err)
+	}
+	data = append(data, '\n')
+
+	if err := fileutil.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("write runs: %w", err)
+	}
+	return nil
+}
+
+// Mutate applies fn to the registry at path while holding an exclusive lock,
+// and saves the runs it returns. Use it for any read-modify-write so that
+// concurrent fitz processes (including run supervisors) don't lose updates.
+func Mutate(path string, fn func([]Run) ([]Run, error)) error {
+	unlock, err := fileutil.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	runs, err := Load(path)
+	if err != nil {
+		return err
+	}
+	runs, err = fn(runs)
+	if err != nil {
+		return err
+	}
+	return Save(path, runs)
+}
+
+func Add(path string, run Run) error {
+	return Mutate(path, func(runs []Run) ([]Run, error) {
+		return append(runs, run), nil
+	})
+}
+
+// Get returns the run with the given id. It waits for any in-progress
+// Mutate, so a supervisor started from inside one sees its run.
+func Get(path, id string) (Run, error) {
+	unlock, err := fileutil.Lock(path)
+	if err != nil {
+		return Run{}, err
+	}
+	defer unlock()
+
+	runs, err := Load(path)
+	if err != nil {
+		return Run{}, err
+	}
+	for _, r := range runs {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return Run{}, fmt.Errorf("run %q not found", id)
+}
+
+// Update applies fn to the run with the given id and saves the registry.
+func Update(path, id string, fn func(*Run)) (Run, error) {
+	var updated Run
+	err := Mutate(path, func(runs []Run) ([]Run, error) {
+		for i := range runs {
+			if runs[i].ID == id {
+				fn(&runs[i])
+				updated = runs[i]
+				return runs, nil
+			}
+		}
+		return nil, fmt.Errorf("run %q not found", id)
+	})
+	if err != nil {
+		return Run{}, err
+	}
+	return updated, nil
+}
+
+// Finish records the exit code and end time of a run.
+func Finish(path, id string, exitCode int) (Run, error) {
+	return Update(path, id, func(r *Run) {
+		now := time.Now().UTC()
+		r.EndedAt = &now
+		r.ExitCode = &exitCode
+	})
+}
+
+// MarkStopped records that the user asked for a run to be stopped, so its
+// eventual exit is reported as stopped rather than failed.
+func MarkStopped(path, id string) (Run, error) {
+	return Update(path, id, func(r *Run) {
+		if r.StoppedAt == nil {
+			now := time.Now().UTC()
+			r.StoppedAt = &now
+		}
+	})
+}
+
+// Cancel marks a queued run as stopped so it never starts.
+func Cancel(path, id string) (Run, error) {
+	var cancelled Run
+	err := Mutate(path, func(runs []Run) ([]Run, error) {
+		for i := range runs {
+			if runs[i].ID == id {
+				if !runs[i].Queued {
+					return nil, fmt.Errorf("run %q is not queued", id)
+				}
+				now := time.Now().UTC()
+				runs[i].Queued = false
+				runs[i].StoppedAt = &now
+				cancelled = runs[i]
+				return runs, nil
+			}
+		}
+		return nil, fmt.Errorf("run %q not found", id)
+	})
+	if err != nil {
+		return Run{}, err
+	}
+	return cancelled, nil
+}
+
+// Queued returns the queued runs in the order they will start.
+func Queued(runs []Run) []Run {
+	var queued []Run
+	for _, r := range runs {
+		if r.Queued {
+			queued = append(queued, r)
+		}
+	}
+	return queued
+}
+
+// MoveQueued moves the queued run id to position (1-based) in the queue,
+// clamping position to the queue's bounds.
+func MoveQueued(path, id string, position int) error {
+	return Mutate(path, func(runs []Run) ([]Run, error) {
+		var slots []int
+		from := -1
+		for i, r := range runs {
+			if r.Queued {
+				if r.ID == id {
+					from = len(slots)
+				}
+				slots = append(slots, i)
+			}
+		}
+		if from < 0 {
+			return nil, fmt.Errorf("run %q is not queued", id)
+		}
+
+		to := min(max(position, 1), len(slots)) - 1
+		order := make([]Run, len(slots))
+		for i, slot := range slots {
+			order[i] = runs[slot]
+		}
+		moved := order[from]
+		order = append(order[:from], order[from+1:]...)
+		order = append(order[:to], append([]Run{moved}, order[to:]...)...)
+
+		// Queued runs keep their slots in the registry; only their order
+		// among themselves changes.
+		for i, slot := range slots {
+			runs[slot] = order[i]
+		}
+		return runs, nil
+	})
+}
+
+// Latest returns the most recently started run for each branch.
+func Latest(runs []Run) map[string]Run {
+	latest := make(map[string]Run)
+	for _, r := range runs {
+		if cur, ok := latest[r.Branch]; !ok || r.StartedAt.After(cur.StartedAt) {
+			latest[r.Branch] = r
+		}
+	}
+	return latest
+}
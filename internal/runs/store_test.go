@@ -0,0 +1,221 @@
+package runs
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoadEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.json")
+	runs, err := Load(path)
+	if err != nil {
+		t.Fatalf("load error: %v", err)
+	}
+	if len(runs) != 0 {
+		t.Fatalf("expected no runs, got %d", len(runs))
+	}
+}
+
+func TestAddUpdateFinish(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.json")
+	run := Run{
+		ID:        "r1",
+		Branch:    "feat",
+		Args:      []string{"copilot", "-p", "do it"},
+		LogPath:   LogPath(path, "r1"),
+		StartedAt: time.Date(2026, 2, 19, 6, 0, 0, 0, time.UTC),
+	}
+	if err := Add(path, run); err != nil {
+		t.Fatalf("add error: %v", err)
+	}
+
+	updated, err := Update(path, "r1", func(r *Run) { r.PID = 4242 })
+	if err != nil {
+		t.Fatalf("update error: %v", err)
+	}
+	if updated.PID != 4242 {
+		t.Fatalf("pid = %d, want 4242", updated.PID)
+	}
+
+	finished, err := Finish(path, "r1", 3)
+	if err != nil {
+		t.Fatalf("finish error: %v", err)
+	}
+	if finished.ExitCode == nil || *finished.ExitCode != 3 || finished.EndedAt == nil {
+		t.Fatalf("finish did not record exit: %+v", finished)
+	}
+
+	got, err := Get(path, "r1")
+	if err != nil {
+		t.Fatalf("get error: %v", err)
+	}
+	if got.PID != 4242 || got.ExitCode == nil || *got.ExitCode != 3 {
+		t.Fatalf("persisted run = %+v", got)
+	}
+	if want := filepath.Join(filepath.Dir(path), ".runs", "r1.log"); got.LogPath != want {
+		t.Fatalf("log path = %q, want %q", got.LogPath, want)
+	}
+}
+
+func TestUpdateMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.json")
+	if _, err := Update(path, "nope", func(*Run) {}); err == nil {
+		t.Fatal("expected error for missing run")
+	}
+}
+
+func TestStateAndLabel(t *testing.T) {
+	zero, one := 0, 1
+	stopped := time.Date(2026, 2, 19, 6, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		run   Run
+		alive bool
+		want  string
+	}{
+		{"running", Run{}, true, "running"},
+		{"vanished", Run{}, false, "failed"},
+		{"finished", Run{ExitCode: &zero}, false, "finished"},
+		{"failed", Run{ExitCode: &one}, false, "failed (exit 1)"},
+		{"stopping", Run{PID: 1, StoppedAt: &stopped}, true, "stopping"},
+		{"stopped", Run{PID: 1, StoppedAt: &stopped, ExitCode: &one}, false, "stopped"},
+		{"stopped without exit code", Run{PID: 1, StoppedAt: &stopped}, false, "stopped"},
+		{"queued", Run{Queued: true}, false, "queued"},
+		{"cancelled", Run{StoppedAt: &stopped}, false, "cancelled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.run.Label(tt.alive); got != tt.want {
+				t.Fatalf("label = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkStoppedKeepsFirstStopTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.json")
+	if err := Add(path, Run{ID: "r1", Branch: "feat"}); err != nil {
+		t.Fatalf("add error: %v", err)
+	}
+
+	first, err := MarkStopped(path, "r1")
+	if err != nil {
+		t.Fatalf("mark stopped error: %v", err)
+	}
+	if first.StoppedAt == nil {
+		t.Fatal("expected stopped_at to be set")
+	}
+	second, err := MarkStopped(path, "r1")
+	if err != nil {
+		t.Fatalf("mark stopped error: %v", err)
+	}
+	if !second.StoppedAt.Equal(*first.StoppedAt) {
+		t.Fatalf("stopped_at changed from %v to %v", first.StoppedAt, second.StoppedAt)
+	}
+}
+
+func TestMoveQueued(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.json")
+	for _, r := range []Run{
+		{ID: "q1", Queued: true},
+		{ID: "done"},
+		{ID: "q2", Queued: true},
+		{ID: "q3", Queued: true},
+	} {
+		if err := Add(path, r); err != nil {
+			t.Fatalf("add error: %v", err)
+		}
+	}
+
+	if err := MoveQueued(path, "q3", 1); err != nil {
+		t.Fatalf("move error: %v", err)
+	}
+	all, err := Load(path)
+	if err != nil {
+		t.Fatalf("load error: %v", err)
+	}
+	var order []string
+	for _, r := range all {
+		order = append(order, r.ID)
+	}
+	if got, want := strings.Join(order, ","), "q3,done,q1,q2"; got != want {
+		t.Fatalf("order = %s, want %s", got, want)
+	}
+
+	if err := MoveQueued(path, "q3", 99); err != nil {
+		t.Fatalf("move error: %v", err)
+	}
+	var queued []string
+	all, _ = Load(path)
+	for _, r := range Queued(all) {
+		queued = append(queued, r.ID)
+	}
+	if got, want := strings.Join(queued, ","), "q1,q2,q3"; got != want {
+		t.Fatalf("queue = %s, want %s", got, want)
+	}
+
+	if err := MoveQueued(path, "done", 1); err == nil {
+		t.Fatal("expected error moving a run that isn't queued")
+	}
+}
+
+func TestCancel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.json")
+	if err := Add(path, Run{ID: "q1", Queued: true}); err != nil {
+		t.Fatalf("add error: %v", err)
+	}
+
+	cancelled, err := Cancel(path, "q1")
+	if err != nil {
+		t.Fatalf("cancel error: %v", err)
+	}
+	if cancelled.Queued || cancelled.Label(false) != "cancelled" {
+		t.Fatalf("cancelled run = %+v, label %q", cancelled, cancelled.Label(false))
+	}
+	if _, err := Cancel(path, "q1"); err == nil {
+		t.Fatal("expected error cancelling a run that is no longer queued")
+	}
+}
+
+func TestMutateConcurrentAdds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.json")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := Add(path, Run{ID: strconv.Itoa(i)}); err != nil {
+				t.Errorf("add error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	all, err := Load(path)
+	if err != nil {
+		t.Fatalf("load error: %v", err)
+	}
+	if len(all) != 20 {
+		t.Fatalf("got %d runs, want 20 (updates were lost)", len(all))
+	}
+}
+
+func TestLatest(t *testing.T) {
+	base := time.Date(2026, 2, 19, 6, 0, 0, 0, time.UTC)
+	latest := Latest([]Run{
+		{ID: "a1", Branch: "a", StartedAt: base},
+		{ID: "a2", Branch: "a", StartedAt: base.Add(time.Minute)},
+		{ID: "b1", Branch: "b", StartedAt: base},
+	})
+	if latest["a"].ID != "a2" {
+		t.Fatalf("latest a = %q, want a2", latest["a"].ID)
+	}
+	if latest["b"].ID != "b1" {
+		t.Fatalf("latest b = %q, want b1", latest["b"].ID)
+	}
+}
@@ -0,0 +1,42 @@
+package session
+
+import (
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// fileCache remembers what was parsed from session files, keyed by path.
+// An entry is reused while the file's size and modification time are
+// unchanged, so repeated scans (the br TUI refreshes every few seconds) only
+// re-read the transcripts that grew since.
+type fileCache[T any] struct {
+	mu      sync.Mutex
+	entries map[string]cachedFile[T]
+}
+
+type cachedFile[T any] struct {
+	modTime time.Time
+	size    int64
+	value   T
+}
+
+// get returns the cached value for the file at path, described by fi,
+// calling parse when there is none or the file has changed.
+func (c *fileCache[T]) get(path string, fi fs.FileInfo, parse func(path string) T) T {
+	c.mu.Lock()
+	entry, ok := c.entries[path]
+	c.mu.Unlock()
+	if ok && entry.size == fi.Size() && entry.modTime.Equal(fi.ModTime()) {
+		return entry.value
+	}
+
+	value := parse(path)
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = make(map[string]cachedFile[T])
+	}
+	c.entries[path] = cachedFile[T]{modTime: fi.ModTime(), size: fi.Size(), value: value}
+	c.mu.Unlock()
+	return value
+}
@@ -0,0 +1,48 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileCacheRereadsOnlyChangedFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	if err := os.WriteFile(path, []byte("one\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var cache fileCache[string]
+	parses := 0
+	parse := func(path string) string {
+		parses++
+		data, _ := os.ReadFile(path)
+		return string(data)
+	}
+	get := func() string {
+		t.Helper()
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cache.get(path, fi, parse)
+	}
+
+	if got := get(); got != "one\n" || parses != 1 {
+		t.Fatalf("first get = %q after %d parses", got, parses)
+	}
+	if got := get(); got != "one\n" || parses != 1 {
+		t.Fatalf("unchanged file was parsed again: %q after %d parses", got, parses)
+	}
+
+	if err := os.WriteFile(path, []byte("one\ntwo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(); got != "one\ntwo\n" || parses != 2 {
+		t.Fatalf("changed file: %q after %d parses", got, parses)
+	}
+}
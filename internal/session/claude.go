@@ -0,0 +1,189 @@
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxTranscriptLine caps the size of a single JSONL record we are willing to
+// buffer. Longer records (large tool outputs) end the scan early.
+const maxTranscriptLine = 16 * 1024 * 1024
+
+// FindAllClaudeSessionInfos scans configDir/projects/ for Claude Code session
+// transcripts and returns the most recently updated SessionInfo for each cwd
+// in cwds. Claude stores transcripts in a directory named after the cwd with
+// every non-alphanumeric character replaced by '-', so only those directories
+// are read. Cwds with no matching session are omitted from the returned map.
+func FindAllClaudeSessionInfos(configDir string, cwds []string) (map[string]SessionInfo, error) {
+	result := make(map[string]SessionInfo, len(cwds))
+	for _, cwd := range cwds {
+		projectDir := filepath.Join(configDir, "projects", claudeProjectDirName(cwd))
+		entries, err := os.ReadDir(projectDir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		var best SessionInfo
+		for _, e := range entries {
+			if e.IsDir() || filepath.Ext(e.Name()) != ".jsonl" {
+				continue
+			}
+			fi, err := e.Info()
+			if err != nil {
+				continue
+			}
+			t := claudeTranscripts.get(filepath.Join(projectDir, e.Name()), fi, func(path string) claudeTranscript {
+				info, cwd := parseClaudeTranscript(path)
+				return claudeTranscript{info: info, cwd: cwd}
+			})
+			info := t.info
+			if info.SessionID == "" || (t.cwd != "" && t.cwd != cwd) {
+				continue
+			}
+			if best.SessionID == "" || info.UpdatedAt.After(best.UpdatedAt) {
+				best = info
+			}
+		}
+		if best.SessionID != "" {
+			result[cwd] = best
+		}
+	}
+	return result, nil
+}
+
+// claudeTranscript is what a Claude transcript says about its session.
+type claudeTranscript struct {
+	info SessionInfo
+	cwd  string
+}
+
+// claudeTranscripts caches parsed transcripts across scans.
+var claudeTranscripts fileCache[claudeTranscript]
+
+// claudeProjectDirName mirrors Claude Code's encoding of a cwd into a
+// directory name under projects/.
+func claudeProjectDirName(cwd string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '-'
+	}, cwd)
+}
+
+type claudeRecord struct {
+	Type      string          `json:"type"`
+	SessionID string          `json:"sessionId"`
+	Cwd       string          `json:"cwd"`
+	Timestamp string          `json:"timestamp"`
+	Summary   string          `json:"summary"`
+	Message   json.RawMessage `json:"message"`
+}
+
+// parseClaudeTranscript reads a session .jsonl and returns its id, summary and
+// last activity time, plus the cwd recorded in the transcript. A "summary"
+// record wins over the first user prompt as the session summary.
+func parseClaudeTranscript(path string) (SessionInfo, string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return SessionInfo{}, ""
+	}
+	defer f.Close()
+
+	info := SessionInfo{SessionID: strings.TrimSuffix(filepath.Base(path), ".jsonl")}
+	var cwd, firstPrompt string
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxTranscriptLine)
+	for scanner.Scan() {
+		var rec claudeRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		if rec.SessionID != "" {
+			info.SessionID = rec.SessionID
+		}
+		if cwd == "" && rec.Cwd != "" {
+			cwd = rec.Cwd
+		}
+		if t, err := time.Parse(time.RFC3339Nano, rec.Timestamp); err == nil && t.After(info.UpdatedAt) {
+			info.UpdatedAt = t
+		}
+		switch rec.Type {
+		case "summary":
+			if info.Summary == "" {
+				info.Summary = firstLine(rec.Summary)
+			}
+		case "user":
+			if firstPrompt == "" {
+				firstPrompt = promptSummary(claudeMessageText(rec.Message))
+			}
+		}
+	}
+
+	if info.Summary == "" {
+		info.Summary = firstPrompt
+	}
+	if info.UpdatedAt.IsZero() {
+		if st, err := os.Stat(path); err == nil {
+			info.UpdatedAt = st.ModTime()
+		}
+	}
+	return info, cwd
+}
+
+// claudeMessageText extracts plain text from a user message whose content is
+// either a string or a list of typed content blocks.
+func claudeMessageText(raw json.RawMessage) string {
+	var msg struct {
+		Content json.RawMessage `json:"content"`
+	}
+	if len(raw) == 0 || json.Unmarshal(raw, &msg) != nil {
+		return ""
+	}
+
+	var text string
+	if json.Unmarshal(msg.Content, &text) == nil {
+		return text
+	}
+
+	var blocks []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if json.Unmarshal(msg.Content, &blocks) == nil {
+		for _, b := range blocks {
+			if b.Type == "text" && b.Text != "" {
+				return b.Text
+			}
+		}
+	}
+	return ""
+}
+
+// promptSummary returns the first line of a user prompt, skipping injected
+// context blocks such as <command-name> or <environment_context>.
+func promptSummary(text string) string {
+	line := firstLine(text)
+	if strings.HasPrefix(line, "<") {
+		return ""
+	}
+	return line
+}
+
+// firstLine returns the first non-empty trimmed line of s.
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			return trimmed
+		}
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeClaudeTranscript(t *testing.T, configDir, cwd, id string, lines []string) string {
+	t.Helper()
+	projectDir := filepath.Join(configDir, "projects", claudeProjectDirName(cwd))
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(projectDir, id+".jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestClaudeProjectDirName(t *testing.T) {
+	got := claudeProjectDirName("/home/me/.fitz/acme/repo/feature_x")
+	want := "-home-me--fitz-acme-repo-feature-x"
+	if got != want {
+		t.Fatalf("claudeProjectDirName = %q, want %q", got, want)
+	}
+}
+
+func TestFindAllClaudeSessionInfos_NoProjects(t *testing.T) {
+	got, err := FindAllClaudeSessionInfos(t.TempDir(), []string{"/my/worktree"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("got %v, want empty", got)
+	}
+}
+
+func TestFindAllClaudeSessionInfos_PicksLatest(t *testing.T) {
+	dir := t.TempDir()
+	cwd := "/my/worktree"
+
+	writeClaudeTranscript(t, dir, cwd, "old-session", []string{
+		`{"type":"user","sessionId":"old-session","cwd":"/my/worktree","timestamp":"2026-01-01T00:00:00.000Z","message":{"role":"user","content":"old prompt"}}`,
+	})
+	writeClaudeTranscript(t, dir, cwd, "new-session", []string{
+		`{"type":"summary","summary":"Fix login redirect\nmore detail","leafUuid":"x"}`,
+		`{"type":"user","sessionId":"new-session","cwd":"/my/worktree","timestamp":"2026-02-15T12:00:00.000Z","message":{"role":"user","content":"fix it"}}`,
+		`{"type":"assistant","sessionId":"new-session","cwd":"/my/worktree","timestamp":"2026-02-15T12:05:00.000Z","message":{"role":"assistant","content":[{"type":"text","text":"done"}]}}`,
+	})
+
+	got, err := FindAllClaudeSessionInfos(dir, []string{cwd, "/other"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info, ok := got[cwd]
+	if !ok {
+		t.Fatalf("no session for %s: %v", cwd, got)
+	}
+	if info.SessionID != "new-session" {
+		t.Errorf("SessionID = %q, want new-session", info.SessionID)
+	}
+	if info.Summary != "Fix login redirect" {
+		t.Errorf("Summary = %q, want %q", info.Summary, "Fix login redirect")
+	}
+	want := time.Date(2026, 2, 15, 12, 5, 0, 0, time.UTC)
+	if !info.UpdatedAt.Equal(want) {
+		t.Errorf("UpdatedAt = %v, want %v", info.UpdatedAt, want)
+	}
+	if _, ok := got["/other"]; ok {
+		t.Error("expected no entry for /other")
+	}
+}
+
+func TestFindAllClaudeSessionInfos_FirstPromptSummary(t *testing.T) {
+	dir := t.TempDir()
+	cwd := "/my/worktree"
+
+	writeClaudeTranscript(t, dir, cwd, "sess", []string{
+		`{"type":"user","sessionId":"sess","cwd":"/my/worktree","timestamp":"2026-01-01T00:00:00.000Z","message":{"role":"user","content":"<command-name>/init</command-name>"}}`,
+		`{"type":"user","sessionId":"sess","cwd":"/my/worktree","timestamp":"2026-01-01T00:01:00.000Z","message":{"role":"user","content":[{"type":"text","text":"Add rate limiting\nto the API"}]}}`,
+	})
+
+	got, err := FindAllClaudeSessionInfos(dir, []string{cwd})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got[cwd].Summary != "Add rate limiting" {
+		t.Errorf("Summary = %q, want %q", got[cwd].Summary, "Add rate limiting")
+	}
+}
+
+func TestFindAllClaudeSessionInfos_SkipsCollidingCwd(t *testing.T) {
+	dir := t.TempDir()
+
+	// "/a-b" and "/a/b" encode to the same project directory.
+	writeClaudeTranscript(t, dir, "/a/b", "sess", []string{
+		`{"type":"user","sessionId":"sess","cwd":"/a/b","timestamp":"2026-01-01T00:00:00.000Z","message":{"role":"user","content":"hi"}}`,
+	})
+
+	got, err := FindAllClaudeSessionInfos(dir, []string{"/a-b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("got %v, want no match for colliding cwd", got)
+	}
+}
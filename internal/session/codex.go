@@ -0,0 +1,150 @@
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FindAllCodexSessionInfos walks configDir/sessions/ for Codex CLI rollout
+// files and returns the most recently updated SessionInfo for each cwd in
+// cwds. Rollouts are append-only, so the file modification time is used as
+// the update time. Cwds with no matching session are omitted from the
+// returned map.
+func FindAllCodexSessionInfos(configDir string, cwds []string) (map[string]SessionInfo, error) {
+	if len(cwds) == 0 {
+		return map[string]SessionInfo{}, nil
+	}
+
+	cwdSet := make(map[string]bool, len(cwds))
+	for _, cwd := range cwds {
+		cwdSet[cwd] = true
+	}
+
+	best := make(map[string]SessionInfo)
+	root := filepath.Join(configDir, "sessions")
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".jsonl" {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		meta := codexMetas.get(path, fi, func(path string) codexMeta {
+			id, cwd := parseCodexMeta(path)
+			return codexMeta{id: id, cwd: cwd}
+		})
+		if meta.id == "" || !cwdSet[meta.cwd] {
+			return nil
+		}
+		if c, ok := best[meta.cwd]; ok && !fi.ModTime().After(c.UpdatedAt) {
+			return nil
+		}
+		best[meta.cwd] = SessionInfo{SessionID: meta.id, Summary: codexSummaries.get(path, fi, parseCodexSummary), UpdatedAt: fi.ModTime()}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return best, nil
+}
+
+// codexMeta is the session id and cwd recorded at the top of a rollout.
+type codexMeta struct {
+	id, cwd string
+}
+
+// codexMetas and codexSummaries cache what was read from rollouts across
+// scans, so only new or grown files are opened again.
+var (
+	codexMetas     fileCache[codexMeta]
+	codexSummaries fileCache[string]
+)
+
+type codexRecord struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+
+	// Legacy rollouts put session metadata at the top level of the first line.
+	ID  string `json:"id"`
+	Cwd string `json:"cwd"`
+}
+
+type codexPayload struct {
+	Type    string `json:"type"`
+	ID      string `json:"id"`
+	Cwd     string `json:"cwd"`
+	Message string `json:"message"`
+}
+
+// parseCodexMeta reads the session_meta record on the first line of a
+// rollout and returns the session id and cwd.
+func parseCodexMeta(path string) (id, cwd string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxTranscriptLine)
+	if !scanner.Scan() {
+		return "", ""
+	}
+
+	var rec codexRecord
+	if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+		return "", ""
+	}
+	if rec.Type == "session_meta" {
+		var p codexPayload
+		if err := json.Unmarshal(rec.Payload, &p); err != nil {
+			return "", ""
+		}
+		return p.ID, p.Cwd
+	}
+	return rec.ID, rec.Cwd
+}
+
+// parseCodexSummary returns the first line of the first user message in a
+// rollout. Codex does not write session summaries, so the opening prompt is
+// the closest equivalent.
+func parseCodexSummary(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxTranscriptLine)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if !strings.Contains(string(line), `"user_message"`) {
+			continue
+		}
+		var rec codexRecord
+		if err := json.Unmarshal(line, &rec); err != nil || rec.Type != "event_msg" {
+			continue
+		}
+		var p codexPayload
+		if err := json.Unmarshal(rec.Payload, &p); err != nil || p.Type != "user_message" {
+			continue
+		}
+		if summary := promptSummary(p.Message); summary != "" {
+			return summary
+		}
+	}
+	return ""
+}
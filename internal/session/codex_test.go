@@ -0,0 +1,85 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeCodexRollout(t *testing.T, configDir, day, name string, lines []string, modTime time.Time) {
+	t.Helper()
+	dayDir := filepath.Join(configDir, "sessions", day)
+	if err := os.MkdirAll(dayDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dayDir, name)
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindAllCodexSessionInfos_NoSessions(t *testing.T) {
+	got, err := FindAllCodexSessionInfos(t.TempDir(), []string{"/my/worktree"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("got %v, want empty", got)
+	}
+}
+
+func TestFindAllCodexSessionInfos_PicksLatest(t *testing.T) {
+	dir := t.TempDir()
+	older := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2026, 2, 15, 12, 0, 0, 0, time.UTC)
+
+	writeCodexRollout(t, dir, "2026/01/01", "rollout-a.jsonl", []string{
+		`{"timestamp":"2026-01-01T00:00:00Z","type":"session_meta","payload":{"id":"old-id","cwd":"/my/worktree"}}`,
+	}, older)
+	writeCodexRollout(t, dir, "2026/02/15", "rollout-b.jsonl", []string{
+		`{"timestamp":"2026-02-15T11:00:00Z","type":"session_meta","payload":{"id":"new-id","cwd":"/my/worktree"}}`,
+		`{"timestamp":"2026-02-15T11:00:01Z","type":"event_msg","payload":{"type":"user_message","message":"<environment_context>...</environment_context>"}}`,
+		`{"timestamp":"2026-02-15T11:00:02Z","type":"event_msg","payload":{"type":"user_message","message":"Refactor the parser\nplease"}}`,
+	}, newer)
+	writeCodexRollout(t, dir, "2026/02/15", "rollout-c.jsonl", []string{
+		`{"timestamp":"2026-02-15T11:00:00Z","type":"session_meta","payload":{"id":"other-id","cwd":"/other"}}`,
+	}, newer)
+
+	got, err := FindAllCodexSessionInfos(dir, []string{"/my/worktree"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want 1: %v", len(got), got)
+	}
+	info := got["/my/worktree"]
+	if info.SessionID != "new-id" {
+		t.Errorf("SessionID = %q, want new-id", info.SessionID)
+	}
+	if info.Summary != "Refactor the parser" {
+		t.Errorf("Summary = %q, want %q", info.Summary, "Refactor the parser")
+	}
+	if !info.UpdatedAt.Equal(newer) {
+		t.Errorf("UpdatedAt = %v, want %v", info.UpdatedAt, newer)
+	}
+}
+
+func TestFindAllCodexSessionInfos_LegacyMeta(t *testing.T) {
+	dir := t.TempDir()
+	writeCodexRollout(t, dir, "2025/06/01", "rollout-legacy.jsonl", []string{
+		`{"id":"legacy-id","cwd":"/my/worktree","timestamp":"2025-06-01T00:00:00Z"}`,
+	}, time.Now())
+
+	got, err := FindAllCodexSessionInfos(dir, []string{"/my/worktree"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got["/my/worktree"].SessionID != "legacy-id" {
+		t.Fatalf("got %v, want legacy-id", got)
+	}
+}
@@ -8,7 +8,7 @@ import (
 	"time"
 )
 
-// SessionInfo holds metadata about a Copilot session for a worktree.
+// SessionInfo holds metadata about an agent session for a worktree.
 type SessionInfo struct {
 	SessionID string
 	Summary   string    // first line of the session summary, empty if none
@@ -0,0 +1,141 @@
+package status
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"fitz/internal/fileutil"
+)
+
+// Event is one status update in a branch's history.
+type Event struct {
+	Message string    `json:"message,omitempty"`
+	PRURL   string    `json:"pr_url,omitempty"`
+	State   string    `json:"state,omitempty"`
+	Detail  string    `json:"detail,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// HistoryPath returns the append-only event log of branch, kept in a
+// .history directory next to the status store at storePath. Each line is a
+// JSON-encoded Event.
+func HistoryPath(storePath, branch string) string {
+	return filepath.Join(historyDir(storePath), url.PathEscape(branch)+".jsonl")
+}
+
+func historyDir(storePath string) string {
+	return filepath.Join(filepath.Dir(storePath), ".history")
+}
+
+// AppendEvent adds e to the end of branch's history.
+func AppendEvent(storePath, branch string, e Event) error {
+	path := HistoryPath(storePath, branch)
+	unlock, err := fileutil.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open history: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("write history: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write history: %w", err)
+	}
+	return nil
+}
+
+// LoadHistory returns branch's events, oldest first. Lines that can't be
+// parsed are skipped.
+func LoadHistory(storePath, branch string) ([]Event, error) {
+	f, err := os.Open(HistoryPath(storePath, branch))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err == nil {
+			events = append(events, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+	return events, nil
+}
+
+// removeHistory deletes branch's history and then its lock file. Callers
+// hold the status lock, under which every history is appended to, so no
+// writer can be waiting on the lock file as it goes.
+func removeHistory(storePath, branch string) error {
+	path := HistoryPath(storePath, branch)
+	lockPath := path + ".lock"
+	if !exists(path) && !exists(lockPath) {
+		return nil
+	}
+	unlock, err := fileutil.Lock(path)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	unlock()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove history: %w", err)
+	}
+	if err := os.Remove(lockPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove history lock: %w", err)
+	}
+	return nil
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// HistoryBranches returns the branches that have a history, or a history lock file
+// left behind, next to the status store at storePath.
+func HistoryBranches(storePath string) ([]string, error) {
+	entries, err := os.ReadDir(historyDir(storePath))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+	var branches []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(strings.TrimSuffix(entry.Name(), ".lock"), ".jsonl")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if branch, err := url.PathUnescape(name); err == nil && !slices.Contains(branches, branch) {
+			branches = append(branches, branch)
+		}
+	}
+	return branches, nil
+}
@@ -0,0 +1,119 @@
+package status
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestHistoryRecordsEveryUpdateInOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.json")
+
+	for _, msg := range []string{"running tests", "fixing lint"} {
+		if _, err := SetStatus(path, "feat/auth", msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := SetPR(path, "feat/auth", "https://github.com/acme/repo/pull/7"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SetStatus(path, "other", "unrelated"); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := LoadHistory(path, "feat/auth")
+	if err != nil {
+		t.Fatalf("load history: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3: %+v", len(events), events)
+	}
+	if events[0].Message != "running tests" || events[1].Message != "fixing lint" || events[2].PRURL != "https://github.com/acme/repo/pull/7" {
+		t.Fatalf("events out of order: %+v", events)
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].At.Before(events[i-1].At) {
+			t.Fatalf("event %d is older than the one before it: %+v", i, events)
+		}
+	}
+
+	entries, _ := Load(path)
+	if entries["feat/auth"].Message != "fixing lint" {
+		t.Fatalf("latest status = %+v, want fixing lint", entries["feat/auth"])
+	}
+}
+
+func TestConcurrentUpdatesKeepHistoryInStatusOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.json")
+	const writers, updates = 8, 10
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < updates; i++ {
+				if _, err := SetStatus(path, "feat", fmt.Sprintf("writer %d step %d", w, i)); err != nil {
+					t.Errorf("set status: %v", err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	events, err := LoadHistory(path, "feat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != writers*updates {
+		t.Fatalf("got %d events, want %d", len(events), writers*updates)
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].At.Before(events[i-1].At) {
+			t.Fatalf("event %d (%s) is older than the one before it (%s)", i, events[i].At, events[i-1].At)
+		}
+	}
+	entries, _ := Load(path)
+	if last := events[len(events)-1]; last.Message != entries["feat"].Message || !last.At.Equal(entries["feat"].UpdatedAt) {
+		t.Fatalf("last event = %+v, status = %+v; want the same update", last, entries["feat"])
+	}
+}
+
+func TestHistoryPathEscapesBranch(t *testing.T) {
+	got := HistoryPath("/home/u/.fitz/acme/repo/status.json", "feat/auth")
+	want := filepath.Join("/home/u/.fitz/acme/repo", ".history", "feat%2Fauth.jsonl")
+	if got != want {
+		t.Fatalf("HistoryPath = %q, want %q", got, want)
+	}
+}
+
+func TestLoadHistorySkipsBadLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.json")
+	if events, err := LoadHistory(path, "none"); err != nil || events != nil {
+		t.Fatalf("missing history = %+v, %v; want nil, nil", events, err)
+	}
+
+	if err := AppendEvent(path, "b", Event{Message: "one"}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(HistoryPath(path, "b"), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("{\"message\": \"trunc\n")
+	f.Close()
+	if err := AppendEvent(path, "b", Event{Message: "two"}); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := LoadHistory(path, "b")
+	if err != nil {
+		t.Fatalf("load history: %v", err)
+	}
+	if len(events) != 2 || events[0].Message != "one" || events[1].Message != "two" {
+		t.Fatalf("events = %+v, want one and two", events)
+	}
+}
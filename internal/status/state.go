@@ -0,0 +1,87 @@
+package status
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The machine states an agent can report with `fitz agent status --state`.
+const (
+	StateWorking    = "working"
+	StateBlocked    = "blocked"
+	StateNeedsInput = "needs-input"
+	StateDone       = "done"
+	StateFailed     = "failed"
+)
+
+// States lists the valid agent states.
+var States = []string{StateWorking, StateBlocked, StateNeedsInput, StateDone, StateFailed}
+
+// AgentState is a structured state report from an agent. Each report
+// replaces the previous one as a whole.
+type AgentState struct {
+	State    string   `json:"state,omitempty"`
+	Progress *float64 `json:"progress,omitempty"` // fraction done, 0 to 1
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// ValidState reports whether state is one of States.
+func ValidState(state string) bool {
+	for _, s := range States {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsAttention reports whether the agent is waiting on a person.
+func (s AgentState) NeedsAttention() bool {
+	return s.State == StateBlocked || s.State == StateNeedsInput
+}
+
+// ParseProgress reads a progress fraction written as "3/5", "60%" or "0.6".
+func ParseProgress(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	invalid := fmt.Errorf("invalid progress: %s (use a fraction like 3/5, 60%% or 0.6)", value)
+
+	var p float64
+	switch {
+	case strings.Contains(value, "/"):
+		num, den, _ := strings.Cut(value, "/")
+		n, err1 := strconv.ParseFloat(strings.TrimSpace(num), 64)
+		d, err2 := strconv.ParseFloat(strings.TrimSpace(den), 64)
+		if err1 != nil || err2 != nil || d <= 0 {
+			return 0, invalid
+		}
+		p = n / d
+	case strings.HasSuffix(value, "%"):
+		n, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(value, "%")), 64)
+		if err != nil {
+			return 0, invalid
+		}
+		p = n / 100
+	default:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, invalid
+		}
+		p = n
+	}
+	if p < 0 || p > 1 {
+		return 0, invalid
+	}
+	return p, nil
+}
+
+// SetState replaces branch's agent state with s. It also returns the state
+// it replaced, so callers can react to transitions.
+func SetState(path, branch string, s AgentState) (BranchStatus, string, error) {
+	var previous string
+	entry, err := update(path, branch, func(entry *BranchStatus) {
+		previous = entry.State
+		entry.AgentState = s
+	}, Event{State: s.State, Detail: s.Detail})
+	return entry, previous, err
+}
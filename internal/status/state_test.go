@@ -0,0 +1,74 @@
+package status
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSetStateReplacesStateAndKeepsMessage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.json")
+	if _, err := SetStatus(path, "feature-auth", "Implementing auth"); err != nil {
+		t.Fatalf("set status error: %v", err)
+	}
+
+	half := 0.5
+	_, previous, err := SetState(path, "feature-auth", AgentState{State: StateWorking, Progress: &half, Detail: "step 2"})
+	if err != nil {
+		t.Fatalf("set state error: %v", err)
+	}
+	if previous != "" {
+		t.Fatalf("previous = %q, want empty", previous)
+	}
+
+	entry, previous, err := SetState(path, "feature-auth", AgentState{State: StateNeedsInput})
+	if err != nil {
+		t.Fatalf("set state error: %v", err)
+	}
+	if previous != StateWorking {
+		t.Fatalf("previous = %q, want working", previous)
+	}
+	if entry.State != StateNeedsInput || entry.Progress != nil || entry.Detail != "" {
+		t.Fatalf("entry = %+v, want the new state to replace progress and detail", entry)
+	}
+
+	entries, err := Load(path)
+	if err != nil {
+		t.Fatalf("load error: %v", err)
+	}
+	if got := entries["feature-auth"]; got.Message != "Implementing auth" || got.State != StateNeedsInput {
+		t.Fatalf("stored entry = %+v", got)
+	}
+
+	events, err := LoadHistory(path, "feature-auth")
+	if err != nil {
+		t.Fatalf("load history error: %v", err)
+	}
+	if len(events) != 3 || events[1].State != StateWorking || events[1].Detail != "step 2" || events[2].State != StateNeedsInput {
+		t.Fatalf("events = %+v", events)
+	}
+}
+
+func TestParseProgress(t *testing.T) {
+	for value, want := range map[string]float64{"3/5": 0.6, "60%": 0.6, "0.6": 0.6, "0": 0, "1": 1, " 2 / 4 ": 0.5} {
+		got, err := ParseProgress(value)
+		if err != nil || got != want {
+			t.Errorf("ParseProgress(%q) = %v, %v, want %v", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "abc", "3/0", "6/5", "150%", "-0.1"} {
+		if _, err := ParseProgress(value); err == nil {
+			t.Errorf("ParseProgress(%q) succeeded, want error", value)
+		}
+	}
+}
+
+func TestValidState(t *testing.T) {
+	for _, s := range States {
+		if !ValidState(s) {
+			t.Errorf("ValidState(%q) = false", s)
+		}
+	}
+	if ValidState("idle") || ValidState("") {
+		t.Fatal("ValidState accepted an unknown state")
+	}
+}
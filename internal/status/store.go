@@ -7,12 +7,15 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"fitz/internal/fileutil"
 )
 
 type BranchStatus struct {
 	Message   string    `json:"message,omitempty"`
 	PRURL     string    `json:"pr_url,omitempty"`
 	UpdatedAt time.Time `json:"updated_at"`
+	AgentState
 }
 
 func StorePath(homeDir, owner, repo string) (string, error) {
@@ -57,40 +60,72 @@ func Save(path string, entries map[string]BranchStatus) error {
 	}
 	data = append(data, '\n')
 
-	if err := os.WriteFile(path, data, 0o644); err != nil {
+	if err := fileutil.WriteFile(path, data, 0o644); err != nil {
 		return fmt.Errorf("write status: %w", err)
 	}
 	return nil
 }
 
-func SetStatus(path, branch, message string) (BranchStatus, error) {
+// Mutate applies fn to the entries at path while holding an exclusive lock,
+// and saves them. Use it for any read-modify-write so that agents updating
+// their status at the same time don't lose each other's updates.
+func Mutate(path string, fn func(map[string]BranchStatus) error) error {
+	unlock, err := fileutil.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	entries, err := Load(path)
 	if err != nil {
-		return BranchStatus{}, err
+		return err
 	}
+	if err := fn(entries); err != nil {
+		return err
+	}
+	return Save(path, entries)
+}
 
-	entry := entries[branch]
-	entry.Message = message
-	entry.UpdatedAt = time.Now().UTC()
-	entries[branch] = entry
-	if err := Save(path, entries); err != nil {
+// update applies fn to branch's entry, stamps it, records e in the branch's
+// history and saves the entry. The event is appended under the status lock,
+// so concurrent updates reach the history in the order of their UpdatedAt.
+func update(path, branch string, fn func(*BranchStatus), e Event) (BranchStatus, error) {
+	var entry BranchStatus
+	err := Mutate(path, func(entries map[string]BranchStatus) error {
+		entry = entries[branch]
+		fn(&entry)
+		entry.UpdatedAt = time.Now().UTC()
+		entries[branch] = entry
+		e.At = entry.UpdatedAt
+		if err := AppendEvent(path, branch, e); err != nil {
+			return fmt.Errorf("record history: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
 		return BranchStatus{}, err
 	}
 	return entry, nil
 }
 
+func SetStatus(path, branch, message string) (BranchStatus, error) {
+	return update(path, branch, func(entry *BranchStatus) { entry.Message = message }, Event{Message: message})
+}
+
 func SetPR(path, branch, prURL string) (BranchStatus, error) {
-	entries, err := Load(path)
-	if err != nil {
-		return BranchStatus{}, err
-	}
+	return update(path, branch, func(entry *BranchStatus) { entry.PRURL = prURL }, Event{PRURL: prURL})
+}
 
-	entry := entries[branch]
-	entry.PRURL = prURL
-	entry.UpdatedAt = time.Now().UTC()
-	entries[branch] = entry
-	if err := Save(path, entries); err != nil {
-		return BranchStatus{}, err
-	}
-	return entry, nil
+// Remove deletes the entries and history of branches, so that a branch
+// created later with the same name starts with a clean status.
+func Remove(path string, branches ...string) error {
+	return Mutate(path, func(entries map[string]BranchStatus) error {
+		for _, branch := range branches {
+			delete(entries, branch)
+			if err := removeHistory(path, branch); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
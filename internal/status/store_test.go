@@ -1,7 +1,11 @@
 package status
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 )
@@ -113,3 +117,122 @@ func TestStorePath(t *testing.T) {
 		t.Fatalf("path = %q, want %q", path, want)
 	}
 }
+
+func TestConcurrentWritersLoseNoUpdates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.json")
+	const writers, updates = 16, 25
+
+	stop := make(chan struct{})
+	readerDone := make(chan struct{})
+	go func() {
+		defer close(readerDone)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if _, err := Load(path); err != nil {
+				t.Errorf("load during writes: %v", err)
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			branch := fmt.Sprintf("agent-%d", w)
+			for i := 0; i < updates; i++ {
+				if _, err := SetStatus(path, branch, fmt.Sprintf("step %d", i)); err != nil {
+					t.Errorf("set status: %v", err)
+					return
+				}
+				if _, err := SetPR(path, branch, fmt.Sprintf("https://github.com/acme/repo/pull/%d", i)); err != nil {
+					t.Errorf("set pr: %v", err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(stop)
+	<-readerDone
+
+	entries, err := Load(path)
+	if err != nil {
+		t.Fatalf("load error: %v", err)
+	}
+	if len(entries) != writers {
+		t.Fatalf("got %d branches, want %d (updates were lost)", len(entries), writers)
+	}
+	last := updates - 1
+	for w := 0; w < writers; w++ {
+		entry := entries[fmt.Sprintf("agent-%d", w)]
+		if entry.Message != fmt.Sprintf("step %d", last) || entry.PRURL != fmt.Sprintf("https://github.com/acme/repo/pull/%d", last) {
+			t.Fatalf("agent-%d = %+v, want its last status and PR", w, entry)
+		}
+	}
+}
+
+func TestRemoveDeletesEntriesAndHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.json")
+	for _, branch := range []string{"feat/auth", "fix-login", "docs"} {
+		if _, err := SetStatus(path, branch, "working on "+branch); err != nil {
+			t.Fatalf("set status error: %v", err)
+		}
+	}
+
+	if err := Remove(path, "feat/auth", "docs", "never-existed"); err != nil {
+		t.Fatalf("remove error: %v", err)
+	}
+
+	entries, err := Load(path)
+	if err != nil {
+		t.Fatalf("load error: %v", err)
+	}
+	if _, ok := entries["fix-login"]; len(entries) != 1 || !ok {
+		t.Fatalf("entries = %v, want only fix-login", entries)
+	}
+	branches, err := HistoryBranches(path)
+	if err != nil {
+		t.Fatalf("history branches error: %v", err)
+	}
+	if len(branches) != 1 || branches[0] != "fix-login" {
+		t.Fatalf("history branches = %v, want [fix-login]", branches)
+	}
+}
+
+func TestRemoveDeletesHistoryLockFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.json")
+	if _, err := SetStatus(path, "feat/auth", "working"); err != nil {
+		t.Fatal(err)
+	}
+	// A lock file left behind without its history, as older versions did.
+	orphan := HistoryPath(path, "gone") + ".lock"
+	if err := os.WriteFile(orphan, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	branches, err := HistoryBranches(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(branches)
+	if !slices.Equal(branches, []string{"feat/auth", "gone"}) {
+		t.Fatalf("history branches = %v, want the orphaned lock's branch too", branches)
+	}
+
+	if err := Remove(path, "feat/auth", "gone"); err != nil {
+		t.Fatalf("remove error: %v", err)
+	}
+	left, err := os.ReadDir(filepath.Join(filepath.Dir(path), ".history"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 0 {
+		t.Fatalf(".history still holds %v", left)
+	}
+}
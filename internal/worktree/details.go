@@ -0,0 +1,106 @@
+package worktree
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Details is the git state of a worktree shown in the `fitz br` detail pane.
+type Details struct {
+	Path string
+	// Base is the branch Ahead and Behind are counted against, or "" when
+	// it couldn't be compared (for example, no remote).
+	Base    string
+	Ahead   int
+	Behind  int
+	Changes []string // `git status --short` lines
+	Commits []string // most recent first, "<short hash> <subject>"
+}
+
+// LoadDetails reads the git state of the worktree at path, comparing it with
+// base and listing up to commits recent commits. An unknown base is not an
+// error; Details.Base is left empty instead.
+func LoadDetails(git GitRunner, path, base string, commits int) (Details, error) {
+	d := Details{Path: path}
+
+	status, err := git.Run(path, "status", "--short")
+	if err != nil {
+		return d, fmt.Errorf("git status: %w", err)
+	}
+	d.Changes = nonEmptyLines(status)
+
+	log, err := git.Run(path, "log", "-n", strconv.Itoa(commits), "--format=%h %s")
+	if err != nil {
+		return d, fmt.Errorf("git log: %w", err)
+	}
+	d.Commits = nonEmptyLines(log)
+
+	if base != "" {
+		counts, err := git.Run(path, "rev-list", "--left-right", "--count", base+"...HEAD")
+		if err == nil {
+			if fields := strings.Fields(counts); len(fields) == 2 {
+				behind, errB := strconv.Atoi(fields[0])
+				ahead, errA := strconv.Atoi(fields[1])
+				if errA == nil && errB == nil {
+					d.Base, d.Ahead, d.Behind = base, ahead, behind
+				}
+			}
+		}
+	}
+
+	return d, nil
+}
+
+func nonEmptyLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, strings.TrimRight(line, "\r"))
+		}
+	}
+	return lines
+}
+
+// Pending is the work in a worktree that removing it would lose.
+type Pending struct {
+	Changes  []string // `git status --short` lines
+	Unpushed []string // commits on no other branch, "<short hash> <subject>"
+}
+
+// Empty reports whether removing the worktree would lose nothing.
+func (p Pending) Empty() bool {
+	return len(p.Changes) == 0 && len(p.Unpushed) == 0
+}
+
+// Dirty reports whether the worktree at path has uncommitted changes,
+// untracked files included, which `git worktree remove` refuses to discard
+// without --force.
+func Dirty(git GitRunner, path string) (bool, error) {
+	status, err := git.Run(path, "status", "--porcelain")
+	if err != nil {
+		return false, fmt.Errorf("git status: %w", err)
+	}
+	return strings.TrimSpace(status) != "", nil
+}
+
+// LoadPending lists the uncommitted changes in the worktree at path and the
+// commits only branch has: those on no remote branch and no other local
+// branch, which deleting branch would lose.
+func LoadPending(git GitRunner, path, branch string) (Pending, error) {
+	var p Pending
+
+	status, err := git.Run(path, "status", "--short")
+	if err != nil {
+		return p, fmt.Errorf("git status: %w", err)
+	}
+	p.Changes = nonEmptyLines(status)
+
+	log, err := git.Run(path, "log", "HEAD", "--format=%h %s", "--not", "--remotes", "--exclude="+branch, "--branches")
+	if err != nil {
+		return p, fmt.Errorf("git log: %w", err)
+	}
+	p.Unpushed = nonEmptyLines(log)
+
+	return p, nil
+}
@@ -0,0 +1,100 @@
+package worktree
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLoadDetails(t *testing.T) {
+	git := &mockGit{
+		outputs: map[string]string{
+			"/wt:status --short ":                                   " M main.go\n?? notes.md\n",
+			"/wt:log -n 3 --format=%h %s ":                          "abc1234 Add login\ndef5678 Scaffold auth\n",
+			"/wt:rev-list --left-right --count origin/main...HEAD ": "1\t2\n",
+		},
+		errs: map[string]error{},
+	}
+
+	d, err := LoadDetails(git, "/wt", "origin/main", 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Path != "/wt" || d.Base != "origin/main" || d.Ahead != 2 || d.Behind != 1 {
+		t.Fatalf("details = %+v", d)
+	}
+	if len(d.Changes) != 2 || d.Changes[0] != " M main.go" {
+		t.Fatalf("changes = %q", d.Changes)
+	}
+	if len(d.Commits) != 2 || d.Commits[0] != "abc1234 Add login" {
+		t.Fatalf("commits = %q", d.Commits)
+	}
+}
+
+func TestLoadDetailsUnknownBase(t *testing.T) {
+	git := &mockGit{
+		outputs: map[string]string{
+			"/wt:status --short ":          "",
+			"/wt:log -n 5 --format=%h %s ": "abc1234 Init\n",
+		},
+		errs: map[string]error{
+			"/wt:rev-list --left-right --count origin/main...HEAD ": errors.New("unknown revision"),
+		},
+	}
+
+	d, err := LoadDetails(git, "/wt", "origin/main", 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Base != "" || len(d.Changes) != 0 || len(d.Commits) != 1 {
+		t.Fatalf("details = %+v", d)
+	}
+}
+
+func TestLoadDetailsStatusError(t *testing.T) {
+	git := &mockGit{outputs: map[string]string{}, errs: map[string]error{}}
+	if _, err := LoadDetails(git, "/gone", "", 5); err == nil {
+		t.Fatal("expected error for a missing worktree")
+	}
+}
+
+func TestLoadPending(t *testing.T) {
+	git := &mockGit{
+		outputs: map[string]string{
+			"/wt:status --short ": "?? notes.md\n",
+			"/wt:log HEAD --format=%h %s --not --remotes --exclude=feat --branches ": "abc1234 Add login\n",
+			"/clean:status --short ": "",
+			"/clean:log HEAD --format=%h %s --not --remotes --exclude=docs --branches ": "",
+		},
+		errs: map[string]error{},
+	}
+
+	p, err := LoadPending(git, "/wt", "feat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Empty() || len(p.Changes) != 1 || len(p.Unpushed) != 1 || p.Unpushed[0] != "abc1234 Add login" {
+		t.Fatalf("pending = %+v", p)
+	}
+
+	p, err = LoadPending(git, "/clean", "docs")
+	if err != nil || !p.Empty() {
+		t.Fatalf("pending = %+v, err = %v", p, err)
+	}
+}
+
+func TestDirty(t *testing.T) {
+	git := &mockGit{
+		outputs: map[string]string{
+			"/wt:status --porcelain ":    "?? notes.md\n",
+			"/clean:status --porcelain ": "",
+		},
+		errs: map[string]error{},
+	}
+
+	if dirty, err := Dirty(git, "/wt"); err != nil || !dirty {
+		t.Fatalf("Dirty(/wt) = %v, %v; want true", dirty, err)
+	}
+	if dirty, err := Dirty(git, "/clean"); err != nil || dirty {
+		t.Fatalf("Dirty(/clean) = %v, %v; want false", dirty, err)
+	}
+}
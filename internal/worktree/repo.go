@@ -57,6 +57,20 @@ func GitRoot(git GitRunner, dir string) (string, error) {
 	return strings.TrimSpace(output), nil
 }
 
+// CommonDir returns the absolute path of the git directory shared by all
+// worktrees of the repository at dir.
+func CommonDir(git GitRunner, dir string) (string, error) {
+	output, err := git.Run(dir, "rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", err
+	}
+	commonDir := strings.TrimSpace(output)
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(dir, commonDir)
+	}
+	return commonDir, nil
+}
+
 func IsWorktree(git GitRunner, dir string) (bool, error) {
 	commonDir, err := git.Run(dir, "rev-parse", "--git-common-dir")
 	if err != nil {
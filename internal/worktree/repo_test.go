@@ -181,6 +181,26 @@ func TestGitRoot(t *testing.T) {
 	}
 }
 
+func TestCommonDir(t *testing.T) {
+	git := &mockGit{
+		outputs: map[string]string{
+			"/repo:rev-parse --git-common-dir ":          ".git\n",
+			"/worktree/path:rev-parse --git-common-dir ": "/repo/.git\n",
+		},
+		errs: make(map[string]error),
+	}
+
+	for dir, want := range map[string]string{"/repo": "/repo/.git", "/worktree/path": "/repo/.git"} {
+		got, err := CommonDir(git, dir)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", dir, err)
+		}
+		if got != want {
+			t.Errorf("CommonDir(%s) = %q, want %q", dir, got, want)
+		}
+	}
+}
+
 func TestIsWorktree(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -3,7 +3,6 @@ package worktree
 import (
 	"errors"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -19,6 +18,9 @@ type WorktreeInfo struct {
 	Branch string
 	Name   string
 	Bare   bool
+	// Prunable is set when git would prune the worktree: its directory is
+	// gone or no longer points back at the repository.
+	Prunable bool
 }
 
 func ValidateName(name string) error {
@@ -190,12 +192,15 @@ func (m *Manager) RemoveAll(dir string, force bool) ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
+	return m.RemoveWorktrees(dir, list[min(1, len(list)):], force)
+}
 
+// RemoveWorktrees removes the worktrees wts, as listed by List, and their
+// branches, stopping at the first failure. Returns the names of removed
+// worktrees.
+func (m *Manager) RemoveWorktrees(dir string, wts []WorktreeInfo, force bool) ([]string, error) {
 	var removed []string
-	for i, wt := range list {
-		if i == 0 {
-			continue // skip root
-		}
+	for _, wt := range wts {
 		name := wt.Branch
 		if name == "" {
 			name = wt.Name
@@ -308,31 +313,6 @@ func (m *Manager) Current(dir string) (string, error) {
 	return "root", nil
 }
 
-// FormatList writes the worktree list to w, highlighting the current worktree
-// with a blue "* " prefix. The first entry is always labeled "root". Others
-// show their branch name (or directory name if detached). current should be
-// "root" or a worktree Name (directory basename) as returned by Current().
-func FormatList(w io.Writer, list []WorktreeInfo, current string) {
-	const blue = "\x1b[34m"
-	const reset = "\x1b[0m"
-
-	for i, wt := range list {
-		name := wt.Branch
-		if i == 0 {
-			name = "root"
-		} else if name == "" {
-			name = wt.Name
-		}
-
-		isCurrent := (i == 0 && current == "root") || (i > 0 && current == wt.Name)
-		if isCurrent {
-			fmt.Fprintf(w, "%s* %s%s\n", blue, name, reset)
-		} else {
-			fmt.Fprintf(w, "  %s\n", name)
-		}
-	}
-}
-
 func parseWorktreeList(output string) []WorktreeInfo {
 	var list []WorktreeInfo
 	var current WorktreeInfo
@@ -357,6 +337,8 @@ func parseWorktreeList(output string) []WorktreeInfo {
 			current.Branch = branch
 		} else if line == "bare" {
 			current.Bare = true
+		} else if line == "prunable" || strings.HasPrefix(line, "prunable ") {
+			current.Prunable = true
 		}
 	}
 
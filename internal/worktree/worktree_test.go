@@ -1,7 +1,7 @@
 package worktree
 
 import (
-	"bytes"
+	"strings"
 	"testing"
 )
 
@@ -250,6 +250,7 @@ branch refs/heads/feature
 worktree /repo/detached
 HEAD ghi789
 detached
+prunable gitdir file points to non-existent location
 `
 
 	git := &mockGit{
@@ -295,6 +296,9 @@ detached
 	if list[2].Branch != "" {
 		t.Errorf("list[2].Branch = %q, want empty", list[2].Branch)
 	}
+	if list[1].Prunable || !list[2].Prunable {
+		t.Errorf("prunable = %v, %v; want false, true", list[1].Prunable, list[2].Prunable)
+	}
 }
 
 func TestManagerPath(t *testing.T) {
@@ -403,6 +407,7 @@ bare
 worktree /repo/detached
 HEAD ghi789
 detached
+prunable gitdir file points to non-existent location
 `
 
 	list := parseWorktreeList(porcelain)
@@ -427,6 +432,9 @@ detached
 	if list[2].Branch != "" {
 		t.Errorf("list[2].Branch = %q, want empty", list[2].Branch)
 	}
+	if list[1].Prunable || !list[2].Prunable {
+		t.Errorf("prunable = %v, %v; want false, true", list[1].Prunable, list[2].Prunable)
+	}
 }
 
 func TestValidateName(t *testing.T) {
@@ -513,53 +521,6 @@ func TestManagerPathSlashName(t *testing.T) {
 	}
 }
 
-func TestFormatList(t *testing.T) {
-	list := []WorktreeInfo{
-		{Path: "/repo/main", Branch: "main", Name: "main"},
-		{Path: "/home/.fitz/o/r/feature", Branch: "feature", Name: "feature"},
-		{Path: "/home/.fitz/o/r/feat-login", Branch: "feat/login", Name: "feat-login"},
-		{Path: "/home/.fitz/o/r/detached", Branch: "", Name: "detached"},
-	}
-
-	tests := []struct {
-		name    string
-		current string
-		want    string
-	}{
-		{
-			name:    "current is root",
-			current: "root",
-			want:    "\x1b[34m* root\x1b[0m\n  feature\n  feat/login\n  detached\n",
-		},
-		{
-			name:    "current is feature",
-			current: "feature",
-			want:    "  root\n\x1b[34m* feature\x1b[0m\n  feat/login\n  detached\n",
-		},
-		{
-			name:    "current is slash branch",
-			current: "feat-login",
-			want:    "  root\n  feature\n\x1b[34m* feat/login\x1b[0m\n  detached\n",
-		},
-		{
-			name:    "no current match",
-			current: "other",
-			want:    "  root\n  feature\n  feat/login\n  detached\n",
-		},
-	}
-
-	for _, tc := range tests {
-		t.Run(tc.name, func(t *testing.T) {
-			var buf bytes.Buffer
-			FormatList(&buf, list, tc.current)
-			got := buf.String()
-			if got != tc.want {
-				t.Errorf("got %q, want %q", got, tc.want)
-			}
-		})
-	}
-}
-
 func TestManagerRemoveAll(t *testing.T) {
 	porcelain := `worktree /repo/main
 HEAD abc123
@@ -648,6 +609,41 @@ branch refs/heads/feature
 	}
 }
 
+func TestManagerRemoveWorktrees(t *testing.T) {
+	git := &mockGit{
+		outputs: map[string]string{
+			"/test/repo:worktree remove /home/user/.fitz/owner/repo/bugfix ": "",
+			"/test/repo:worktree prune ":                                     "",
+			"/test/repo:branch -D bugfix ":                                   "",
+		},
+		errs: make(map[string]error),
+	}
+	m := &Manager{Git: git, HomeDir: "/home/user"}
+
+	wts := []WorktreeInfo{{Path: "/home/user/.fitz/owner/repo/bugfix", Branch: "bugfix"}}
+	removed, err := m.RemoveWorktrees("/test/repo", wts, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != "bugfix" {
+		t.Fatalf("removed = %v, want [bugfix]", removed)
+	}
+	for _, call := range git.calls {
+		if strings.Contains(strings.Join(call, " "), "feature") {
+			t.Errorf("unexpected call for a worktree that wasn't given: %v", call)
+		}
+	}
+
+	// Nothing to remove: no git commands, not even prune.
+	git.calls = nil
+	if removed, err := m.RemoveWorktrees("/test/repo", nil, false); err != nil || len(removed) != 0 {
+		t.Fatalf("RemoveWorktrees(nil) = %v, %v", removed, err)
+	}
+	if len(git.calls) != 0 {
+		t.Errorf("calls = %v, want none", git.calls)
+	}
+}
+
 func TestManagerRemoveAllNoWorktrees(t *testing.T) {
 	porcelain := `worktree /repo/main
 HEAD abc123